@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+)
+
+func runSubcommand(name string, args []string) int {
+	switch name {
+	case "fms":
+		return runFMS(args)
+	case "track":
+		return runTrack(args)
+	case "logbook":
+		return runLogbook(args)
+	case "rules":
+		return runRules(args)
+	case "status":
+		return runStatus(args)
+	case "prefile":
+		return runPrefile(args)
+	case "file":
+		return runFile(args)
+	case "cancel":
+		return runCancel(args)
+	case "resume":
+		return runResume(args)
+	case "reset":
+		return runReset(args)
+	case "pireps":
+		return runPIREPs(args)
+	case "log-level":
+		return runLogLevel(args)
+	case "fleet":
+		return runFleet(args)
+	case "airlines":
+		return runAirlines(args)
+	case "simbrief":
+		return runSimbrief(args)
+	case "doctor":
+		return runDoctor(args)
+	case "help":
+		printUsage()
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", name)
+		printUsage()
+		return 2
+	}
+}
+
+func printUsage() {
+	fmt.Fprint(os.Stderr, `Usage: pxp [flags]
+       pxp <command> [flags]
+
+Commands:
+  status       Show the active PIREP and live flight data
+  prefile      Prefile a PIREP, from flags or the latest SimBrief OFP
+  file         File the active PIREP, optionally overriding values from telemetry
+  cancel       Cancel the active PIREP
+  resume       Have the running PXP pick up an in-progress or paused PIREP
+  reset        Have the running PXP stop tracking the active PIREP, leaving it in phpVMS
+  pireps list  List your PIREPs
+  log-level    Show or change the running PXP's log levels, e.g. info,api=debug
+  fleet        List the aircraft you can fly, with their fares
+  airlines     List airlines
+  simbrief     Show the latest SimBrief OFP
+  fms          Export the latest SimBrief OFP as an X-Plane .fms flight plan
+  track        List recorded flight tracks or export one as GPX, KML, GeoJSON or Tacview ACMI
+  logbook      List logged flights, show totals by aircraft and airport, or export as CSV/JSON
+  rules        Print the default scoring rules, or validate a rule file with -check
+  doctor       Check the configuration, phpVMS, the UDP port, X-Plane and SimBrief
+  help         Show this help
+
+PIREP commands talk to a running PXP through its control socket (or
+CONTROL_ADDR) if there is one, and to phpVMS directly otherwise. Add -json for machine-readable output.
+
+Run without a command to start the UDP listener and TUI.
+`)
+}
+
+// configSource is where a command's configuration comes from.
+type configSource struct {
+	DotEnv  string
+	Profile string
+}
+
+func addConfigFlags(fs *flag.FlagSet) *configSource {
+	var source configSource
+	fs.StringVar(&source.DotEnv, "config", "", "Path to .env file")
+	fs.StringVar(&source.Profile, "profile", "", "Config file profile (default: PXP_PROFILE, or the file's default)")
+	return &source
+}
+
+func loadConfig(source configSource) (*config.Config, error) {
+	file, profile, err := selectProfile(source)
+	if err != nil {
+		return nil, err
+	}
+	return loadProfile(source, file, profile)
+}
+
+// selectProfile reads the config file and picks the profile to use, which is
+// "" if there's no file or it has several profiles and none was chosen.
+func selectProfile(source configSource) (*config.File, string, error) {
+	// The .env file can choose the profile and hold its API key
+	if err := config.LoadDotEnv(source.DotEnv); err != nil {
+		return nil, "", fmt.Errorf("failed to load configuration from .env file: %w", err)
+	}
+
+	file, err := config.LoadFile("")
+	if err != nil {
+		return nil, "", err
+	}
+	profile, err := file.SelectProfile(source.Profile)
+	if err != nil {
+		return nil, "", err
+	}
+	return file, profile, nil
+}
+
+// loadProfile loads the profile's settings, then the environment's over them,
+// then the profile's saved preferences.
+// migrateDataDir carries data from before profiles over to the only profile.
+// With more than one, which VA it belongs to isn't known, so it's left alone.
+func migrateDataDir(cfg *config.Config, file *config.File) {
+	legacy := cfg.LegacyData()
+	if len(legacy) == 0 {
+		return
+	}
+	if len(file.Profiles) > 1 {
+		fmt.Fprintf(os.Stderr, "Warning: %s is still in the shared data directory, move it into the data directory of the profile it belongs to\n", strings.Join(legacy, ", "))
+		return
+	}
+
+	moved, err := cfg.MigrateDataDir()
+	for _, path := range moved {
+		fmt.Fprintf(os.Stderr, "Moved %s into profile %s's data directory %s\n", path, cfg.Profile, cfg.DataDir)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to move data into profile %s's data directory: %v\n", cfg.Profile, err)
+	}
+}
+
+func loadProfile(source configSource, file *config.File, profile string) (*config.Config, error) {
+	cfg := config.DefaultConfig()
+	if profile != "" {
+		if err := cfg.ApplyProfile(file, profile); err != nil {
+			return nil, err
+		}
+	} else if len(file.Profiles) > 1 {
+		return nil, fmt.Errorf("choose a profile with -profile or PXP_PROFILE, one of: %s", strings.Join(file.Names(), ", "))
+	}
+
+	if err := cfg.LoadFromEnv(); err != nil {
+		return nil, fmt.Errorf("failed to load configuration from the environment: %w", err)
+	}
+
+	for _, warning := range config.KeyFileWarnings(source.DotEnv, file, profile) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	migrateDataDir(cfg, file)
+
+	// Encrypted preferences need the key to be read
+	if cfg.PrefsEncrypted {
+		if err := cfg.ResolveAPIKey(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.LoadPreferences(""); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading user preferences: %v\n", err)
+	}
+
+	return cfg, nil
+}
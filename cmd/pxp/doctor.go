@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/doctor"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	listen := fs.Duration("listen", 10*time.Second, "How long to wait for a packet from X-Plane")
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*source)
+	opts := doctor.Options{Config: cfg, ConfigErr: err, Listen: *listen}
+	if cfg != nil {
+		if client := dialRunning(cfg); client != nil {
+			opts.Running = func(ctx context.Context) (udp.MetricsSnapshot, error) {
+				snapshot, err := client.Snapshot(ctx)
+				return snapshot.MetricsSnapshot, err
+			}
+		}
+	}
+
+	if !*asJSON {
+		fmt.Fprintf(os.Stderr, "Checking, listening for X-Plane for up to %s...\n\n", listen.Round(time.Second))
+	}
+	report := doctor.Run(context.Background(), opts)
+	if *asJSON {
+		printJSON(report)
+	} else {
+		report.Write(os.Stdout)
+	}
+	if report.Failed() {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+func runFleet(args []string) int {
+	fs := flag.NewFlagSet("fleet", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	fleet, err := backend.Fleet(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get fleet: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		if fleet == nil {
+			fleet = []models.AircraftFleet{}
+		}
+		return printJSON(fleet)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tREGISTRATION\tTYPE\tNAME\tSUBFLEET\tAT\tSTATUS\tFUEL (KG)\tFARES")
+	for _, subfleet := range fleet {
+		fares := make([]string, len(subfleet.Fares))
+		for i, fare := range subfleet.Fares {
+			fares[i] = fmt.Sprintf("%s #%d", fare.Code, fare.ID)
+		}
+		for _, aircraft := range subfleet.Aircraft {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%.0f\t%s\n",
+				aircraft.ID,
+				aircraft.Registration,
+				aircraft.ICAO,
+				aircraft.Name,
+				subfleet.Name,
+				aircraft.AirportID,
+				aircraft.StatusText(),
+				aircraft.FuelOnboard.Kg,
+				strings.Join(fares, ", "))
+		}
+	}
+	w.Flush()
+	return 0
+}
+
+func runAirlines(args []string) int {
+	fs := flag.NewFlagSet("airlines", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	airlines, err := backend.Airlines(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get airlines: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		if airlines == nil {
+			airlines = []models.Airline{}
+		}
+		return printJSON(airlines)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tICAO\tIATA\tNAME")
+	for _, airline := range airlines {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", airline.ID, airline.ICAO, airline.IATA, airline.Name)
+	}
+	w.Flush()
+	return 0
+}
+
+func runSimbrief(args []string) int {
+	fs := flag.NewFlagSet("simbrief", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	plan, err := fetchPlan(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(plan)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Flight\t%s\n", plan.FlightNumber)
+	fmt.Fprintf(w, "Route\t%s-%s (alternate %s)\n", plan.Origin, plan.Destination, plan.Alternate)
+	fmt.Fprintf(w, "Level\t%d ft\n", plan.Altitude)
+	fmt.Fprintf(w, "Distance\t%d nm\n", plan.Distance)
+	fmt.Fprintf(w, "Flight time\t%s\n", formatMinutes(plan.FlightTime))
+	fmt.Fprintf(w, "Block fuel\t%d kg\n", plan.BlockFuel)
+	if plan.ZFW > 0 {
+		fmt.Fprintf(w, "ZFW\t%d kg\n", plan.ZFW)
+	}
+	if plan.Passengers > 0 {
+		fmt.Fprintf(w, "Passengers\t%d\n", plan.Passengers)
+	}
+	fmt.Fprintf(w, "Routing\t%s\n", plan.Route)
+	w.Flush()
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
+)
+
+func runFMS(args []string) int {
+	fs := flag.NewFlagSet("fms", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	outputDir := fs.String("out", "", "Directory to write the .fms file to (overrides FMS_OUTPUT_DIR)")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	if cfg.SimbriefUserID == "" {
+		fmt.Fprintln(os.Stderr, "SIMBRIEF_USER_ID is required to export an FMS plan")
+		return 1
+	}
+
+	dir := cfg.FMSOutputDir
+	if *outputDir != "" {
+		dir = *outputDir
+	}
+
+	logger := logging.SetupLogger(cfg.LogLevel)
+	apiClient := api.NewClient(cfg.PhpVMSBaseURL, cfg.PhpVMSAPIKey, logger)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ofp, err := apiClient.GetSimbriefOFP(ctx, cfg.SimbriefUserID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to fetch SimBrief OFP: %v\n", err)
+		return 1
+	}
+
+	path, err := flightplan.ExportFMS(dir, ofp)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export FMS plan: %v\n", err)
+		return 1
+	}
+
+	fmt.Println(path)
+	return 0
+}
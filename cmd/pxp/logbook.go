@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
+)
+
+func runLogbook(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: pxp logbook <list|totals|export> [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return runLogbookList(args[1:])
+	case "totals":
+		return runLogbookTotals(args[1:])
+	case "export":
+		return runLogbookExport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown logbook command: %s\n", args[0])
+		return 2
+	}
+}
+
+func loadLogbook(source configSource, filter string) ([]logbook.Entry, error) {
+	cfg, err := loadConfig(source)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := logbook.NewStore(cfg.LogbookPath()).List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logbook: %w", err)
+	}
+	return logbook.Filter(entries, filter), nil
+}
+
+func runLogbookList(args []string) int {
+	fs := flag.NewFlagSet("logbook list", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	filter := fs.String("filter", "", "Only show flights matching this flight number, airport, aircraft or state")
+	fs.Parse(args)
+
+	entries, err := loadLogbook(*source, *filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tFLIGHT\tROUTE\tAIRCRAFT\tTIME\tDIST\tLANDING\tSTATE")
+	for _, entry := range entries {
+		landing := "-"
+		if entry.LandingRate != nil {
+			landing = fmt.Sprintf("%.0f fpm", *entry.LandingRate)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s-%s\t%s\t%s\t%.0f nm\t%s\t%s\n",
+			entry.PrefiledAt.Local().Format(time.DateOnly),
+			entry.FlightNumber,
+			entry.Departure,
+			entry.Arrival,
+			entry.Registration,
+			formatMinutes(entry.FlightTime),
+			entry.Distance,
+			landing,
+			entry.State)
+	}
+	w.Flush()
+	return 0
+}
+
+func runLogbookTotals(args []string) int {
+	fs := flag.NewFlagSet("logbook totals", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	filter := fs.String("filter", "", "Only count flights matching this flight number, airport, aircraft or state")
+	fs.Parse(args)
+
+	entries, err := loadLogbook(*source, *filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	printTotals(w, "AIRCRAFT", logbook.TotalsByAircraft(entries))
+	fmt.Fprintln(w)
+	printTotals(w, "AIRPORT", logbook.TotalsByAirport(entries))
+	w.Flush()
+	return 0
+}
+
+func printTotals(w io.Writer, heading string, totals []logbook.Total) {
+	fmt.Fprintf(w, "%s\tFLIGHTS\tTIME\tDIST\tFUEL\n", heading)
+	for _, total := range totals {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%.0f nm\t%.0f kg\n",
+			total.Key,
+			total.Flights,
+			formatMinutes(total.FlightTime),
+			total.Distance,
+			total.FuelUsed)
+	}
+}
+
+func runLogbookExport(args []string) int {
+	fs := flag.NewFlagSet("logbook export", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	format := fs.String("format", "csv", "Export format: csv or json")
+	filter := fs.String("filter", "", "Only export flights matching this flight number, airport, aircraft or state")
+	outputPath := fs.String("out", "", "File to write to (default: stdout)")
+	fs.Parse(args)
+
+	entries, err := loadLogbook(*source, *filter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *outputPath != "" {
+		file, err := os.Create(*outputPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *outputPath, err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch *format {
+	case "csv":
+		err = logbook.WriteCSV(w, entries)
+	case "json":
+		err = logbook.WriteJSON(w, entries)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown logbook format: %s\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to export logbook: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func formatMinutes(minutes int) string {
+	return fmt.Sprintf("%d:%02d", minutes/60, minutes%60)
+}
@@ -4,33 +4,49 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
+	"github.com/julietrb1/phpvms-xplane/internal/acars"
+	"github.com/julietrb1/phpvms-xplane/internal/airports"
 	"github.com/julietrb1/phpvms-xplane/internal/api"
 	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/control"
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
 	"github.com/julietrb1/phpvms-xplane/internal/logging"
+	"github.com/julietrb1/phpvms-xplane/internal/metrics"
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
 	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
 	"github.com/julietrb1/phpvms-xplane/internal/tui"
 	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/internal/web"
 )
 
 func main() {
-	var configFile string
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		os.Exit(runSubcommand(os.Args[1], os.Args[2:]))
+	}
+
 	var enableTUI bool
-	flag.StringVar(&configFile, "config", "", "Path to config file")
+	source := addConfigFlags(flag.CommandLine)
 	flag.BoolVar(&enableTUI, "tui", true, "Enable Terminal User Interface")
 	flag.Parse()
 
-	cfg := config.DefaultConfig()
-	if err := cfg.LoadFromDotEnv(configFile); err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading configuration from .env file: %v\n", err)
-		os.Exit(1)
+	file, profile, err := selectProfile(*source)
+	if err == nil && profile == "" && len(file.Profiles) > 1 && enableTUI {
+		profile, err = tui.PickProfile(file)
 	}
-
-	if err := cfg.LoadPreferences(""); err != nil {
-		fmt.Fprintf(os.Stderr, "Error loading user preferences: %v\n", err)
+	var cfg *config.Config
+	if err == nil {
+		cfg, err = loadProfile(*source, file, profile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		os.Exit(1)
 	}
 
 	cfg.TUIEnabled = enableTUI
@@ -40,19 +56,62 @@ func main() {
 		os.Exit(1)
 	}
 
-	logger := logging.SetupLogger(cfg.LogLevel)
+	// Before the TUI takes the terminal, in case the key command prompts
+	if err := cfg.ResolveAPIKey(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get the API key: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Anything written to the terminal would end up over the TUI, which shows
+	// recent records itself
+	logFile := cfg.LogFile
+	bufferSize := 0
+	if cfg.TUIEnabled {
+		logFile = cfg.LogFilePath()
+		bufferSize = 1000
+	}
+	logs, err := logging.New(logging.Options{
+		Level:      cfg.LogLevel,
+		Format:     cfg.LogFormat,
+		File:       logFile,
+		MaxSize:    cfg.LogMaxSize,
+		MaxAge:     cfg.LogMaxAge,
+		MaxBackups: cfg.LogMaxBackups,
+		Secrets:    []string{cfg.PhpVMSAPIKey.Reveal(), cfg.ControlToken},
+		BufferSize: bufferSize,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up logging: %v\n", err)
+		os.Exit(1)
+	}
+	defer logs.Close()
+	if logFile != "" {
+		fmt.Fprintf(os.Stderr, "Logging to %s\n", logFile)
+	}
+
+	logger := logs.Logger
 	logger.Info("Starting phpVMS ACARS client",
 		"udp_bind", fmt.Sprintf("%s:%d", cfg.UDPBindHost, cfg.UDPBindPort),
 	)
 
-	apiClient := api.NewClient(cfg.PhpVMSBaseURL, cfg.PhpVMSAPIKey, logger)
-	flightService := service.NewFlightService(apiClient, logger)
+	flightService, err := newFlightService(cfg, logger)
+	if err != nil {
+		logger.Error("Failed to set up flight service", "error", err)
+		os.Exit(1)
+	}
 	udpListener, err := udp.NewListener(cfg.UDPBindHost, cfg.UDPBindPort, flightService, logger)
 	if err != nil {
 		logger.Error("Failed to create UDP listener", "error", err)
 		os.Exit(1)
 	}
 
+	// The observer is set before anything that makes requests starts
+	var exporter *metrics.Exporter
+	if cfg.MetricsAddr != "" {
+		exporter = metrics.NewExporter(udpListener.GetMetrics(), flightService, logger)
+		flightService.Client.Observer = exporter.ObserveRequest
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
@@ -64,11 +123,59 @@ func main() {
 		cancel()
 	}()
 
+	go flightService.Outbox.Run(ctx)
+
+	live := config.NewLive(cfg)
+	reload := newReloader(*source, live, logs, udpListener, flightService)
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go reload.handleSignals(ctx, hupCh)
+
+	controlToken := cfg.ControlToken
+	if controlToken == "" {
+		if controlToken, err = control.LoadToken(cfg.ControlTokenPath()); err != nil {
+			logger.Warn("Failed to load control token, the control socket is disabled", "error", err)
+		}
+		logs.Redact(controlToken)
+	}
+	local := control.NewLocal(flightService, udpListener.GetMetrics())
+	local.Levels = logs.Levels
+	controlServer := control.NewServer(local, controlToken, logger)
+	go func() {
+		if err := controlServer.Serve(ctx, cfg.ControlSocketPath()); err != nil {
+			logger.Warn("Control socket unavailable, CLI commands will use the API directly", "error", err)
+		}
+	}()
+	if cfg.ControlAddr != "" {
+		go func() {
+			if err := controlServer.ListenAndServe(ctx, cfg.ControlAddr); err != nil {
+				logger.Warn("Control API unavailable", "addr", cfg.ControlAddr, "error", err)
+			}
+		}()
+	}
+
+	if exporter != nil {
+		go func() {
+			if err := exporter.Serve(ctx, cfg.MetricsAddr); err != nil {
+				logger.Warn("Metrics endpoint unavailable", "addr", cfg.MetricsAddr, "error", err)
+			}
+		}()
+	}
+
+	if cfg.WebAddr != "" {
+		go func() {
+			if err := web.NewServer(local, logger).Serve(ctx, cfg.WebAddr); err != nil {
+				logger.Warn("Web dashboard unavailable", "addr", cfg.WebAddr, "error", err)
+			}
+		}()
+	}
+
 	if cfg.TUIEnabled {
 		logger.Info("Starting Terminal User Interface")
 		go func() {
-			if err := tui.Run(ctx, cancel, udpListener.GetMetrics(), flightService, cfg, logger); err != nil {
+			if err := tui.Run(ctx, cancel, udpListener.GetMetrics(), flightService, live, reload, logs.Buffer, logger); err != nil {
 				logger.Error("TUI error", "error", err)
+				fmt.Fprintf(os.Stderr, "TUI error: %v\n", err)
 				os.Exit(1)
 			}
 		}()
@@ -82,3 +189,24 @@ func main() {
 
 	logger.Info("Shutdown complete")
 }
+
+// newFlightService sets up the flight service with its track recorder,
+// logbook, ACARS outbox and scoring rules.
+func newFlightService(cfg *config.Config, logger *slog.Logger) (*service.FlightService, error) {
+	apiClient := api.NewClient(cfg.PhpVMSBaseURL, cfg.PhpVMSAPIKey, logger)
+	flightService := service.NewFlightService(apiClient, logger)
+	flightService.Recorder = track.NewRecorder(track.NewStore(cfg.TracksDir()), logger)
+	flightService.Logbook = logbook.NewStore(cfg.LogbookPath())
+	flightService.Outbox = acars.NewOutbox(cfg.OutboxPath(), apiClient, logger)
+	flightService.Airports = airports.NewCache(cfg.AirportsPath(), apiClient, logger)
+	flightService.SourceName = cfg.SourceName
+	if cfg.RulesFile != "" {
+		ruleSet, err := rules.Load(cfg.RulesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load rules: %w", err)
+		}
+		flightService.Rules = rules.NewEngine(ruleSet)
+		logger.Info("Loaded scoring rules", "file", cfg.RulesFile, "rules", len(ruleSet.Rules))
+	}
+	return flightService, nil
+}
@@ -0,0 +1,698 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/control"
+	"github.com/julietrb1/phpvms-xplane/internal/fleet"
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
+	"github.com/julietrb1/phpvms-xplane/internal/prefile"
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+const commandTimeout = 2 * time.Minute
+
+// connect returns the running PXP if one is listening on the control socket
+// or CONTROL_ADDR, otherwise a backend that uses the phpVMS API directly.
+func connect(source configSource) (*config.Config, control.Backend, error) {
+	cfg, err := loadConfig(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if client := dialRunning(cfg); client != nil {
+		return cfg, client, nil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	if err := cfg.ResolveAPIKey(context.Background()); err != nil {
+		return nil, nil, err
+	}
+	flightService, err := newFlightService(cfg, cliLogger(cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+	return cfg, control.NewLocal(flightService, nil), nil
+}
+
+// cliLogger logs warnings to stderr, keeping stdout for output, with the API
+// key redacted.
+func cliLogger(cfg *config.Config) *slog.Logger {
+	logs, err := logging.New(logging.Options{
+		Level:   "warn",
+		Output:  os.Stderr,
+		Secrets: []string{cfg.PhpVMSAPIKey.Reveal(), cfg.ControlToken},
+	})
+	if err != nil {
+		return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	}
+	return logs.Logger
+}
+
+// dialRunning returns a client for the running PXP, or nil if there isn't
+// one.
+func dialRunning(cfg *config.Config) *control.Client {
+	token := cfg.ControlToken
+	if token == "" {
+		// A running PXP saves its token here when it starts
+		token, _ = control.ReadToken(cfg.ControlTokenPath())
+	}
+	if client, err := control.Dial(cfg.ControlSocketPath(), token); err == nil {
+		return client
+	}
+	if cfg.ControlAddr != "" {
+		if client, err := control.DialHTTP(cfg.ControlAddr, token); err == nil {
+			return client
+		}
+	}
+	return nil
+}
+
+func printJSON(v interface{}) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write JSON: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// listFlag collects a flag that can be given more than once.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func runStatus(args []string) int {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	status, err := backend.Status(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get status: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(status)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if status.Running {
+		fmt.Fprintln(w, "PXP\trunning")
+	} else {
+		fmt.Fprintln(w, "PXP\tnot running")
+	}
+	if status.PirepID == "" {
+		fmt.Fprintln(w, "PIREP\tnone")
+		w.Flush()
+		return 0
+	}
+	fmt.Fprintf(w, "PIREP\t%s (%s)\n", status.PirepID, status.State)
+	fmt.Fprintf(w, "Flight\t%s %s-%s\n", status.FlightNumber, status.Departure, status.Arrival)
+	if status.Phase != "" {
+		fmt.Fprintf(w, "Phase\t%s\n", status.Phase)
+	}
+	if status.FlightTime != nil {
+		fmt.Fprintf(w, "Flight time\t%s\n", formatMinutes(*status.FlightTime))
+	}
+	if status.Distance != nil {
+		fmt.Fprintf(w, "Distance\t%d nm\n", *status.Distance)
+	}
+	if status.Fuel != nil {
+		fmt.Fprintf(w, "Fuel on board\t%d kg\n", *status.Fuel)
+	}
+	if status.Score != nil {
+		fmt.Fprintf(w, "Score\t%d (%d violations)\n", *status.Score, status.Violations)
+	}
+	if status.LastPacket != nil {
+		fmt.Fprintf(w, "Last packet\t%s\n", status.LastPacket.Local().Format(time.RFC3339))
+	}
+	if status.Running {
+		fmt.Fprintf(w, "ACARS queued\t%d\n", status.PendingACARS)
+	}
+	w.Flush()
+	return 0
+}
+
+func runPrefile(args []string) int {
+	fs := flag.NewFlagSet("prefile", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fromSimbrief := fs.Bool("simbrief", false, "Fill in the flight from the latest SimBrief OFP; other flags override it")
+	airlineID := fs.Int("airline", 0, "Airline ID (default: the OFP's with -simbrief, else the selected airline)")
+	aircraftID := fs.Int("aircraft", 0, "Aircraft ID (default: the OFP's with -simbrief, else the selected aircraft)")
+	flightNumber := fs.String("flight", "", "Flight number (default: the OFP's with -simbrief)")
+	departure := fs.String("dep", "", "Departure airport ICAO")
+	arrival := fs.String("arr", "", "Arrival airport ICAO")
+	alternate := fs.String("alt", "", "Alternate airport ICAO")
+	route := fs.String("route", "", "Route")
+	level := fs.Int("level", 0, "Cruise altitude (ft)")
+	distance := fs.Int("distance", 0, "Planned distance (nm)")
+	flightTime := fs.Int("time", 0, "Planned flight time (min)")
+	blockFuel := fs.Int("block-fuel", 0, "Block fuel (kg)")
+	callsign := fs.String("callsign", "", "Network callsign")
+	flightType := fs.String("type", "", "phpVMS flight type code, e.g. J or F (default: FLIGHT_TYPE)")
+	network := fs.String("network", "", "Network: Offline, VATSIM, IVAO or PilotEdge (default: NETWORK)")
+	simulator := fs.String("simulator", "", "Simulator (default: SIMULATOR, or as reported by X-Plane)")
+	var fields listFlag
+	fs.Var(&fields, "field", "Custom PIREP field as Name=value, overriding the template; repeatable")
+	fs.Parse(args)
+
+	cfg, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	data := api.PrefilePIREPRequest{
+		AirlineID:  cfg.SelectedAirlineID,
+		AircraftID: cfg.SelectedAircraftID,
+		FlightType: cfg.FlightType,
+		Source:     1,
+		SourceName: cfg.SourceName,
+	}
+
+	// A running PXP knows what X-Plane is sending
+	var detected *udp.Simulator
+	if snapshot, err := backend.Snapshot(ctx); err == nil {
+		detected = snapshot.LastSimulator
+	}
+
+	var hints *fleet.Hints
+	if *fromSimbrief {
+		plan, err := fetchPlan(ctx, cfg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		data.DepartureAirportID = plan.Origin
+		data.ArrivalAirportID = plan.Destination
+		data.FlightNumber = plan.FlightNumber
+		data.AlternateAirportID = plan.Alternate
+		data.Route = plan.Route
+		data.Level = plan.Altitude
+		data.PlannedDistance = plan.Distance
+		data.PlannedFlightTime = plan.FlightTime
+		data.BlockFuel = kgToLbs(plan.BlockFuel)
+		if *callsign == "" {
+			*callsign = plan.FlightNumber
+		}
+		hints = &fleet.Hints{Registration: plan.Registration, AircraftType: plan.AircraftType, Airline: plan.Airline}
+		if detected != nil {
+			hints.TailNumber = detected.TailNumber
+			hints.SimAircraftType = detected.AircraftICAO
+		}
+	}
+
+	// The OFP's aircraft and airline rather than the last selected, unless
+	// they're given
+	var subfleets []models.AircraftFleet
+	var airlines []models.Airline
+	if hints != nil {
+		if subfleets, err = backend.Fleet(ctx); err == nil {
+			airlines, err = backend.Airlines(ctx)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to match the OFP's aircraft to the fleet: %v\n", err)
+			return 1
+		}
+		aircraft, airline := fleet.Match(*hints, subfleets, airlines)
+		if aircraft != nil {
+			data.AircraftID = aircraft.ID
+		}
+		if airline != nil {
+			data.AirlineID = airline.ID
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "airline":
+			data.AirlineID = *airlineID
+		case "aircraft":
+			data.AircraftID = *aircraftID
+		case "dep":
+			data.DepartureAirportID = strings.ToUpper(*departure)
+		case "arr":
+			data.ArrivalAirportID = strings.ToUpper(*arrival)
+		case "alt":
+			data.AlternateAirportID = strings.ToUpper(*alternate)
+		case "route":
+			data.Route = *route
+		case "level":
+			data.Level = *level
+		case "distance":
+			data.PlannedDistance = *distance
+		case "time":
+			data.PlannedFlightTime = *flightTime
+		case "block-fuel":
+			data.BlockFuel = kgToLbs(*blockFuel)
+		case "flight":
+			data.FlightNumber = *flightNumber
+		}
+	})
+
+	if *flightType != "" {
+		found, ok := prefile.FindFlightType(*flightType)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown flight type %q\n", *flightType)
+			return 2
+		}
+		data.FlightType = found.Code
+	}
+	values := prefile.Values{Callsign: *callsign, FlightType: data.FlightType}
+	values.Network, _ = prefile.FindNetwork(cfg.Network)
+	if *network != "" {
+		found, ok := prefile.FindNetwork(*network)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Network must be one of: %s\n", strings.Join(prefile.Networks, ", "))
+			return 2
+		}
+		values.Network = found
+	}
+	values.Simulator = *simulator
+	if values.Simulator == "" {
+		values.Simulator = service.SimulatorName(cfg.Simulator, detected)
+	}
+
+	overrides := map[string]string{}
+	for _, field := range fields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok || name == "" {
+			fmt.Fprintf(os.Stderr, "Invalid field %q, expected Name=value\n", field)
+			return 2
+		}
+		overrides[name] = value
+	}
+	template, err := cfg.PrefileTemplate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load prefile fields: %v\n", err)
+		return 1
+	}
+	if data.Fields, err = template.Fill(values, overrides); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	switch {
+	case data.AirlineID <= 0:
+		fmt.Fprintln(os.Stderr, "An airline is required, use -airline")
+		return 2
+	case data.AircraftID <= 0:
+		fmt.Fprintln(os.Stderr, "An aircraft is required, use -aircraft")
+		return 2
+	case data.FlightNumber == "":
+		fmt.Fprintln(os.Stderr, "A flight number is required, use -flight")
+		return 2
+	case data.DepartureAirportID == "" || data.ArrivalAirportID == "":
+		fmt.Fprintln(os.Stderr, "Departure and arrival airports are required, use -dep and -arr or -simbrief")
+		return 2
+	}
+
+	if hints != nil {
+		for _, mismatch := range fleetMismatches(*hints, subfleets, airlines, data.AircraftID, data.AirlineID) {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", mismatch)
+		}
+	}
+
+	pirepID, err := backend.Prefile(ctx, data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to prefile PIREP: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(map[string]string{"pirep_id": pirepID})
+	}
+	fmt.Printf("Prefiled PIREP %s\n", pirepID)
+	return 0
+}
+
+// fleetMismatches describes where the OFP and X-Plane disagree with the
+// aircraft and airline being prefiled.
+func fleetMismatches(hints fleet.Hints, subfleets []models.AircraftFleet, airlines []models.Airline, aircraftID, airlineID int) []string {
+	var aircraft *models.Aircraft
+	for _, subfleet := range subfleets {
+		for i := range subfleet.Aircraft {
+			if subfleet.Aircraft[i].ID == aircraftID {
+				aircraft = &subfleet.Aircraft[i]
+			}
+		}
+	}
+	var airline *models.Airline
+	for i := range airlines {
+		if airlines[i].ID == airlineID {
+			airline = &airlines[i]
+		}
+	}
+	return fleet.Mismatches(hints, subfleets, aircraft, airline)
+}
+
+func runFile(args []string) int {
+	fs := flag.NewFlagSet("file", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pirepID := fs.String("pirep", "", "PIREP ID (default: the active or latest in-progress or paused PIREP)")
+	flightTime := fs.Int("flight-time", 0, "Flight time (min)")
+	fuelUsed := fs.Int("fuel-used", 0, "Fuel used (kg)")
+	distance := fs.Int("distance", 0, "Distance flown (nm)")
+	landingRate := fs.Float64("landing-rate", 0, "Landing rate (ft/min)")
+	blockOff := fs.String("block-off", "", "Block off time (RFC 3339)")
+	blockOn := fs.String("block-on", "", "Block on time (RFC 3339)")
+	zfw := fs.Int("zfw", 0, "Zero fuel weight (kg)")
+	notes := fs.String("notes", "", "Notes for the reviewer")
+	var fares, fields listFlag
+	fs.Var(&fares, "fare", "Fare count as ID=count, repeatable (see pxp fleet for fare IDs)")
+	fs.Var(&fields, "field", "Custom PIREP field as Name=value, repeatable")
+	fs.Parse(args)
+
+	request := control.FileRequest{
+		PirepID:      *pirepID,
+		BlockOffTime: *blockOff,
+		BlockOnTime:  *blockOn,
+		Notes:        *notes,
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "flight-time":
+			request.FlightTime = flightTime
+		case "fuel-used":
+			request.FuelUsedKg = fuelUsed
+		case "distance":
+			request.Distance = distance
+		case "landing-rate":
+			request.LandingRate = landingRate
+		case "zfw":
+			request.ZFWKg = zfw
+		}
+	})
+	for _, value := range []string{*blockOff, *blockOn} {
+		if _, err := time.Parse(time.RFC3339, value); value != "" && err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid time %q, expected RFC 3339 like 2025-08-21T10:00:00Z\n", value)
+			return 2
+		}
+	}
+	for _, fare := range fares {
+		id, count, err := parseFare(fare)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		request.Fares = append(request.Fares, api.PIREPFare{ID: id, Count: count})
+	}
+	for _, field := range fields {
+		name, value, ok := strings.Cut(field, "=")
+		if !ok || name == "" {
+			fmt.Fprintf(os.Stderr, "Invalid field %q, expected Name=value\n", field)
+			return 2
+		}
+		if request.Fields == nil {
+			request.Fields = map[string]interface{}{}
+		}
+		request.Fields[name] = value
+	}
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	data, err := backend.File(ctx, request)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to file PIREP: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(data)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PIREP filed")
+	fmt.Fprintf(w, "Flight time\t%s\n", formatMinutes(data.FlightTime))
+	fmt.Fprintf(w, "Fuel used\t%d lbs\n", data.FuelUsedLbs)
+	fmt.Fprintf(w, "Distance\t%d nm\n", data.Distance)
+	if data.LandingRate != nil {
+		fmt.Fprintf(w, "Landing rate\t%.0f fpm\n", *data.LandingRate)
+	}
+	if data.Score != nil {
+		fmt.Fprintf(w, "Score\t%d\n", *data.Score)
+	}
+	w.Flush()
+	return 0
+}
+
+func parseFare(value string) (int, int, error) {
+	id, count, ok := strings.Cut(value, "=")
+	fareID, idErr := strconv.Atoi(id)
+	n, countErr := strconv.Atoi(count)
+	if !ok || idErr != nil || countErr != nil || n < 0 {
+		return 0, 0, fmt.Errorf("invalid fare %q, expected ID=count", value)
+	}
+	return fareID, n, nil
+}
+
+func runCancel(args []string) int {
+	fs := flag.NewFlagSet("cancel", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pirepID := fs.String("pirep", "", "PIREP ID (default: the active or latest in-progress or paused PIREP)")
+	fs.Parse(args)
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	cancelledID, err := backend.Cancel(ctx, *pirepID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to cancel PIREP: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(map[string]string{"pirep_id": cancelledID})
+	}
+	fmt.Printf("Cancelled PIREP %s\n", cancelledID)
+	return 0
+}
+
+func runReset(args []string) int {
+	fs := flag.NewFlagSet("reset", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	fs.Parse(args)
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	resetID, err := backend.Reset(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reset PIREP: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(map[string]string{"pirep_id": resetID})
+	}
+	if resetID == "" {
+		fmt.Println("No active PIREP")
+		return 0
+	}
+	fmt.Printf("Stopped tracking PIREP %s, it is still in progress in phpVMS\n", resetID)
+	return 0
+}
+
+func runLogLevel(args []string) int {
+	fs := flag.NewFlagSet("log-level", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: pxp log-level [flags] [level]")
+		fmt.Fprintln(os.Stderr, "Show or change the log levels of the running PXP, e.g. info,api=debug")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if fs.NArg() > 0 {
+		level, err := backend.SetLogLevel(ctx, fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to set log level: %v\n", err)
+			return 1
+		}
+		fmt.Println(level)
+		return 0
+	}
+
+	level, err := backend.LogLevel(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to get log level: %v\n", err)
+		return 1
+	}
+	fmt.Println(level)
+	return 0
+}
+
+func runResume(args []string) int {
+	fs := flag.NewFlagSet("resume", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	pirepID := fs.String("pirep", "", "PIREP ID (default: the latest in-progress or paused PIREP)")
+	fs.Parse(args)
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	if _, ok := backend.(*control.Local); ok {
+		fmt.Fprintln(os.Stderr, "PXP is not running, so the PIREP can't be tracked until it is started")
+		return 1
+	}
+
+	pirep, err := backend.Resume(ctx, *pirepID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to resume PIREP: %v\n", err)
+		return 1
+	}
+	if *asJSON {
+		return printJSON(pirep)
+	}
+	fmt.Printf("Resumed PIREP %s (%s %s-%s)\n", pirep.ID, pirep.FlightNumber, pirep.DptAirportID, pirep.ArrAirportID)
+	return 0
+}
+
+func runPIREPs(args []string) int {
+	if len(args) == 0 || args[0] != "list" {
+		fmt.Fprintln(os.Stderr, "Usage: pxp pireps list [flags]")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("pireps list", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	asJSON := fs.Bool("json", false, "Print JSON")
+	state := fs.String("state", "", "Only list PIREPs in this state, e.g. IN_PROGRESS or PENDING")
+	fs.Parse(args[1:])
+
+	_, backend, err := connect(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	pireps, err := backend.PIREPs(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list PIREPs: %v\n", err)
+		return 1
+	}
+	if *state != "" {
+		var filtered []models.ListedPIREP
+		for _, pirep := range pireps {
+			if strings.EqualFold(models.PirepState(pirep.State).String(), *state) {
+				filtered = append(filtered, pirep)
+			}
+		}
+		pireps = filtered
+	}
+	if *asJSON {
+		if pireps == nil {
+			pireps = []models.ListedPIREP{}
+		}
+		return printJSON(pireps)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFLIGHT\tROUTE\tAIRCRAFT\tSTATE\tCREATED")
+	for _, pirep := range pireps {
+		fmt.Fprintf(w, "%s\t%s\t%s-%s\t%s\t%s\t%s\n",
+			pirep.ID,
+			pirep.FlightNumber,
+			pirep.DptAirportID,
+			pirep.ArrAirportID,
+			pirep.Aircraft.Registration,
+			models.PirepState(pirep.State),
+			pirep.CreatedAt.Local().Format(time.DateTime))
+	}
+	w.Flush()
+	return 0
+}
+
+func fetchPlan(ctx context.Context, cfg *config.Config) (flightplan.Plan, error) {
+	if cfg.SimbriefUserID == "" {
+		return flightplan.Plan{}, fmt.Errorf("SIMBRIEF_USER_ID is not set")
+	}
+
+	apiClient := api.NewClient(cfg.PhpVMSBaseURL, cfg.PhpVMSAPIKey, cliLogger(cfg))
+	ofp, err := apiClient.GetSimbriefOFP(ctx, cfg.SimbriefUserID)
+	if err != nil {
+		return flightplan.Plan{}, fmt.Errorf("failed to fetch SimBrief OFP: %w", err)
+	}
+	return flightplan.FromOFP(ofp)
+}
+
+func kgToLbs(kg int) int {
+	return int(math.Ceil(float64(kg) * 2.20462))
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+// reloader reloads the configuration while PXP runs, on SIGHUP or from the
+// TUI's Settings tab, so changing a setting doesn't lose the active PIREP.
+type reloader struct {
+	source        configSource
+	cfg           *config.Live
+	logs          *logging.Logging
+	listener      *udp.Listener
+	flightService *service.FlightService
+	logger        *slog.Logger
+	requests      chan struct{}
+}
+
+func newReloader(source configSource, cfg *config.Live, logs *logging.Logging, listener *udp.Listener, flightService *service.FlightService) *reloader {
+	return &reloader{
+		source:        source,
+		cfg:           cfg,
+		logs:          logs,
+		listener:      listener,
+		flightService: flightService,
+		logger:        logs.Logger,
+		requests:      make(chan struct{}, 1),
+	}
+}
+
+// Prepare loads the configuration again, from the same profile, without
+// applying it.
+func (r *reloader) Prepare(ctx context.Context) (*config.Config, config.Changes, error) {
+	current := r.cfg.Get()
+	if err := config.ReloadDotEnv(r.source.DotEnv); err != nil {
+		return nil, config.Changes{}, fmt.Errorf("failed to load configuration from .env file: %w", err)
+	}
+	file, err := config.LoadFile("")
+	if err != nil {
+		return nil, config.Changes{}, err
+	}
+
+	next := config.DefaultConfig()
+	if current.Profile != "" {
+		if err := next.ApplyProfile(file, current.Profile); err != nil {
+			return nil, config.Changes{}, err
+		}
+	}
+	if err := next.LoadFromEnv(); err != nil {
+		return nil, config.Changes{}, fmt.Errorf("failed to load configuration from the environment: %w", err)
+	}
+	next.TUIEnabled = current.TUIEnabled
+	if err := next.Validate(); err != nil {
+		return nil, config.Changes{}, fmt.Errorf("invalid configuration: %w", err)
+	}
+	for _, warning := range config.KeyFileWarnings(r.source.DotEnv, file, current.Profile) {
+		r.logger.Warn(warning)
+	}
+
+	// The command may prompt, which can't be answered under the TUI, so it's
+	// only run again if it changed
+	if next.PhpVMSAPIKeyCommand != "" && next.PhpVMSAPIKeyCommand == current.PhpVMSAPIKeyCommand {
+		next.PhpVMSAPIKey = current.PhpVMSAPIKey
+	}
+	if err := next.ResolveAPIKey(ctx); err != nil {
+		return nil, config.Changes{}, err
+	}
+	return next, current.Changes(next), nil
+}
+
+// Apply rebinds the UDP listener, sets the log levels and points the API
+// client at the new site or key, then takes the rest of the settings that can
+// change from next. Everything that can fail is checked or done first, so a
+// failure leaves the running configuration as it was.
+func (r *reloader) Apply(next *config.Config, changes config.Changes) error {
+	if changes.Has("LOG_LEVEL") {
+		if err := logging.ParseLevels(next.LogLevel); err != nil {
+			return err
+		}
+	}
+	if changes.Has("UDP_BIND_HOST", "UDP_BIND_PORT") {
+		if err := r.listener.Rebind(next.UDPBindHost, next.UDPBindPort); err != nil {
+			return err
+		}
+	}
+	if changes.Has("LOG_LEVEL") {
+		// Already parsed above, so this can't fail
+		r.logs.Levels.Set(next.LogLevel)
+	}
+	if changes.Credentials() {
+		r.logs.Redact(next.PhpVMSAPIKey.Reveal())
+		r.flightService.Client.SetCredentials(next.PhpVMSBaseURL, next.PhpVMSAPIKey)
+	}
+	r.cfg.Update(func(cfg *config.Config) {
+		cfg.Apply(next)
+	})
+
+	r.logger.Info("Reloaded configuration", "changed", changes.Applied)
+	if len(changes.Restart) > 0 {
+		r.logger.Warn("Some changes need PXP to be restarted", "settings", changes.Restart)
+	}
+	return nil
+}
+
+// Requests receives when the TUI should reload, so it can ask before leaving
+// a PIREP behind.
+func (r *reloader) Requests() <-chan struct{} {
+	return r.requests
+}
+
+// Reload loads and applies the configuration, unless that would leave the
+// active PIREP behind.
+func (r *reloader) Reload(ctx context.Context) error {
+	next, changes, err := r.Prepare(ctx)
+	if err != nil {
+		return err
+	}
+	if pirepID := r.flightService.GetActivePirepID(); pirepID != nil && changes.Credentials() {
+		return fmt.Errorf("the phpVMS site or API key changed while PIREP %s is active, file, cancel or reset it first, or reload from the TUI's Settings tab to confirm", *pirepID)
+	}
+	return r.Apply(next, changes)
+}
+
+// handleSignals reloads on every signal, through the TUI if it's running.
+func (r *reloader) handleSignals(ctx context.Context, signals <-chan os.Signal) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-signals:
+			r.logger.Info("Received signal, reloading configuration", "signal", sig)
+			if r.cfg.Get().TUIEnabled {
+				select {
+				case r.requests <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			if err := r.Reload(ctx); err != nil {
+				r.logger.Error("Failed to reload configuration", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+)
+
+func runRules(args []string) int {
+	fs := flag.NewFlagSet("rules", flag.ExitOnError)
+	checkPath := fs.String("check", "", "Validate a rule file instead of printing the defaults")
+	fs.Parse(args)
+
+	if *checkPath != "" {
+		ruleSet, err := rules.Load(*checkPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("%s: %d rules OK\n", *checkPath, len(ruleSet.Rules))
+		return 0
+	}
+
+	if err := rules.Default().Write(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write rules: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+)
+
+func runTrack(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: pxp track <list|export> [flags]")
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return runTrackList(args[1:])
+	case "export":
+		return runTrackExport(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown track command: %s\n", args[0])
+		return 2
+	}
+}
+
+func runTrackList(args []string) int {
+	fs := flag.NewFlagSet("track list", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	summaries, err := track.NewStore(cfg.TracksDir()).List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list tracks: %v\n", err)
+		return 1
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PIREP\tFLIGHT\tROUTE\tSTARTED")
+	for _, summary := range summaries {
+		fmt.Fprintf(w, "%s\t%s\t%s-%s\t%s\n",
+			summary.PirepID,
+			summary.Meta.FlightNumber,
+			summary.Meta.Departure,
+			summary.Meta.Arrival,
+			summary.Meta.StartedAt.Local().Format(time.RFC3339))
+	}
+	w.Flush()
+	return 0
+}
+
+func runTrackExport(args []string) int {
+	fs := flag.NewFlagSet("track export", flag.ExitOnError)
+	source := addConfigFlags(fs)
+	pirepID := fs.String("pirep", "", "PIREP ID of the track to export (default: most recent)")
+	formatName := fs.String("format", "all", "Export format: gpx, kml, geojson, acmi or all")
+	zipped := fs.Bool("zip", false, "Write Tacview ACMI exports as .zip.acmi")
+	outputDir := fs.String("out", "", "Directory to write exports to (overrides EXPORT_DIR)")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*source)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading configuration: %v\n", err)
+		return 1
+	}
+
+	formats := append([]track.Format(nil), track.Formats...)
+	if *formatName != "all" {
+		format, err := track.ParseFormat(*formatName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		formats = []track.Format{format}
+	}
+	if *zipped {
+		for i, format := range formats {
+			if format == track.FormatACMI {
+				formats[i] = track.FormatACMIZip
+			}
+		}
+	}
+
+	store := track.NewStore(cfg.TracksDir())
+	id := *pirepID
+	if id == "" {
+		if id, err = store.Latest(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to find a track: %v\n", err)
+			return 1
+		}
+	}
+
+	flightTrack, err := store.Load(id)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load track %s: %v\n", id, err)
+		return 1
+	}
+
+	dir := cfg.ExportsDir()
+	if *outputDir != "" {
+		dir = *outputDir
+	}
+
+	for _, format := range formats {
+		path, err := track.Export(dir, flightTrack, format)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to export %s: %v\n", format, err)
+			return 1
+		}
+		fmt.Println(path)
+	}
+	return 0
+}
@@ -82,6 +82,9 @@ end
 dataref("gs_ms", "sim/flightmodel/position/groundspeed", "readonly")     -- m/s
 dataref("on_ground", "sim/flightmodel/failures/onground_any", "readonly")
 dataref("eng1_running", "sim/flightmodel/engine/ENGN_running", "readonly", 0)
+dataref("num_engines", "sim/aircraft/engine/acf_num_engines", "readonly")
+dataref("gross_weight_kg", "sim/flightmodel/weight/m_total", "readonly")
+local engines_running = dataref_table("sim/flightmodel/engine/ENGN_running")
 dataref("paused", "sim/time/paused", "readonly")
 dataref("radalt_ft", "sim/cockpit2/gauges/indicators/radio_altimeter_height_ft_pilot", "readonly")
 dataref("dist_m", "sim/flightmodel/controls/dist", "readonly")
@@ -94,6 +97,12 @@ dataref("trk_mag", "sim/flightmodel/position/hpath", "readonly")
 dataref("ias", "sim/flightmodel/position/indicated_airspeed", "readonly")
 dataref("vs_ms", "sim/flightmodel/position/vh_ind", "readonly")
 dataref("alt_agl_m", "sim/flightmodel/position/y_agl", "readonly")
+-- Lights
+dataref("beacon_on", "sim/cockpit/electrical/beacon_lights_on", "readonly")
+dataref("nav_on", "sim/cockpit/electrical/nav_lights_on", "readonly")
+dataref("strobe_on", "sim/cockpit/electrical/strobe_lights_on", "readonly")
+dataref("landing_on", "sim/cockpit/electrical/landing_lights_on", "readonly")
+dataref("taxi_on", "sim/cockpit/electrical/taxi_light_on", "readonly")
 
 -- =====================
 -- Helpers
@@ -203,6 +212,14 @@ local function detect_status()
     return status
 end
 
+local function count_engines_running()
+    local count = 0
+    for i = 0, math.max(0, num_engines - 1) do
+        if engines_running[i] == 1 then count = count + 1 end
+    end
+    return count
+end
+
 local function osTimeToISO8601Zulu(timestamp)
     return os.date("!%Y-%m-%dT%H:%M:%SZ", timestamp)
 end
@@ -224,8 +241,24 @@ local function build_payload()
       ias = math.max(0, math.floor(ias)),
       vs = math.floor(fpm(vs_ms)),
     },
+    on_ground = on_ground == 1,
+    lights = {
+      beacon = beacon_on == 1,
+      nav = nav_on == 1,
+      strobe = strobe_on == 1,
+      landing = landing_on == 1,
+      taxi = taxi_on == 1,
+    },
     fuel = math.floor(fuel_1 + fuel_2 + fuel_3 + fuel_4),
+    engines_running = count_engines_running(),
+    gross_weight = math.floor(gross_weight_kg),
     flight_time = final_time_sec ~= 0 and final_time_sec or calculate_minutes(),
+    simulator = {
+      name = "X-Plane",
+      version = XPLANE_VERSION,
+      aircraft_icao = PLANE_ICAO,
+      tail_number = PLANE_TAILNUMBER,
+    },
   }
   return payload
 end
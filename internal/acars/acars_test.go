@@ -0,0 +1,222 @@
+package acars
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestDetectorFlight(t *testing.T) {
+	start := time.Date(2025, 8, 21, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+	weight := 71234.0
+	rate := -180.0
+
+	samples := []Sample{
+		{Time: at(0), OnGround: true, Engines: intPtr(0)},
+		{Time: at(10), OnGround: true, GS: 3, Engines: intPtr(0)},
+		{Time: at(20), OnGround: true, GS: 0, Engines: intPtr(2)},
+		{Time: at(30), OnGround: true, GS: 140, Heading: 157, Engines: intPtr(2)},
+		{Time: at(31), AltMSL: 300, GS: 150, VS: 2000, Heading: 157, Engines: intPtr(2), Weight: &weight},
+		{Time: at(300), AltMSL: 9900, VS: 2000, Engines: intPtr(2)},
+		{Time: at(305), AltMSL: 10100, VS: 2000, Engines: intPtr(2)},
+		{Time: at(900), AltMSL: 35000, VS: 0, Engines: intPtr(2)},
+		{Time: at(990), AltMSL: 35000, VS: 0, Engines: intPtr(2)},
+		{Time: at(3000), AltMSL: 34000, VS: -1500, Engines: intPtr(2)},
+		{Time: at(3040), AltMSL: 33000, VS: -1500, Engines: intPtr(2)},
+		{Time: at(4000), AltMSL: 10100, VS: -1000, Engines: intPtr(2)},
+		{Time: at(4010), AltMSL: 9900, VS: -1000, Engines: intPtr(2)},
+		{Time: at(5000), AltMSL: 20, VS: -180, OnGround: true, GS: 130, Engines: intPtr(2), LandingRate: &rate},
+		{Time: at(5300), OnGround: true, GS: 0, Engines: intPtr(2)},
+		{Time: at(5310), OnGround: true, GS: 0, Engines: intPtr(0)},
+	}
+
+	detector := NewDetector()
+	var texts []string
+	var kinds []MilestoneKind
+	for _, sample := range samples {
+		for _, milestone := range detector.Update(sample) {
+			kinds = append(kinds, milestone.Kind)
+			texts = append(texts, milestone.Text)
+		}
+	}
+
+	want := []MilestoneKind{
+		MilestonePushback, MilestoneEngineStart, MilestoneTakeoff, MilestoneClimb10k, MilestoneTopOfClimb,
+		MilestoneTopOfDescent, MilestoneDescent10k, MilestoneTouchdown, MilestoneBlockOn, MilestoneShutdown,
+	}
+	if len(kinds) != len(want) {
+		t.Fatalf("Expected milestones %v, got %v (%q)", want, kinds, texts)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Errorf("Expected milestone %d to be %s, got %s", i, want[i], kinds[i])
+		}
+	}
+
+	if texts[2] != "Takeoff from runway 16, weight 71234 kg" {
+		t.Errorf("Unexpected takeoff text %q", texts[2])
+	}
+	if texts[4] != "Top of climb, FL350" {
+		t.Errorf("Unexpected top of climb text %q", texts[4])
+	}
+	if texts[7] != "Touchdown at -180 fpm" {
+		t.Errorf("Unexpected touchdown text %q", texts[7])
+	}
+}
+
+func TestDetectorSeed(t *testing.T) {
+	detector := NewDetector()
+	detector.Seed([]MilestoneKind{MilestoneTakeoff, MilestoneClimb10k})
+
+	start := time.Date(2025, 8, 21, 10, 0, 0, 0, time.UTC)
+	detector.Update(Sample{Time: start, AltMSL: 9900, VS: 1500})
+	if milestones := detector.Update(Sample{Time: start.Add(time.Second), AltMSL: 10100, VS: 1500}); len(milestones) != 0 {
+		t.Errorf("Expected seeded milestone not to repeat, got %v", milestones)
+	}
+}
+
+type fakePoster struct {
+	fail bool
+	// started and release, if set, hold each post until released
+	started chan struct{}
+	release chan struct{}
+
+	mutex sync.Mutex
+	logs  []api.ACARSLog
+	calls int
+}
+
+func (p *fakePoster) PostACARSLogs(_ context.Context, _ string, logs ...api.ACARSLog) error {
+	if p.started != nil {
+		p.started <- struct{}{}
+		<-p.release
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.calls++
+	if p.fail {
+		return errors.New("server unavailable")
+	}
+	p.logs = append(p.logs, logs...)
+	return nil
+}
+
+func (p *fakePoster) PostACARSEvents(context.Context, string, ...api.ACARSEvent) error {
+	return nil
+}
+
+func TestOutboxRetriesAndPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	poster := &fakePoster{fail: true}
+	outbox := NewOutbox(path, poster, nil)
+
+	outbox.Enqueue(Entry{PirepID: "abc", Text: "Pushback", Milestone: MilestonePushback})
+	outbox.Enqueue(Entry{PirepID: "abc", Text: "Engine start"})
+	outbox.Flush(context.Background())
+
+	entries := outbox.Entries("abc")
+	if len(entries) != 2 || entries[0].Attempts != 1 || entries[0].LastError == "" {
+		t.Fatalf("Expected failed attempt to be recorded, got %+v", entries)
+	}
+	if poster.calls != 1 {
+		t.Errorf("Expected entries to be batched into one call, got %d", poster.calls)
+	}
+
+	// A restart picks up the unsent entries
+	poster = &fakePoster{}
+	reloaded := NewOutbox(path, poster, nil)
+	if reloaded.Pending() != 2 {
+		t.Fatalf("Expected 2 pending entries after reload, got %d", reloaded.Pending())
+	}
+
+	reloaded.Flush(context.Background())
+	if poster.calls != 0 {
+		t.Errorf("Expected no retry before the backoff expires, got %d calls", poster.calls)
+	}
+
+	reloaded.mutex.Lock()
+	for i := range reloaded.entries {
+		reloaded.entries[i].NextAttempt = time.Time{}
+	}
+	reloaded.mutex.Unlock()
+
+	reloaded.Flush(context.Background())
+	if len(poster.logs) != 2 || poster.logs[0].Log != "Pushback" {
+		t.Fatalf("Expected both logs to be posted in order, got %+v", poster.logs)
+	}
+	if reloaded.Pending() != 0 {
+		t.Errorf("Expected nothing pending, got %d", reloaded.Pending())
+	}
+	if entries := reloaded.Entries("abc"); entries[0].Milestone != MilestonePushback || !entries[0].Sent() {
+		t.Errorf("Expected sent milestone entry, got %+v", entries[0])
+	}
+}
+
+func TestOutboxConcurrentFlushPostsOnce(t *testing.T) {
+	poster := &fakePoster{started: make(chan struct{}, 2), release: make(chan struct{})}
+	outbox := NewOutbox("", poster, nil)
+	outbox.Enqueue(Entry{PirepID: "abc", Text: "Pushback"})
+	outbox.Enqueue(Entry{PirepID: "abc", Text: "Engine start"})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		outbox.Flush(context.Background())
+	}()
+	<-poster.started
+
+	// A second flush, as before filing, while the first is posting
+	go func() {
+		defer wg.Done()
+		outbox.Flush(context.Background())
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(poster.release)
+	wg.Wait()
+
+	if len(poster.logs) != 2 || poster.calls != 1 {
+		t.Errorf("Expected each entry to be posted once, got %d calls posting %+v", poster.calls, poster.logs)
+	}
+	if outbox.Pending() != 0 {
+		t.Errorf("Expected nothing pending, got %d", outbox.Pending())
+	}
+}
+
+func TestOutboxSavesOnFlushAndPrunes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.json")
+	outbox := NewOutbox(path, &fakePoster{}, nil)
+
+	old := time.Now().Add(-keepSentFor - time.Hour)
+	outbox.Enqueue(Entry{PirepID: "old", Text: "Long ago"})
+	outbox.Enqueue(Entry{PirepID: "abc", Text: "Pushback"})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected Enqueue to leave saving to Flush, got %v", err)
+	}
+
+	outbox.Flush(context.Background())
+	outbox.mutex.Lock()
+	outbox.entries[0].SentAt = &old
+	outbox.dirty = true
+	outbox.mutex.Unlock()
+	outbox.Enqueue(Entry{PirepID: "abc", Text: "Engine start"})
+	outbox.Flush(context.Background())
+
+	var saved []Entry
+	if data, err := os.ReadFile(path); err != nil || json.Unmarshal(data, &saved) != nil {
+		t.Fatalf("Expected a saved outbox, got %v", err)
+	}
+	if len(saved) != 2 || saved[0].Text != "Pushback" || len(outbox.Entries("old")) != 0 {
+		t.Errorf("Expected the old sent entry to be pruned, got %+v", saved)
+	}
+}
@@ -0,0 +1,258 @@
+package acars
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+type MilestoneKind string
+
+const (
+	MilestonePushback     MilestoneKind = "pushback"
+	MilestoneEngineStart  MilestoneKind = "engine_start"
+	MilestoneTakeoff      MilestoneKind = "takeoff"
+	MilestoneClimb10k     MilestoneKind = "climb_10k"
+	MilestoneTopOfClimb   MilestoneKind = "top_of_climb"
+	MilestoneTopOfDescent MilestoneKind = "top_of_descent"
+	MilestoneDescent10k   MilestoneKind = "descent_10k"
+	MilestoneTouchdown    MilestoneKind = "touchdown"
+	MilestoneBlockOn      MilestoneKind = "block_on"
+	MilestoneShutdown     MilestoneKind = "shutdown"
+)
+
+const (
+	transitionAltitude = 10000 // ft
+	levelVS            = 300   // ft/min either side of level
+	levelFor           = 60 * time.Second
+	descentVS          = -500 // ft/min
+	descentFor         = 30 * time.Second
+	blockOnFor         = 30 * time.Second
+)
+
+type Milestone struct {
+	Time time.Time
+	Kind MilestoneKind
+	Text string
+	Lat  float64
+	Lon  float64
+}
+
+// Sample is one telemetry update. Engines and Weight are nil if the bridge
+// script doesn't send them.
+type Sample struct {
+	Time        time.Time
+	Lat         float64
+	Lon         float64
+	AltMSL      float64 // ft
+	GS          float64 // kt
+	VS          float64 // ft/min
+	Heading     float64
+	OnGround    bool
+	Engines     *int
+	Weight      *float64 // kg
+	LandingRate *float64 // ft/min, set on the touchdown sample
+}
+
+// Detector turns telemetry into flight log milestones. Each milestone is
+// reported at most once per flight, except engine starts.
+type Detector struct {
+	mutex sync.Mutex
+
+	last      *Sample
+	done      map[MilestoneKind]bool
+	engines   int
+	airborne  bool
+	landed    bool
+	levelAt   time.Time
+	cruiseAlt float64
+	descentAt time.Time
+	stoppedAt time.Time
+}
+
+func NewDetector() *Detector {
+	return &Detector{done: map[MilestoneKind]bool{}}
+}
+
+func (d *Detector) Reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.last = nil
+	d.done = map[MilestoneKind]bool{}
+	d.engines = 0
+	d.airborne = false
+	d.landed = false
+	d.levelAt = time.Time{}
+	d.cruiseAlt = 0
+	d.descentAt = time.Time{}
+	d.stoppedAt = time.Time{}
+}
+
+// Seed marks milestones as already reported, so resuming a flight doesn't
+// repeat them.
+func (d *Detector) Seed(kinds []MilestoneKind) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	for _, kind := range kinds {
+		d.done[kind] = true
+		switch kind {
+		case MilestoneTakeoff:
+			d.airborne = true
+		case MilestoneTouchdown:
+			d.airborne = false
+			d.landed = true
+		}
+	}
+}
+
+func (d *Detector) Update(sample Sample) []Milestone {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var milestones []Milestone
+	emit := func(kind MilestoneKind, text string) {
+		if kind != MilestoneEngineStart && d.done[kind] {
+			return
+		}
+		d.done[kind] = true
+		milestones = append(milestones, Milestone{
+			Time: sample.Time,
+			Kind: kind,
+			Text: text,
+			Lat:  sample.Lat,
+			Lon:  sample.Lon,
+		})
+	}
+
+	last := d.last
+	d.last = &sample
+	if last == nil {
+		if sample.Engines != nil {
+			d.engines = *sample.Engines
+		}
+		d.airborne = d.airborne || !sample.OnGround
+		return nil
+	}
+
+	if sample.Engines != nil {
+		running := *sample.Engines
+		switch {
+		case running > d.engines && !d.landed:
+			emit(MilestoneEngineStart, fmt.Sprintf("Engine start, %d running", running))
+		case running == 0 && d.engines > 0 && d.landed:
+			if !d.done[MilestoneBlockOn] {
+				emit(MilestoneBlockOn, "On blocks")
+			}
+			emit(MilestoneShutdown, "Engines shut down")
+		}
+		d.engines = running
+	}
+
+	// Moving with the engines off is a pushback. Without engine data, the
+	// first movement is all that can be reported.
+	if sample.OnGround && !d.airborne && !d.landed && sample.GS >= 1 {
+		if sample.Engines == nil {
+			emit(MilestonePushback, "Off blocks")
+		} else if d.engines == 0 {
+			emit(MilestonePushback, "Pushback")
+		}
+	}
+
+	if last.OnGround && !sample.OnGround && !d.landed {
+		d.airborne = true
+		text := fmt.Sprintf("Takeoff from runway %s", runwayFromHeading(last.Heading))
+		if sample.Weight != nil {
+			text += fmt.Sprintf(", weight %.0f kg", *sample.Weight)
+		}
+		emit(MilestoneTakeoff, text)
+	}
+
+	if d.airborne {
+		if last.AltMSL < transitionAltitude && sample.AltMSL >= transitionAltitude && sample.VS > 0 {
+			emit(MilestoneClimb10k, "Passing 10,000 ft climbing")
+		}
+		if last.AltMSL >= transitionAltitude && sample.AltMSL < transitionAltitude && sample.VS < 0 {
+			emit(MilestoneDescent10k, "Passing 10,000 ft descending")
+		}
+		d.detectCruise(sample, emit)
+	}
+
+	if !last.OnGround && sample.OnGround && d.airborne {
+		d.airborne = false
+		d.landed = true
+		text := "Touchdown"
+		if sample.LandingRate != nil {
+			text += fmt.Sprintf(" at %.0f fpm", *sample.LandingRate)
+		}
+		emit(MilestoneTouchdown, text)
+	}
+
+	if d.landed && sample.OnGround && !d.done[MilestoneBlockOn] {
+		if sample.GS < 1 {
+			if d.stoppedAt.IsZero() {
+				d.stoppedAt = sample.Time
+			}
+			if sample.Time.Sub(d.stoppedAt) >= blockOnFor {
+				emit(MilestoneBlockOn, "On blocks")
+			}
+		} else {
+			d.stoppedAt = time.Time{}
+		}
+	}
+
+	return milestones
+}
+
+// detectCruise reports top of climb once the aircraft has held level for a
+// minute, and top of descent once it has been descending from there.
+func (d *Detector) detectCruise(sample Sample, emit func(MilestoneKind, string)) {
+	if !d.done[MilestoneTopOfClimb] {
+		if math.Abs(sample.VS) > levelVS || sample.AltMSL < transitionAltitude {
+			d.levelAt = time.Time{}
+			return
+		}
+		if d.levelAt.IsZero() {
+			d.levelAt = sample.Time
+		}
+		if sample.Time.Sub(d.levelAt) >= levelFor {
+			d.cruiseAlt = sample.AltMSL
+			emit(MilestoneTopOfClimb, fmt.Sprintf("Top of climb, %s", flightLevel(sample.AltMSL)))
+		}
+		return
+	}
+
+	if d.done[MilestoneTopOfDescent] {
+		return
+	}
+	if sample.VS > descentVS {
+		d.descentAt = time.Time{}
+		if sample.AltMSL > d.cruiseAlt {
+			// Step climbs move the cruise altitude up
+			d.cruiseAlt = sample.AltMSL
+		}
+		return
+	}
+	if d.descentAt.IsZero() {
+		d.descentAt = sample.Time
+	}
+	if sample.Time.Sub(d.descentAt) >= descentFor && sample.AltMSL < d.cruiseAlt-1000 {
+		emit(MilestoneTopOfDescent, fmt.Sprintf("Top of descent from %s", flightLevel(d.cruiseAlt)))
+	}
+}
+
+// runwayFromHeading estimates the runway designator from the takeoff roll's
+// track, e.g. 157° is runway 16.
+func runwayFromHeading(heading float64) string {
+	number := int(math.Round(math.Mod(heading+360, 360) / 10))
+	if number == 0 {
+		number = 36
+	}
+	return fmt.Sprintf("%02d", number)
+}
+
+func flightLevel(altitude float64) string {
+	return fmt.Sprintf("FL%03d", int(math.Round(altitude/100)))
+}
@@ -0,0 +1,351 @@
+package acars
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/atomicfile"
+)
+
+type EntryKind string
+
+const (
+	EntryLog   EntryKind = "log"
+	EntryEvent EntryKind = "event"
+)
+
+const (
+	maxAttempts  = 10
+	maxBackoff   = 5 * time.Minute
+	pollInterval = 5 * time.Second
+	keepSentFor  = 7 * 24 * time.Hour
+)
+
+type Entry struct {
+	ID          int64         `json:"id"`
+	PirepID     string        `json:"pirep_id"`
+	Kind        EntryKind     `json:"kind"`
+	Milestone   MilestoneKind `json:"milestone,omitempty"`
+	Text        string        `json:"text"`
+	Time        time.Time     `json:"time"`
+	Lat         float64       `json:"lat,omitempty"`
+	Lon         float64       `json:"lon,omitempty"`
+	SentAt      *time.Time    `json:"sent_at,omitempty"`
+	Attempts    int           `json:"attempts,omitempty"`
+	NextAttempt time.Time     `json:"next_attempt,omitempty"`
+	LastError   string        `json:"last_error,omitempty"`
+}
+
+func (e Entry) Sent() bool {
+	return e.SentAt != nil
+}
+
+// Failed reports whether the entry has been given up on.
+func (e Entry) Failed() bool {
+	return e.SentAt == nil && e.Attempts >= maxAttempts
+}
+
+type Poster interface {
+	PostACARSLogs(ctx context.Context, id string, logs ...api.ACARSLog) error
+	PostACARSEvents(ctx context.Context, id string, events ...api.ACARSEvent) error
+}
+
+// Outbox queues ACARS log entries and events and posts them in the
+// background, retrying with backoff. Entries are saved to Path, if set, so
+// anything unsent survives a restart. Saving waits for the end of a Flush,
+// so a burst of entries is written once rather than once each.
+type Outbox struct {
+	Path   string
+	Poster Poster
+	Logger *slog.Logger
+
+	mutex sync.Mutex
+	// flushing is held for a whole Flush, so Run and a flush before filing
+	// can't both post the same entries
+	flushing sync.Mutex
+	entries  []Entry
+	nextID   int64
+	// dirty is set when entries have changed since they were last saved
+	dirty bool
+	wake  chan struct{}
+}
+
+func NewOutbox(path string, poster Poster, logger *slog.Logger) *Outbox {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	outbox := &Outbox{
+		Path:   path,
+		Poster: poster,
+		Logger: logger,
+		nextID: 1,
+		wake:   make(chan struct{}, 1),
+	}
+	if err := outbox.load(); err != nil {
+		logger.Warn("Failed to load ACARS outbox", "path", path, "error", err)
+	}
+	return outbox
+}
+
+func (o *Outbox) Enqueue(entry Entry) {
+	o.mutex.Lock()
+	entry.ID = o.nextID
+	o.nextID++
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if entry.Kind == "" {
+		entry.Kind = EntryLog
+	}
+	o.entries = append(o.entries, entry)
+	o.dirty = true
+	o.mutex.Unlock()
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Entries returns the queued and sent entries for a PIREP, oldest first.
+func (o *Outbox) Entries(pirepID string) []Entry {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var entries []Entry
+	for _, entry := range o.entries {
+		if entry.PirepID == pirepID {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (o *Outbox) Pending() int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	var pending int
+	for _, entry := range o.entries {
+		if !entry.Sent() && !entry.Failed() {
+			pending++
+		}
+	}
+	return pending
+}
+
+// Run posts queued entries until ctx is cancelled.
+func (o *Outbox) Run(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			o.mutex.Lock()
+			o.saveLocked()
+			o.mutex.Unlock()
+			return
+		case <-o.wake:
+		case <-timer.C:
+		}
+
+		o.Flush(ctx)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(pollInterval)
+	}
+}
+
+// Flush posts every entry that is due, batching them by PIREP and kind, then
+// saves the outbox if anything changed. A Flush already under way is waited
+// for, rather than posting its entries again.
+func (o *Outbox) Flush(ctx context.Context) {
+	o.flushing.Lock()
+	defer o.flushing.Unlock()
+	defer func() {
+		o.mutex.Lock()
+		o.saveLocked()
+		o.mutex.Unlock()
+	}()
+
+	type batchKey struct {
+		pirepID string
+		kind    EntryKind
+	}
+
+	now := time.Now()
+	o.mutex.Lock()
+	batches := map[batchKey][]Entry{}
+	var order []batchKey
+	for _, entry := range o.entries {
+		if entry.Sent() || entry.Failed() || entry.NextAttempt.After(now) {
+			continue
+		}
+		key := batchKey{entry.PirepID, entry.Kind}
+		if _, ok := batches[key]; !ok {
+			order = append(order, key)
+		}
+		batches[key] = append(batches[key], entry)
+	}
+	o.mutex.Unlock()
+
+	for _, key := range order {
+		batch := batches[key]
+		err := o.post(ctx, key.pirepID, key.kind, batch)
+		if ctx.Err() != nil {
+			return
+		}
+		o.markResult(batch, err)
+	}
+}
+
+func (o *Outbox) post(ctx context.Context, pirepID string, kind EntryKind, batch []Entry) error {
+	if kind == EntryEvent {
+		events := make([]api.ACARSEvent, len(batch))
+		for i, entry := range batch {
+			events[i] = api.ACARSEvent{
+				Event:     entry.Text,
+				Lat:       entry.Lat,
+				Lon:       entry.Lon,
+				CreatedAt: entry.Time.UTC().Format(time.RFC3339),
+			}
+		}
+		return o.Poster.PostACARSEvents(ctx, pirepID, events...)
+	}
+
+	logs := make([]api.ACARSLog, len(batch))
+	for i, entry := range batch {
+		logs[i] = api.ACARSLog{
+			Log:       entry.Text,
+			Lat:       entry.Lat,
+			Lon:       entry.Lon,
+			CreatedAt: entry.Time.UTC().Format(time.RFC3339),
+		}
+	}
+	return o.Poster.PostACARSLogs(ctx, pirepID, logs...)
+}
+
+func (o *Outbox) markResult(batch []Entry, err error) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	ids := map[int64]bool{}
+	for _, entry := range batch {
+		ids[entry.ID] = true
+	}
+
+	now := time.Now().UTC()
+	for i := range o.entries {
+		entry := &o.entries[i]
+		if !ids[entry.ID] {
+			continue
+		}
+		entry.Attempts++
+		if err == nil {
+			entry.SentAt = &now
+			entry.LastError = ""
+			continue
+		}
+		entry.LastError = err.Error()
+		entry.NextAttempt = now.Add(backoff(entry.Attempts))
+		if entry.Failed() {
+			o.Logger.Warn("Giving up on ACARS entry", "pirep_id", entry.PirepID, "text", entry.Text, "error", err)
+		}
+	}
+	if err != nil {
+		o.Logger.Debug("Failed to post ACARS entries, will retry", "count", len(batch), "error", err)
+	}
+	o.dirty = true
+}
+
+func backoff(attempts int) time.Duration {
+	delay := time.Duration(1<<min(attempts, 10)) * time.Second
+	return min(delay, maxBackoff)
+}
+
+func (o *Outbox) load() error {
+	if o.Path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(o.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("failed to decode outbox: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.ID >= o.nextID {
+			o.nextID = entry.ID + 1
+		}
+	}
+	o.entries = entries
+	o.pruneLocked()
+	return nil
+}
+
+// pruneLocked drops entries that were sent more than keepSentFor ago.
+func (o *Outbox) pruneLocked() {
+	cutoff := time.Now().Add(-keepSentFor)
+	kept := o.entries[:0]
+	for _, entry := range o.entries {
+		if entry.Sent() && entry.SentAt.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if len(kept) != len(o.entries) {
+		o.dirty = true
+	}
+	o.entries = kept
+}
+
+// saveLocked writes the outbox if it changed since it was last saved.
+func (o *Outbox) saveLocked() {
+	o.pruneLocked()
+	if o.Path == "" || !o.dirty {
+		return
+	}
+
+	data, err := json.Marshal(o.entries)
+	if err != nil {
+		o.Logger.Warn("Failed to encode ACARS outbox", "error", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(o.Path), 0o755); err != nil {
+		o.Logger.Warn("Failed to create ACARS outbox directory", "error", err)
+		return
+	}
+
+	if err := atomicfile.Write(o.Path, 0o600, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		o.Logger.Warn("Failed to save ACARS outbox", "error", err)
+		return
+	}
+	o.dirty = false
+}
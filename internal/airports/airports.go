@@ -0,0 +1,253 @@
+package airports
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/atomicfile"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// maxAge is how long a cached airport is used before it's looked up again.
+const maxAge = 30 * 24 * time.Hour
+
+var ErrNotFound = errors.New("unknown airport")
+
+type Lookup interface {
+	GetAirport(ctx context.Context, icao string) (*models.Airport, error)
+	SearchAirports(ctx context.Context, query string) ([]models.Airport, error)
+}
+
+type entry struct {
+	Airport   models.Airport `json:"airport"`
+	FetchedAt time.Time      `json:"fetched_at"`
+}
+
+// Cache keeps airports looked up on phpVMS. They're saved to Path, if set, so
+// each is only fetched once in a while rather than on every start.
+type Cache struct {
+	Path   string
+	Lookup Lookup
+	Logger *slog.Logger
+
+	mutex    sync.Mutex
+	airports map[string]entry
+}
+
+func NewCache(path string, lookup Lookup, logger *slog.Logger) *Cache {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	cache := &Cache{
+		Path:     path,
+		Lookup:   lookup,
+		Logger:   logger,
+		airports: map[string]entry{},
+	}
+	cache.load()
+	return cache
+}
+
+// Get returns the airport with the ICAO code, from the cache if it was looked
+// up recently. It returns ErrNotFound if phpVMS doesn't know the airport.
+func (c *Cache) Get(ctx context.Context, icao string) (models.Airport, error) {
+	icao = strings.ToUpper(strings.TrimSpace(icao))
+
+	c.mutex.Lock()
+	cached, ok := c.airports[icao]
+	c.mutex.Unlock()
+	if ok && time.Since(cached.FetchedAt) < maxAge {
+		return cached.Airport, nil
+	}
+
+	airport, err := c.Lookup.GetAirport(ctx, icao)
+	if err != nil {
+		var statusErr *api.StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return models.Airport{}, fmt.Errorf("%w %s", ErrNotFound, icao)
+		}
+		// Better an old airport than none while phpVMS is unreachable
+		if ok {
+			c.Logger.Warn("Failed to refresh airport, using the cached one", "icao", icao, "error", err)
+			return cached.Airport, nil
+		}
+		return models.Airport{}, fmt.Errorf("failed to look up airport %s: %w", icao, err)
+	}
+
+	c.add(*airport)
+	return normalise(*airport), nil
+}
+
+// Search returns up to limit airports that roughly match query, best first.
+// Codes one or two letters out match, so it can suggest what a mistyped code
+// was meant to be.
+func (c *Cache) Search(ctx context.Context, query string, limit int) []models.Airport {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+
+	// phpVMS only finds close matches, so also ask for the region, e.g. YS for
+	// YSYY, which is where a mistyped code's airport usually is
+	searches := []string{query}
+	if len(query) == 4 {
+		searches = append(searches, query[:2])
+	}
+	for _, search := range searches {
+		found, err := c.Lookup.SearchAirports(ctx, search)
+		if err != nil {
+			c.Logger.Warn("Failed to search airports", "query", search, "error", err)
+			continue
+		}
+		c.add(found...)
+	}
+
+	type match struct {
+		airport models.Airport
+		score   int
+	}
+	var matches []match
+	c.mutex.Lock()
+	for _, cached := range c.airports {
+		if score := Score(query, cached.Airport); score >= 0 {
+			matches = append(matches, match{cached.Airport, score})
+		}
+	}
+	c.mutex.Unlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score < matches[j].score
+		}
+		return matches[i].airport.ICAO < matches[j].airport.ICAO
+	})
+	airports := make([]models.Airport, 0, min(limit, len(matches)))
+	for _, match := range matches[:min(limit, len(matches))] {
+		airports = append(airports, match.airport)
+	}
+	return airports
+}
+
+// Score is how well airport matches query, lower being better, or -1 if it
+// doesn't match at all.
+func Score(query string, airport models.Airport) int {
+	query = strings.ToUpper(query)
+	switch {
+	case query == airport.ICAO || query == airport.IATA:
+		return 0
+	case strings.HasPrefix(airport.ICAO, query):
+		return 1
+	case len(query) >= 3 && (strings.Contains(strings.ToUpper(airport.Name), query) ||
+		strings.Contains(strings.ToUpper(airport.Location), query)):
+		return 2
+	}
+	if len(query) == len(airport.ICAO) {
+		if distance := editDistance(query, airport.ICAO); distance <= 2 {
+			return 2 + distance
+		}
+	}
+	return -1
+}
+
+// editDistance is the number of letters changed, added, removed or swapped
+// with their neighbour to turn a into b.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// normalise fills in the ICAO code from the ID, which phpVMS always sets.
+func normalise(airport models.Airport) models.Airport {
+	if airport.ICAO == "" {
+		airport.ICAO = airport.ID
+	}
+	airport.ICAO = strings.ToUpper(airport.ICAO)
+	return airport
+}
+
+func (c *Cache) add(airports ...models.Airport) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, airport := range airports {
+		if airport = normalise(airport); airport.ICAO != "" {
+			c.airports[airport.ICAO] = entry{Airport: airport, FetchedAt: time.Now()}
+		}
+	}
+	c.saveLocked()
+}
+
+func (c *Cache) load() {
+	if c.Path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(c.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		c.Logger.Warn("Failed to read airport cache", "error", err)
+		return
+	}
+	if err := json.Unmarshal(data, &c.airports); err != nil {
+		c.Logger.Warn("Failed to parse airport cache, starting afresh", "error", err)
+		c.airports = map[string]entry{}
+	}
+}
+
+func (c *Cache) saveLocked() {
+	if c.Path == "" {
+		return
+	}
+
+	data, err := json.Marshal(c.airports)
+	if err != nil {
+		c.Logger.Warn("Failed to encode airport cache", "error", err)
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		c.Logger.Warn("Failed to create airport cache directory", "error", err)
+		return
+	}
+
+	if err := atomicfile.Write(c.Path, 0o600, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	}); err != nil {
+		c.Logger.Warn("Failed to save airport cache", "error", err)
+	}
+}
@@ -0,0 +1,119 @@
+package airports
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+type fakeLookup struct {
+	airports []models.Airport
+	gets     int
+	err      error
+}
+
+func (f *fakeLookup) GetAirport(_ context.Context, icao string) (*models.Airport, error) {
+	f.gets++
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, airport := range f.airports {
+		if airport.ID == icao {
+			return &airport, nil
+		}
+	}
+	return nil, &api.StatusError{URL: "/api/airports/" + icao, StatusCode: 404}
+}
+
+func (f *fakeLookup) SearchAirports(_ context.Context, query string) ([]models.Airport, error) {
+	var found []models.Airport
+	for _, airport := range f.airports {
+		if strings.HasPrefix(airport.ID, query) {
+			found = append(found, airport)
+		}
+	}
+	return found, nil
+}
+
+func testAirports() []models.Airport {
+	return []models.Airport{
+		{ID: "YSSY", ICAO: "YSSY", IATA: "SYD", Name: "Sydney Kingsford Smith", Location: "Sydney", Timezone: "Australia/Sydney", Hub: true},
+		{ID: "YSBK", ICAO: "YSBK", IATA: "BWU", Name: "Bankstown", Location: "Sydney"},
+		{ID: "YMML", ICAO: "YMML", IATA: "MEL", Name: "Melbourne", Location: "Melbourne"},
+		{ID: "NZAA", ICAO: "NZAA", IATA: "AKL", Name: "Auckland", Location: "Auckland"},
+	}
+}
+
+func TestGetCachesAirports(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "airports.json")
+	lookup := &fakeLookup{airports: testAirports()}
+	cache := NewCache(path, lookup, nil)
+
+	airport, err := cache.Get(context.Background(), "yssy")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if airport.Name != "Sydney Kingsford Smith" || !airport.Hub {
+		t.Errorf("Expected Sydney hub, got %+v", airport)
+	}
+
+	// A new cache reads the saved airports rather than asking phpVMS again
+	reloaded := NewCache(path, lookup, nil)
+	if _, err := reloaded.Get(context.Background(), "YSSY"); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if lookup.gets != 1 {
+		t.Errorf("Expected 1 lookup, got %d", lookup.gets)
+	}
+
+	if _, err := cache.Get(context.Background(), "YSYY"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown airport, got %v", err)
+	}
+}
+
+func TestGetKeepsCachedAirportWhenOffline(t *testing.T) {
+	lookup := &fakeLookup{err: errors.New("connection refused")}
+	cache := NewCache("", lookup, nil)
+	cache.add(testAirports()[0])
+
+	if _, err := cache.Get(context.Background(), "YMML"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected a lookup error for an uncached airport, got %v", err)
+	}
+	if airport, err := cache.Get(context.Background(), "YSSY"); err != nil || airport.ICAO != "YSSY" {
+		t.Errorf("Expected cached YSSY, got %+v (err %v)", airport, err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"mistyped code", "YSYY", []string{"YSSY", "YSBK"}},
+		{"swapped letters", "YMLM", []string{"YMML"}},
+		{"IATA code", "mel", []string{"YMML"}},
+		{"name", "sydney", []string{"YSBK", "YSSY"}},
+		{"no match", "KJFK", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := NewCache("", &fakeLookup{airports: testAirports()}, nil)
+			cache.add(testAirports()...)
+
+			var got []string
+			for _, airport := range cache.Search(context.Background(), tt.query, 5) {
+				got = append(got, airport.ICAO)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// maxErrorBody is how much of an error response is kept for a Call.
+const maxErrorBody = 4096
+
+// Call is a request made by the client, kept for the TUI's API timeline.
+type Call struct {
+	Time     time.Time
+	Method   string
+	Path     string
+	Status   int
+	Duration time.Duration
+	// Error is the transport error, or the body of an error response.
+	Error string
+}
+
+// CallLog keeps the most recent calls.
+type CallLog struct {
+	mutex sync.Mutex
+	calls []Call
+	size  int
+}
+
+func NewCallLog(size int) *CallLog {
+	return &CallLog{size: size}
+}
+
+func (l *CallLog) Add(call Call) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.calls = append(l.calls, call)
+	if len(l.calls) > l.size {
+		l.calls = append(l.calls[:0], l.calls[len(l.calls)-l.size:]...)
+	}
+}
+
+// Calls returns the kept calls, oldest first.
+func (l *CallLog) Calls() []Call {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return append([]Call(nil), l.calls...)
+}
@@ -5,18 +5,42 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
 	"github.com/julietrb1/phpvms-xplane/models"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Client struct {
 	BaseURL    string
-	APIKey     string
+	APIKey     secret.Value
 	HTTPClient *http.Client
 	Logger     *slog.Logger
+	Observer   RequestObserver
+	Calls      *CallLog
+	// mu guards BaseURL and APIKey, which change when the configuration is
+	// reloaded
+	mu sync.RWMutex
+}
+
+// RequestObserver is called after every request. Endpoint is the request path
+// with PIREP and flight IDs replaced by {id}, and status is 0 if no response
+// was received.
+type RequestObserver func(method, endpoint string, status int, duration time.Duration)
+
+// StatusError is returned when phpVMS responds with a status other than 2xx.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API error: %s (status %d)", e.URL, e.StatusCode)
 }
 
 type DataResponse[T any] struct {
@@ -82,13 +106,41 @@ type PositionUpdateRequest struct {
 	VSFPM      int     `json:"vs"`
 }
 
+type ACARSLog struct {
+	Log       string  `json:"log"`
+	Lat       float64 `json:"lat,omitempty"`
+	Lon       float64 `json:"lon,omitempty"`
+	CreatedAt string  `json:"created_at,omitempty"`
+}
+
+type ACARSEvent struct {
+	Event     string  `json:"event"`
+	Lat       float64 `json:"lat,omitempty"`
+	Lon       float64 `json:"lon,omitempty"`
+	CreatedAt string  `json:"created_at,omitempty"`
+}
+
+type PIREPFare struct {
+	ID    int `json:"id"`
+	Count int `json:"count"`
+}
+
 type FilePIREPRequest struct {
-	FlightTime  int `json:"flight_time"`
-	FuelUsedLbs int `json:"fuel_used"`
-	Distance    int `json:"distance"`
+	FlightTime   int                    `json:"flight_time"`
+	FuelUsedLbs  int                    `json:"fuel_used"`
+	Distance     int                    `json:"distance"`
+	LandingRate  *float64               `json:"landing_rate,omitempty"`
+	Score        *int                   `json:"score,omitempty"`
+	BlockOffTime string                 `json:"block_off_time,omitempty"`
+	BlockOnTime  string                 `json:"block_on_time,omitempty"`
+	ZFWLbs       int                    `json:"zfw,omitempty"`
+	SourceName   string                 `json:"source_name,omitempty"`
+	Notes        string                 `json:"notes,omitempty"`
+	Fares        []PIREPFare            `json:"fares,omitempty"`
+	Fields       map[string]interface{} `json:"fields,omitempty"`
 }
 
-func NewClient(baseURL, apiKey string, logger *slog.Logger) *Client {
+func NewClient(baseURL string, apiKey secret.Value, logger *slog.Logger) *Client {
 	if logger == nil {
 		logger = slog.Default()
 	}
@@ -102,19 +154,38 @@ func NewClient(baseURL, apiKey string, logger *slog.Logger) *Client {
 		APIKey:     apiKey,
 		HTTPClient: httpClient,
 		Logger:     logger,
+		Calls:      NewCallLog(200),
 	}
 }
 
+// SetCredentials points the client at another phpVMS site or pilot, for
+// requests made from now on.
+func (c *Client) SetCredentials(baseURL string, apiKey secret.Value) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.BaseURL = baseURL
+	c.APIKey = apiKey
+}
+
+func (c *Client) credentials() (string, secret.Value) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.BaseURL, c.APIKey
+}
+
 func (c *Client) doGenericRequest(ctx context.Context, method, url string, body interface{}, result interface{}, transformResponse func([]byte) []byte) error {
-	return c.doRequest(ctx, method, url, body, result, false, transformResponse)
+	return c.doRequest(ctx, method, url, body, result, nil, transformResponse)
 }
 
 func (c *Client) doACARSRequest(ctx context.Context, method, path string, body interface{}, result interface{}) error {
-	url := fmt.Sprintf("%s%s", c.BaseURL, path)
-	return c.doRequest(ctx, method, url, body, result, true, nil)
+	// Read once, so a reload can't send one site's key to the other site
+	baseURL, apiKey := c.credentials()
+	url := fmt.Sprintf("%s%s", baseURL, path)
+	return c.doRequest(ctx, method, url, body, result, &apiKey, nil)
 }
 
-func (c *Client) doRequest(ctx context.Context, method, url string, body interface{}, result interface{}, includeAPIKey bool, transformResponse func([]byte) []byte) error {
+// doRequest sends the request with apiKey, if it isn't nil.
+func (c *Client) doRequest(ctx context.Context, method, url string, body interface{}, result interface{}, apiKey *secret.Value, transformResponse func([]byte) []byte) error {
 	var bodyReader io.Reader
 	if body != nil {
 		bodyBytes, err := json.Marshal(body)
@@ -132,8 +203,8 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "phpVMS-Go-Client/1.0")
-	if includeAPIKey {
-		req.Header.Set("X-API-Key", c.APIKey)
+	if apiKey != nil {
+		req.Header.Set("X-API-Key", apiKey.Reveal())
 	}
 
 	c.Logger.Debug("API request",
@@ -142,21 +213,34 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 		"has_body", body != nil,
 	)
 
+	start := time.Now()
 	resp, err := c.HTTPClient.Do(req)
+	call := Call{Time: start, Method: method, Path: req.URL.Path, Duration: time.Since(start)}
+	if resp != nil {
+		call.Status = resp.StatusCode
+	}
+	if c.Observer != nil {
+		c.Observer(method, c.endpoint(req.URL), call.Status, call.Duration)
+	}
 	if err != nil {
+		call.Error = err.Error()
+		c.addCall(call)
 		return fmt.Errorf("request failed: %w", err)
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("API error: %s (status %d)", url, resp.StatusCode)
+		errorBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		call.Error = strings.TrimSpace(string(errorBody))
+		c.addCall(call)
+		return &StatusError{URL: url, StatusCode: resp.StatusCode}
 	}
+	c.addCall(call)
 
 	if result == nil {
 		return nil
 	}
 
-	defer resp.Body.Close()
-
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return fmt.Errorf("failed to read response body: %w", err)
@@ -179,6 +263,39 @@ func (c *Client) doRequest(ctx context.Context, method, url string, body interfa
 	return nil
 }
 
+func (c *Client) addCall(call Call) {
+	if c.Calls != nil {
+		c.Calls.Add(call)
+	}
+}
+
+// apiPathSegments are the fixed parts of phpVMS API paths, anything else in a
+// path is an ID.
+var apiPathSegments = map[string]bool{
+	"api": true, "pireps": true, "prefile": true, "file": true, "cancel": true,
+	"acars": true, "position": true, "logs": true, "events": true,
+	"flights": true, "aircraft": true, "user": true, "fleet": true, "airlines": true,
+	"airports": true, "search": true,
+}
+
+// endpoint labels a request for observers without the IDs in its path, so
+// requests for different PIREPs are counted together.
+func (c *Client) endpoint(u *url.URL) string {
+	baseURL, _ := c.credentials()
+	base, err := url.Parse(baseURL)
+	if err != nil || u.Host != base.Host {
+		return u.Host + u.Path
+	}
+
+	segments := strings.Split(strings.TrimPrefix(u.Path, base.Path), "/")
+	for i, segment := range segments {
+		if segment != "" && !apiPathSegments[segment] {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
 func (c *Client) PrefilePIREP(ctx context.Context, data PrefilePIREPRequest) (*DataResponse[models.ListedPIREP], error) {
 	var result DataResponse[models.ListedPIREP]
 	err := c.doACARSRequest(ctx, http.MethodPost, "/api/pireps/prefile", data, &result)
@@ -221,14 +338,20 @@ func (c *Client) PostACARSPosition(ctx context.Context, id string, position Posi
 	return c.doACARSRequest(ctx, http.MethodPost, path, body, nil)
 }
 
-func (c *Client) PostACARSLog(ctx context.Context, id string, log map[string]interface{}) error {
-	path := fmt.Sprintf("/api/acars/%s/logs", id)
-	return c.doACARSRequest(ctx, http.MethodPost, path, log, nil)
+func (c *Client) PostACARSLogs(ctx context.Context, id string, logs ...ACARSLog) error {
+	path := fmt.Sprintf("/api/pireps/%s/acars/logs", id)
+	body := map[string]interface{}{
+		"logs": logs,
+	}
+	return c.doACARSRequest(ctx, http.MethodPost, path, body, nil)
 }
 
-func (c *Client) PostACARSEvent(ctx context.Context, id string, event map[string]interface{}) error {
-	path := fmt.Sprintf("/api/acars/%s/events", id)
-	return c.doACARSRequest(ctx, http.MethodPost, path, event, nil)
+func (c *Client) PostACARSEvents(ctx context.Context, id string, events ...ACARSEvent) error {
+	path := fmt.Sprintf("/api/pireps/%s/acars/events", id)
+	body := map[string]interface{}{
+		"events": events,
+	}
+	return c.doACARSRequest(ctx, http.MethodPost, path, body, nil)
 }
 
 func (c *Client) GetACARSData(ctx context.Context, id string) (map[string]interface{}, error) {
@@ -270,6 +393,22 @@ func (c *Client) GetAirlines(ctx context.Context) (*PaginatedResponse[models.Air
 	return &result, err
 }
 
+func (c *Client) GetAirport(ctx context.Context, icao string) (*models.Airport, error) {
+	var result DataResponse[models.Airport]
+	path := fmt.Sprintf("/api/airports/%s", url.PathEscape(icao))
+	err := c.doACARSRequest(ctx, http.MethodGet, path, nil, &result)
+	return &result.Data, err
+}
+
+// SearchAirports returns the first page of airports whose code or name
+// matches query.
+func (c *Client) SearchAirports(ctx context.Context, query string) ([]models.Airport, error) {
+	var result PaginatedResponse[models.Airport]
+	path := "/api/airports/search?search=" + url.QueryEscape(query)
+	err := c.doACARSRequest(ctx, http.MethodGet, path, nil, &result)
+	return result.Data, err
+}
+
 func (c *Client) GetSimbriefOFP(ctx context.Context, simbriefUserID string) (*models.SimBriefOFP, error) {
 	var result models.SimBriefOFP
 	url := fmt.Sprintf("https://www.simbrief.com/api/xml.fetcher.php?userid=%s&json=1", simbriefUserID)
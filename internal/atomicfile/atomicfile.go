@@ -0,0 +1,34 @@
+// Package atomicfile replaces files without ever leaving a partial one in
+// place.
+package atomicfile
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Write calls write with a temporary file next to path, then renames it over
+// path, so a failure or crash can't leave a truncated file behind. The
+// temporary file has a unique name, so two processes writing the same path
+// don't trample each other. Errors from write are returned unchanged.
+func Write(path string, perm os.FileMode, write func(io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Chmod(perm); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,40 @@
+package atomicfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "plan.fms")
+
+	if err := Write(path, 0o600, func(w io.Writer) error {
+		_, err := io.WriteString(w, "first")
+		return err
+	}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	failure := errors.New("encoder failed")
+	err := Write(path, 0o600, func(w io.Writer) error {
+		io.WriteString(w, "trunc")
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected the write error back, got %v", err)
+	}
+
+	if data, err := os.ReadFile(path); err != nil || string(data) != "first" {
+		t.Errorf("Expected the first write to survive, got %q, %v", data, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected mode 0600, got %v, %v", info, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary files left in %s, got %v", dir, entries)
+	}
+}
@@ -4,15 +4,29 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/joho/godotenv"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
+	"github.com/julietrb1/phpvms-xplane/internal/prefile"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
 )
 
 type Config struct {
+	// Profile is the config file profile in use, if any, out of Profiles
+	Profile  string
+	Profiles []string
+
 	PhpVMSBaseURL string
-	PhpVMSAPIKey  string
+	PhpVMSAPIKey  secret.Value
+	// PhpVMSAPIKeyFile and PhpVMSAPIKeyCommand are where ResolveAPIKey gets
+	// the API key from, if it isn't given directly
+	PhpVMSAPIKeyFile    string
+	PhpVMSAPIKeyCommand string
+	// apiKeyEnv is the variable the profile reads the API key from, if any
+	apiKeyEnv string
 
 	UDPBindHost string
 	UDPBindPort int
@@ -21,44 +35,132 @@ type Config struct {
 
 	SelectedAirlineID  int
 	SelectedAircraftID int
+	// PrefsEncrypted encrypts the saved preferences with the API key
+	PrefsEncrypted bool
 
 	SimbriefUserID string
 
-	LogLevel string
+	FlightType        string
+	Network           string
+	Simulator         string
+	SourceName        string
+	PrefileFieldsFile string
+	// PrefileFields are a profile's custom prefile fields, used over
+	// PrefileFieldsFile
+	PrefileFields []prefile.Field
+
+	// WeightUnit is how the TUI shows and takes weights: kg or lb
+	WeightUnit string
+
+	FMSOutputDir string
+
+	DataDir   string
+	ExportDir string
+	// baseDataDir is the data directory the profile's own is made in, and
+	// profileDataDir is the profile's data_dir, if it sets one
+	baseDataDir    string
+	profileDataDir string
+
+	RulesFile string
+
+	ControlSocket string
+	ControlAddr   string
+	ControlToken  string
+
+	MetricsAddr string
+	WebAddr     string
+
+	LogLevel      string
+	LogFormat     string
+	LogFile       string
+	LogMaxSize    int // MB
+	LogMaxAge     int // days
+	LogMaxBackups int
 }
 
 func DefaultConfig() *Config {
 	return &Config{
 		PhpVMSBaseURL:      "",
-		PhpVMSAPIKey:       "",
+		PhpVMSAPIKey:       secret.New(""),
 		UDPBindHost:        "0.0.0.0",
 		UDPBindPort:        47777,
 		TUIEnabled:         true,
 		SelectedAirlineID:  0,
 		SelectedAircraftID: 0,
 		SimbriefUserID:     "",
+		FlightType:         "J",
+		Network:            "VATSIM",
+		Simulator:          "",
+		SourceName:         "vmsacars",
+		PrefileFieldsFile:  "",
+		WeightUnit:         "kg",
+		FMSOutputDir:       "",
+		DataDir:            DefaultDataDir(),
+		ExportDir:          "",
+		RulesFile:          "",
+		ControlSocket:      "",
+		ControlAddr:        "",
+		ControlToken:       "",
+		MetricsAddr:        "",
+		WebAddr:            "",
 		LogLevel:           "info",
+		LogFormat:          "text",
+		LogFile:            "",
+		LogMaxSize:         10,
+		LogMaxAge:          14,
+		LogMaxBackups:      5,
 	}
 }
 
 func (c *Config) LoadFromDotEnv(filePath string) error {
-	if filePath == "" {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return fmt.Errorf("failed to get current working directory: %w", err)
-		}
-		filePath = filepath.Join(cwd, ".env")
+	if err := LoadDotEnv(filePath); err != nil {
+		return err
 	}
+	return c.LoadFromEnv()
+}
 
+// LoadDotEnv adds the .env file's variables to the environment, without
+// overriding any already set.
+func LoadDotEnv(filePath string) error {
+	filePath = DotEnvPath(filePath)
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
 		return nil
 	}
 
-	if err := godotenv.Load(filePath); err != nil {
+	values, err := godotenv.Read(filePath)
+	if err != nil {
 		return fmt.Errorf("error loading .env file: %w", err)
 	}
+	// The environment takes precedence over the file
+	for key, value := range values {
+		if _, ok := os.LookupEnv(key); !ok {
+			os.Setenv(key, value)
+			dotEnvKeys = append(dotEnvKeys, key)
+		}
+	}
+	return nil
+}
 
-	return c.LoadFromEnv()
+// dotEnvKeys are the variables set by LoadDotEnv rather than the environment.
+var dotEnvKeys []string
+
+// ReloadDotEnv loads the .env file again, replacing the variables it set
+// before, so edits to the file take effect.
+func ReloadDotEnv(filePath string) error {
+	for _, key := range dotEnvKeys {
+		os.Unsetenv(key)
+	}
+	dotEnvKeys = nil
+	return LoadDotEnv(filePath)
+}
+
+// DotEnvPath is the .env file to load, defaulting to .env in the current
+// directory.
+func DotEnvPath(filePath string) string {
+	if filePath != "" {
+		return filePath
+	}
+	return ".env"
 }
 
 func (c *Config) LoadFromEnv() error {
@@ -66,8 +168,13 @@ func (c *Config) LoadFromEnv() error {
 		c.PhpVMSBaseURL = val
 	}
 
-	if val := os.Getenv("PHPVMS_API_KEY"); val != "" {
-		c.PhpVMSAPIKey = val
+	// A key source in the environment replaces the profile's
+	key, keyFile, keyCommand := os.Getenv("PHPVMS_API_KEY"), os.Getenv("PHPVMS_API_KEY_FILE"), os.Getenv("PHPVMS_API_KEY_COMMAND")
+	if key != "" || keyFile != "" || keyCommand != "" {
+		c.PhpVMSAPIKey = secret.New(key)
+		c.PhpVMSAPIKeyFile = keyFile
+		c.PhpVMSAPIKeyCommand = keyCommand
+		c.apiKeyEnv = ""
 	}
 
 	if val := os.Getenv("UDP_BIND_HOST"); val != "" {
@@ -90,10 +197,84 @@ func (c *Config) LoadFromEnv() error {
 		c.TUIEnabled = enabled
 	}
 
+	if val := os.Getenv("PREFS_ENCRYPTED"); val != "" {
+		encrypted, err := strconv.ParseBool(val)
+		if err != nil {
+			return fmt.Errorf("invalid PREFS_ENCRYPTED: %w", err)
+		}
+		c.PrefsEncrypted = encrypted
+	}
+
 	if val := os.Getenv("SIMBRIEF_USER_ID"); val != "" {
 		c.SimbriefUserID = val
 	}
 
+	if val := os.Getenv("FLIGHT_TYPE"); val != "" {
+		c.FlightType = strings.ToUpper(val)
+	}
+
+	if val := os.Getenv("NETWORK"); val != "" {
+		c.Network = val
+	}
+
+	if val := os.Getenv("SIMULATOR"); val != "" {
+		c.Simulator = val
+	}
+
+	if val := os.Getenv("PIREP_SOURCE_NAME"); val != "" {
+		c.SourceName = val
+	}
+
+	if val := os.Getenv("PREFILE_FIELDS_FILE"); val != "" {
+		c.PrefileFieldsFile = val
+		c.PrefileFields = nil
+	}
+
+	if val := os.Getenv("WEIGHT_UNIT"); val != "" {
+		c.WeightUnit = strings.ToLower(val)
+	}
+
+	if val := os.Getenv("FMS_OUTPUT_DIR"); val != "" {
+		c.FMSOutputDir = val
+	}
+
+	// A profile still gets its own directory under an overridden base
+	if val := os.Getenv("PXP_DATA_DIR"); val != "" {
+		c.DataDir = val
+		if c.Profile != "" {
+			c.baseDataDir = val
+			c.DataDir = c.profileDir(val)
+		}
+	}
+
+	if val := os.Getenv("EXPORT_DIR"); val != "" {
+		c.ExportDir = val
+	}
+
+	if val := os.Getenv("RULES_FILE"); val != "" {
+		c.RulesFile = val
+	}
+
+	if val := os.Getenv("CONTROL_SOCKET"); val != "" {
+		c.ControlSocket = val
+	}
+
+	if val := os.Getenv("CONTROL_ADDR"); val != "" {
+		c.ControlAddr = val
+	}
+
+	if val := os.Getenv("CONTROL_TOKEN"); val != "" {
+		c.ControlToken = val
+	}
+
+	if val := os.Getenv("METRICS_ADDR"); val != "" {
+		c.MetricsAddr = val
+	}
+
+	if val := os.Getenv("WEB_ADDR"); val != "" {
+		c.WebAddr = val
+	}
+
 	if val := os.Getenv("SELECTED_AIRLINE_ID"); val != "" {
 		id, err := strconv.Atoi(val)
 		if err == nil {
@@ -112,72 +293,205 @@ func (c *Config) LoadFromEnv() error {
 		c.LogLevel = strings.ToLower(val)
 	}
 
+	if val := os.Getenv("LOG_FORMAT"); val != "" {
+		c.LogFormat = strings.ToLower(val)
+	}
+
+	if val := os.Getenv("LOG_FILE"); val != "" {
+		c.LogFile = val
+	}
+
+	if val := os.Getenv("LOG_MAX_SIZE"); val != "" {
+		size, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_MAX_SIZE: %w", err)
+		}
+		c.LogMaxSize = size
+	}
+
+	if val := os.Getenv("LOG_MAX_AGE"); val != "" {
+		age, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_MAX_AGE: %w", err)
+		}
+		c.LogMaxAge = age
+	}
+
+	if val := os.Getenv("LOG_MAX_BACKUPS"); val != "" {
+		backups, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("invalid LOG_MAX_BACKUPS: %w", err)
+		}
+		c.LogMaxBackups = backups
+	}
+
 	return nil
 }
 
+func DefaultDataDir() string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "phpvms-xplane")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ".phpvms-xplane"
+	}
+	return filepath.Join(homeDir, ".local", "share", "phpvms-xplane")
+}
+
+func (c *Config) TracksDir() string {
+	return filepath.Join(c.DataDir, "tracks")
+}
+
+func (c *Config) LogbookPath() string {
+	return filepath.Join(c.DataDir, "logbook.db")
+}
+
+func (c *Config) AirportsPath() string {
+	return filepath.Join(c.DataDir, "airports.json")
+}
+
+func (c *Config) OutboxPath() string {
+	return filepath.Join(c.DataDir, "acars-outbox.json")
+}
+
+func (c *Config) ControlSocketPath() string {
+	if c.ControlSocket != "" {
+		return c.ControlSocket
+	}
+	return filepath.Join(c.DataDir, "pxp.sock")
+}
+
+func (c *Config) ControlTokenPath() string {
+	return filepath.Join(c.DataDir, "control-token")
+}
+
+// LogFilePath is where logs are written while the TUI has the terminal.
+func (c *Config) LogFilePath() string {
+	if c.LogFile != "" {
+		return c.LogFile
+	}
+	return filepath.Join(c.DataDir, "logs", "pxp.log")
+}
+
+func (c *Config) ExportsDir() string {
+	if c.ExportDir != "" {
+		return c.ExportDir
+	}
+	return filepath.Join(c.DataDir, "exports")
+}
+
+// PrefileTemplate is the VA's custom prefile fields, or the defaults without
+// a PREFILE_FIELDS_FILE.
+func (c *Config) PrefileTemplate() (*prefile.Template, error) {
+	if len(c.PrefileFields) > 0 {
+		template := &prefile.Template{Fields: c.PrefileFields}
+		return template, template.Validate()
+	}
+	if c.PrefileFieldsFile == "" {
+		return prefile.Default(), nil
+	}
+	return prefile.Load(c.PrefileFieldsFile)
+}
+
 func (c *Config) Validate() error {
 	if c.PhpVMSBaseURL == "" {
 		return fmt.Errorf("PHPVMS_BASE_URL is required")
 	}
 
-	if c.PhpVMSAPIKey == "" {
-		return fmt.Errorf("PHPVMS_API_KEY is required")
+	if c.APIKeySource() == "" {
+		return fmt.Errorf("PHPVMS_API_KEY, PHPVMS_API_KEY_FILE or PHPVMS_API_KEY_COMMAND is required")
+	}
+
+	if !c.PhpVMSAPIKey.IsSet() && c.apiKeyEnv != "" {
+		return fmt.Errorf("%s is required by profile %s, or PHPVMS_API_KEY", c.apiKeyEnv, c.Profile)
+	}
+
+	if c.PhpVMSAPIKeyFile != "" && c.PhpVMSAPIKeyCommand != "" {
+		return fmt.Errorf("only one of PHPVMS_API_KEY_FILE and PHPVMS_API_KEY_COMMAND can be set")
 	}
 
 	if c.UDPBindPort <= 0 || c.UDPBindPort > 65535 {
 		return fmt.Errorf("UDP_BIND_PORT must be between 1 and 65535")
 	}
 
-	validLogLevels := map[string]bool{
-		"debug": true,
-		"info":  true,
-		"warn":  true,
-		"error": true,
+	if _, ok := prefile.FindFlightType(c.FlightType); !ok {
+		return fmt.Errorf("FLIGHT_TYPE %q is not a phpVMS flight type code", c.FlightType)
+	}
+
+	if _, ok := prefile.FindNetwork(c.Network); !ok {
+		return fmt.Errorf("NETWORK must be one of: %s", strings.Join(prefile.Networks, ", "))
 	}
 
-	if !validLogLevels[c.LogLevel] {
-		return fmt.Errorf("LOG_LEVEL must be one of: debug, info, warn, error")
+	if c.WeightUnit != "kg" && c.WeightUnit != "lb" {
+		return fmt.Errorf("WEIGHT_UNIT must be one of: kg, lb")
+	}
+
+	if _, err := c.PrefileTemplate(); err != nil {
+		return fmt.Errorf("invalid PREFILE_FIELDS_FILE: %w", err)
+	}
+
+	if err := logging.ParseLevels(c.LogLevel); err != nil {
+		return fmt.Errorf("invalid LOG_LEVEL: %w", err)
+	}
+
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("LOG_FORMAT must be one of: text, json")
+	}
+
+	if c.LogMaxSize < 0 || c.LogMaxAge < 0 || c.LogMaxBackups < 0 {
+		return fmt.Errorf("LOG_MAX_SIZE, LOG_MAX_AGE and LOG_MAX_BACKUPS must not be negative")
 	}
 
 	return nil
 }
 
+// SavePreferences saves the selected airline and aircraft. Each profile has
+// its own, kept alongside the others' in the same file. With PrefsEncrypted,
+// values are encrypted with the API key, which must have been resolved.
 func (c *Config) SavePreferences(filePath string) error {
-	if filePath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
-		}
-		filePath = filepath.Join(homeDir, ".phpvms-xplane-prefs")
+	filePath, err := preferencesPath(filePath)
+	if err != nil {
+		return err
 	}
 
 	prefs := map[string]string{
-		"SELECTED_AIRLINE_ID":  strconv.Itoa(c.SelectedAirlineID),
-		"SELECTED_AIRCRAFT_ID": strconv.Itoa(c.SelectedAircraftID),
+		c.preferenceKey("SELECTED_AIRLINE_ID"):  strconv.Itoa(c.SelectedAirlineID),
+		c.preferenceKey("SELECTED_AIRCRAFT_ID"): strconv.Itoa(c.SelectedAircraftID),
 	}
 
-	file, err := os.Create(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to create preferences file: %w", err)
+	// Other profiles' preferences are kept as they are
+	var lines []string
+	if data, err := os.ReadFile(filePath); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			key, _, _ := strings.Cut(line, "=")
+			if _, ok := prefs[key]; line != "" && !ok {
+				lines = append(lines, line)
+			}
+		}
 	}
-	defer file.Close()
-
 	for key, value := range prefs {
-		if _, err := fmt.Fprintf(file, "%s=%s\n", key, value); err != nil {
-			return fmt.Errorf("failed to write preference to file: %w", err)
+		if c.PrefsEncrypted {
+			if value, err = c.encryptPreference(key, value); err != nil {
+				return fmt.Errorf("failed to encrypt preferences: %w", err)
+			}
 		}
+		lines = append(lines, key+"="+value)
+	}
+	slices.Sort(lines)
+
+	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write preferences file: %w", err)
 	}
 
 	return nil
 }
 
 func (c *Config) LoadPreferences(filePath string) error {
-	if filePath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get user home directory: %w", err)
-		}
-		filePath = filepath.Join(homeDir, ".phpvms-xplane-prefs")
+	filePath, err := preferencesPath(filePath)
+	if err != nil {
+		return err
 	}
 
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -202,13 +516,21 @@ func (c *Config) LoadPreferences(filePath string) error {
 
 		key := parts[0]
 		value := parts[1]
+		if key != c.preferenceKey("SELECTED_AIRLINE_ID") && key != c.preferenceKey("SELECTED_AIRCRAFT_ID") {
+			continue
+		}
+		if strings.HasPrefix(value, encryptedPrefix) {
+			if value, err = c.decryptPreference(key, value); err != nil {
+				return err
+			}
+		}
 
 		switch key {
-		case "SELECTED_AIRLINE_ID":
+		case c.preferenceKey("SELECTED_AIRLINE_ID"):
 			if id, err := strconv.Atoi(value); err == nil {
 				c.SelectedAirlineID = id
 			}
-		case "SELECTED_AIRCRAFT_ID":
+		case c.preferenceKey("SELECTED_AIRCRAFT_ID"):
 			if id, err := strconv.Atoi(value); err == nil {
 				c.SelectedAircraftID = id
 			}
@@ -217,3 +539,23 @@ func (c *Config) LoadPreferences(filePath string) error {
 
 	return nil
 }
+
+func preferencesPath(filePath string) (string, error) {
+	if filePath != "" {
+		return filePath, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".phpvms-xplane-prefs"), nil
+}
+
+// preferenceKey is the key a preference is saved under, prefixed with the
+// profile if there is one, e.g. qantas.SELECTED_AIRLINE_ID.
+func (c *Config) preferenceKey(key string) string {
+	if c.Profile == "" {
+		return key
+	}
+	return c.Profile + "." + key
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/julietrb1/phpvms-xplane/internal/prefile"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+)
+
+// File is the config file, with a profile for each VA flown for.
+type File struct {
+	// Path is where the file was read from, if it exists
+	Path string `yaml:"-"`
+
+	// Profile is used when none is chosen with -profile or PXP_PROFILE
+	Profile  string             `yaml:"profile"`
+	Profiles map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is one VA's settings. Anything left out keeps its default, and
+// environment variables override all of them.
+type Profile struct {
+	BaseURL string `yaml:"base_url"`
+	APIKey  string `yaml:"api_key"`
+	// APIKeyEnv names an environment variable holding the API key, so it
+	// needn't be written in the file
+	APIKeyEnv     string `yaml:"api_key_env"`
+	APIKeyFile    string `yaml:"api_key_file"`
+	APIKeyCommand string `yaml:"api_key_command"`
+	EncryptPrefs  bool   `yaml:"encrypt_prefs"`
+
+	SimbriefUserID string `yaml:"simbrief_user_id"`
+	AirlineID      int    `yaml:"airline_id"`
+	AircraftID     int    `yaml:"aircraft_id"`
+
+	FlightType        string          `yaml:"flight_type"`
+	Network           string          `yaml:"network"`
+	Simulator         string          `yaml:"simulator"`
+	SourceName        string          `yaml:"source_name"`
+	PrefileFields     []prefile.Field `yaml:"prefile_fields"`
+	PrefileFieldsFile string          `yaml:"prefile_fields_file"`
+
+	WeightUnit string `yaml:"weight_unit"`
+
+	UDPBindHost string `yaml:"udp_bind_host"`
+	UDPBindPort int    `yaml:"udp_bind_port"`
+	ControlAddr string `yaml:"control_addr"`
+	WebAddr     string `yaml:"web_addr"`
+	MetricsAddr string `yaml:"metrics_addr"`
+
+	// DataDir defaults to a directory of the profile's own, so VAs don't
+	// share a logbook or ACARS outbox
+	DataDir string `yaml:"data_dir"`
+}
+
+// DefaultFilePath is config.yaml in the XDG config directory.
+func DefaultFilePath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "phpvms-xplane", "config.yaml")
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".phpvms-xplane", "config.yaml")
+	}
+	return filepath.Join(homeDir, ".config", "phpvms-xplane", "config.yaml")
+}
+
+// LoadFile reads the config file at path, or the default path if it's empty.
+// A missing file is the same as one without profiles.
+func LoadFile(path string) (*File, error) {
+	if path == "" {
+		path = DefaultFilePath()
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &File{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	file := File{Path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: failed to parse config file: %w", path, err)
+	}
+	if file.Profile != "" {
+		if _, ok := file.Profiles[file.Profile]; !ok {
+			return nil, fmt.Errorf("%s: default profile %q isn't defined", path, file.Profile)
+		}
+	}
+	for name, profile := range file.Profiles {
+		if len(profile.PrefileFields) > 0 {
+			template := prefile.Template{Fields: profile.PrefileFields}
+			if err := template.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: profile %s: %w", path, name, err)
+			}
+		}
+	}
+	return &file, nil
+}
+
+// Names are the profiles' names, sorted.
+func (f *File) Names() []string {
+	var names []string
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// SelectProfile picks the profile to use: name if given, otherwise
+// PXP_PROFILE, the file's default or its only profile. It returns "" if there
+// are no profiles, or several and none was chosen.
+func (f *File) SelectProfile(name string) (string, error) {
+	if name == "" {
+		name = os.Getenv("PXP_PROFILE")
+	}
+	if name == "" {
+		name = f.Profile
+	}
+	if name == "" && len(f.Profiles) == 1 {
+		name = f.Names()[0]
+	}
+	if name == "" {
+		return "", nil
+	}
+
+	if _, ok := f.Profiles[name]; !ok {
+		if len(f.Profiles) == 0 {
+			return "", fmt.Errorf("no profile %q, as there's no config file at %s", name, DefaultFilePath())
+		}
+		return "", fmt.Errorf("no profile %q, expected one of: %s", name, strings.Join(f.Names(), ", "))
+	}
+	return name, nil
+}
+
+// ApplyProfile uses the named profile's settings, over the defaults.
+func (c *Config) ApplyProfile(file *File, name string) error {
+	profile, ok := file.Profiles[name]
+	if !ok {
+		return fmt.Errorf("no profile %q", name)
+	}
+	c.Profile = name
+	c.Profiles = file.Names()
+
+	setString(&c.PhpVMSBaseURL, profile.BaseURL)
+	if profile.APIKey != "" {
+		c.PhpVMSAPIKey = secret.New(profile.APIKey)
+	}
+	if profile.APIKeyEnv != "" {
+		c.PhpVMSAPIKey = secret.New(os.Getenv(profile.APIKeyEnv))
+		c.apiKeyEnv = profile.APIKeyEnv
+	}
+	setString(&c.PhpVMSAPIKeyFile, profile.APIKeyFile)
+	setString(&c.PhpVMSAPIKeyCommand, profile.APIKeyCommand)
+	c.PrefsEncrypted = c.PrefsEncrypted || profile.EncryptPrefs
+	setString(&c.SimbriefUserID, profile.SimbriefUserID)
+	setInt(&c.SelectedAirlineID, profile.AirlineID)
+	setInt(&c.SelectedAircraftID, profile.AircraftID)
+	setString(&c.FlightType, strings.ToUpper(profile.FlightType))
+	setString(&c.Network, profile.Network)
+	setString(&c.Simulator, profile.Simulator)
+	setString(&c.SourceName, profile.SourceName)
+	if len(profile.PrefileFields) > 0 {
+		c.PrefileFields = profile.PrefileFields
+	}
+	setString(&c.PrefileFieldsFile, profile.PrefileFieldsFile)
+	setString(&c.WeightUnit, strings.ToLower(profile.WeightUnit))
+	setString(&c.UDPBindHost, profile.UDPBindHost)
+	setInt(&c.UDPBindPort, profile.UDPBindPort)
+	setString(&c.ControlAddr, profile.ControlAddr)
+	setString(&c.WebAddr, profile.WebAddr)
+	setString(&c.MetricsAddr, profile.MetricsAddr)
+
+	c.profileDataDir = profile.DataDir
+	c.baseDataDir = c.DataDir
+	c.DataDir = c.profileDir(c.DataDir)
+	return nil
+}
+
+// profileDir is the profile's data directory under base, unless the profile
+// sets its own.
+func (c *Config) profileDir(base string) string {
+	if c.profileDataDir != "" {
+		return c.profileDataDir
+	}
+	return filepath.Join(base, "profiles", c.Profile)
+}
+
+// legacyData is what PXP kept in the base data directory before profiles had
+// directories of their own.
+var legacyData = []string{"logbook.db", "acars-outbox.json", "airports.json", "tracks"}
+
+// LegacyData lists the data left in the base data directory that the
+// profile's data directory doesn't have yet.
+func (c *Config) LegacyData() []string {
+	if c.baseDataDir == "" || filepath.Clean(c.baseDataDir) == filepath.Clean(c.DataDir) {
+		return nil
+	}
+
+	var found []string
+	for _, name := range legacyData {
+		if _, err := os.Stat(filepath.Join(c.baseDataDir, name)); err != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(c.DataDir, name)); !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		found = append(found, name)
+	}
+	return found
+}
+
+// MigrateDataDir moves LegacyData into the profile's data directory, so the
+// logbook and ACARS outbox carry on after adopting a profile. It returns the
+// paths moved.
+func (c *Config) MigrateDataDir() ([]string, error) {
+	names := c.LegacyData()
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if err := os.MkdirAll(c.DataDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	var moved []string
+	for _, name := range names {
+		from := filepath.Join(c.baseDataDir, name)
+		if err := os.Rename(from, filepath.Join(c.DataDir, name)); err != nil {
+			return moved, fmt.Errorf("failed to move %s: %w", from, err)
+		}
+		moved = append(moved, from)
+	}
+	return moved, nil
+}
+
+func setString(dst *string, value string) {
+	if value != "" {
+		*dst = value
+	}
+}
+
+func setInt(dst *int, value int) {
+	if value != 0 {
+		*dst = value
+	}
+}
@@ -0,0 +1,239 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfigFile = `profile: qantas
+profiles:
+  qantas:
+    base_url: https://qantas.example.com
+    api_key_env: QANTAS_API_KEY
+    network: VATSIM
+    udp_bind_port: 47001
+  virgin:
+    base_url: https://virgin.example.com
+    api_key: virginkey
+    airline_id: 4
+`
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSelectProfile(t *testing.T) {
+	file, err := LoadFile(writeConfigFile(t, testConfigFile))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	noDefault := &File{Profiles: file.Profiles}
+	only := &File{Profiles: map[string]Profile{"virgin": file.Profiles["virgin"]}}
+
+	tests := []struct {
+		name string
+		file *File
+		flag string
+		env  string
+		want string
+	}{
+		{name: "flag", file: file, flag: "virgin", env: "qantas", want: "virgin"},
+		{name: "PXP_PROFILE", file: file, env: "virgin", want: "virgin"},
+		{name: "file default", file: file, want: "qantas"},
+		{name: "only profile", file: only, want: "virgin"},
+		{name: "none chosen", file: noDefault, want: ""},
+		{name: "no profiles", file: &File{}, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("PXP_PROFILE", tt.env)
+			got, err := tt.file.SelectProfile(tt.flag)
+			if err != nil {
+				t.Fatalf("SelectProfile() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected profile %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSelectUnknownProfile(t *testing.T) {
+	t.Setenv("PXP_PROFILE", "")
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	file, err := LoadFile(writeConfigFile(t, testConfigFile))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	_, err = file.SelectProfile("delta")
+	if err == nil || !strings.Contains(err.Error(), `no profile "delta", expected one of: qantas, virgin`) {
+		t.Errorf("Expected an unknown profile error listing the profiles, got %v", err)
+	}
+
+	t.Setenv("PXP_PROFILE", "delta")
+	if _, err := file.SelectProfile(""); err == nil {
+		t.Error("Expected an unknown PXP_PROFILE to fail")
+	}
+
+	missing, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	_, err = missing.SelectProfile("qantas")
+	if err == nil || !strings.Contains(err.Error(), "there's no config file") {
+		t.Errorf("Expected an error about the missing config file, got %v", err)
+	}
+
+	_, err = LoadFile(writeConfigFile(t, "profile: delta\nprofiles:\n  qantas: {}\n"))
+	if err == nil || !strings.Contains(err.Error(), `default profile "delta" isn't defined`) {
+		t.Errorf("Expected an undefined default profile to fail, got %v", err)
+	}
+}
+
+func TestEnvOverridesProfile(t *testing.T) {
+	for _, name := range []string{"PHPVMS_API_KEY_FILE", "PHPVMS_API_KEY_COMMAND", "UDP_BIND_HOST", "NETWORK", "PXP_DATA_DIR"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("QANTAS_API_KEY", "profilekey")
+	file, err := LoadFile(writeConfigFile(t, testConfigFile))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	if err := cfg.ApplyProfile(file, "qantas"); err != nil {
+		t.Fatalf("ApplyProfile() error = %v", err)
+	}
+	t.Setenv("PHPVMS_BASE_URL", "https://env.example.com")
+	t.Setenv("PHPVMS_API_KEY", "envkey")
+	t.Setenv("UDP_BIND_PORT", "47999")
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+
+	if cfg.PhpVMSBaseURL != "https://env.example.com" {
+		t.Errorf("Expected PHPVMS_BASE_URL over the profile's, got %s", cfg.PhpVMSBaseURL)
+	}
+	if cfg.PhpVMSAPIKey.Reveal() != "envkey" || cfg.APIKeySource() != "PHPVMS_API_KEY" {
+		t.Errorf("Expected PHPVMS_API_KEY over the profile's api_key_env, got the key from %s", cfg.APIKeySource())
+	}
+	if cfg.UDPBindPort != 47999 {
+		t.Errorf("Expected UDP_BIND_PORT over the profile's, got %d", cfg.UDPBindPort)
+	}
+	// What the environment doesn't set is the profile's
+	if cfg.Network != "VATSIM" {
+		t.Errorf("Expected the profile's network, got %s", cfg.Network)
+	}
+	if cfg.Profile != "qantas" || !strings.HasSuffix(cfg.DataDir, filepath.Join("profiles", "qantas")) {
+		t.Errorf("Expected the qantas profile's data directory, got %s", cfg.DataDir)
+	}
+}
+
+func TestDataDirOverrideKeepsProfileDir(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("PXP_DATA_DIR", base)
+	file, err := LoadFile(writeConfigFile(t, testConfigFile))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	if err := cfg.ApplyProfile(file, "qantas"); err != nil {
+		t.Fatalf("ApplyProfile() error = %v", err)
+	}
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+
+	want := filepath.Join(base, "profiles", "qantas")
+	if cfg.DataDir != want {
+		t.Errorf("Expected %s, got %s", want, cfg.DataDir)
+	}
+}
+
+func TestMigrateDataDir(t *testing.T) {
+	base := t.TempDir()
+	t.Setenv("PXP_DATA_DIR", base)
+	for _, name := range []string{"logbook.db", "acars-outbox.json", "control-token"} {
+		if err := os.WriteFile(filepath.Join(base, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(base, "tracks"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadFile(writeConfigFile(t, testConfigFile))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	cfg := DefaultConfig()
+	if err := cfg.ApplyProfile(file, "qantas"); err != nil {
+		t.Fatalf("ApplyProfile() error = %v", err)
+	}
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	// The profile's own outbox is kept
+	if err := os.MkdirAll(cfg.DataDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg.OutboxPath(), []byte("profile"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := cfg.MigrateDataDir()
+	if err != nil {
+		t.Fatalf("MigrateDataDir() error = %v", err)
+	}
+	if len(moved) != 2 {
+		t.Errorf("Expected the logbook and tracks to be moved, got %v", moved)
+	}
+	if data, err := os.ReadFile(cfg.LogbookPath()); err != nil || string(data) != "logbook.db" {
+		t.Errorf("Expected the logbook in the profile's data directory, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(cfg.TracksDir()); err != nil {
+		t.Errorf("Expected the tracks in the profile's data directory, got %v", err)
+	}
+	if data, _ := os.ReadFile(cfg.OutboxPath()); string(data) != "profile" {
+		t.Errorf("Expected the profile's outbox to be kept, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(base, "control-token")); err != nil {
+		t.Errorf("Expected the control token to stay, got %v", err)
+	}
+	if legacy := cfg.LegacyData(); len(legacy) != 0 {
+		t.Errorf("Expected nothing left to move, got %v", legacy)
+	}
+}
+
+func TestProfileAPIKeyEnv(t *testing.T) {
+	for _, name := range []string{"PHPVMS_API_KEY", "PHPVMS_API_KEY_FILE", "PHPVMS_API_KEY_COMMAND"} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("QANTAS_API_KEY", "profilekey")
+	file, err := LoadFile(writeConfigFile(t, testConfigFile))
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	if err := cfg.ApplyProfile(file, "qantas"); err != nil {
+		t.Fatalf("ApplyProfile() error = %v", err)
+	}
+	if err := cfg.LoadFromEnv(); err != nil {
+		t.Fatalf("LoadFromEnv() error = %v", err)
+	}
+	if cfg.PhpVMSAPIKey.Reveal() != "profilekey" || cfg.APIKeySource() != "QANTAS_API_KEY" {
+		t.Errorf("Expected the key from the profile's api_key_env, got it from %q", cfg.APIKeySource())
+	}
+	if err := cfg.ApplyProfile(file, "delta"); err == nil {
+		t.Error("Expected applying an unknown profile to fail")
+	}
+}
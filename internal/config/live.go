@@ -0,0 +1,37 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Live is the running configuration, which can be reloaded while other
+// goroutines read it. The Config it gives out is a snapshot that's replaced on
+// every change, never modified.
+type Live struct {
+	mu      sync.Mutex
+	current atomic.Pointer[Config]
+}
+
+func NewLive(cfg *Config) *Live {
+	live := &Live{}
+	live.current.Store(cfg)
+	return live
+}
+
+// Get returns the current configuration, which mustn't be modified.
+func (l *Live) Get() *Config {
+	return l.current.Load()
+}
+
+// Update replaces the configuration with a copy changed by fn, and returns the
+// new one.
+func (l *Live) Update(fn func(cfg *Config)) *Config {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := *l.current.Load()
+	fn(&next)
+	l.current.Store(&next)
+	return &next
+}
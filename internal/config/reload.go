@@ -0,0 +1,104 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Changes are the settings that differ between the running configuration and
+// a reloaded one, by their environment variable names.
+type Changes struct {
+	// Applied take effect as soon as the configuration is applied
+	Applied []string
+	// Restart only take effect when PXP is restarted
+	Restart []string
+}
+
+// Changes compares the configuration with a reloaded one.
+func (c *Config) Changes(next *Config) Changes {
+	var changes Changes
+	applied := func(name string, changed bool) {
+		if changed {
+			changes.Applied = append(changes.Applied, name)
+		}
+	}
+	restart := func(name string, changed bool) {
+		if changed {
+			changes.Restart = append(changes.Restart, name)
+		}
+	}
+
+	applied("PHPVMS_BASE_URL", c.PhpVMSBaseURL != next.PhpVMSBaseURL)
+	applied("PHPVMS_API_KEY", c.PhpVMSAPIKey.Reveal() != next.PhpVMSAPIKey.Reveal())
+	applied("UDP_BIND_HOST", c.UDPBindHost != next.UDPBindHost)
+	applied("UDP_BIND_PORT", c.UDPBindPort != next.UDPBindPort)
+	applied("LOG_LEVEL", c.LogLevel != next.LogLevel)
+	applied("SIMBRIEF_USER_ID", c.SimbriefUserID != next.SimbriefUserID)
+	applied("FMS_OUTPUT_DIR", c.FMSOutputDir != next.FMSOutputDir)
+	applied("FLIGHT_TYPE", c.FlightType != next.FlightType)
+	applied("NETWORK", c.Network != next.Network)
+	applied("SIMULATOR", c.Simulator != next.Simulator)
+	applied("PREFILE_FIELDS_FILE", c.PrefileFieldsFile != next.PrefileFieldsFile || !reflect.DeepEqual(c.PrefileFields, next.PrefileFields))
+	applied("WEIGHT_UNIT", c.WeightUnit != next.WeightUnit)
+	applied("EXPORT_DIR", c.ExportDir != next.ExportDir)
+
+	restart("PREFS_ENCRYPTED", c.PrefsEncrypted != next.PrefsEncrypted)
+	restart("PIREP_SOURCE_NAME", c.SourceName != next.SourceName)
+	restart("PXP_DATA_DIR", c.DataDir != next.DataDir)
+	restart("RULES_FILE", c.RulesFile != next.RulesFile)
+	restart("CONTROL_SOCKET", c.ControlSocket != next.ControlSocket)
+	restart("CONTROL_ADDR", c.ControlAddr != next.ControlAddr)
+	restart("CONTROL_TOKEN", c.ControlToken != next.ControlToken)
+	restart("METRICS_ADDR", c.MetricsAddr != next.MetricsAddr)
+	restart("WEB_ADDR", c.WebAddr != next.WebAddr)
+	restart("LOG_FORMAT", c.LogFormat != next.LogFormat)
+	restart("LOG_FILE", c.LogFile != next.LogFile)
+	restart("LOG_MAX_SIZE", c.LogMaxSize != next.LogMaxSize)
+	restart("LOG_MAX_AGE", c.LogMaxAge != next.LogMaxAge)
+	restart("LOG_MAX_BACKUPS", c.LogMaxBackups != next.LogMaxBackups)
+	return changes
+}
+
+// Has is whether any of the named settings changed and can be applied.
+func (c Changes) Has(names ...string) bool {
+	for _, name := range names {
+		if slices.Contains(c.Applied, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Credentials is whether the phpVMS site or API key changed, which would
+// leave an active PIREP behind, as it belongs to the old site or pilot.
+func (c Changes) Credentials() bool {
+	return c.Has("PHPVMS_BASE_URL", "PHPVMS_API_KEY")
+}
+
+func (c Changes) None() bool {
+	return len(c.Applied) == 0 && len(c.Restart) == 0
+}
+
+// Apply takes the settings that can change while PXP runs from a reloaded
+// configuration. The rest keep their values until a restart. The running
+// configuration is changed through Live.Update.
+func (c *Config) Apply(next *Config) {
+	c.Profiles = next.Profiles
+	c.PhpVMSBaseURL = next.PhpVMSBaseURL
+	c.PhpVMSAPIKey = next.PhpVMSAPIKey
+	c.PhpVMSAPIKeyFile = next.PhpVMSAPIKeyFile
+	c.PhpVMSAPIKeyCommand = next.PhpVMSAPIKeyCommand
+	c.apiKeyEnv = next.apiKeyEnv
+	c.UDPBindHost = next.UDPBindHost
+	c.UDPBindPort = next.UDPBindPort
+	c.LogLevel = next.LogLevel
+	c.SimbriefUserID = next.SimbriefUserID
+	c.FMSOutputDir = next.FMSOutputDir
+	c.FlightType = next.FlightType
+	c.Network = next.Network
+	c.Simulator = next.Simulator
+	c.PrefileFieldsFile = next.PrefileFieldsFile
+	c.PrefileFields = next.PrefileFields
+	c.WeightUnit = next.WeightUnit
+	c.ExportDir = next.ExportDir
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+)
+
+func TestLiveReloadWhileReading(t *testing.T) {
+	configs := make([]*Config, 2)
+	for i, site := range []string{"a", "b"} {
+		configs[i] = DefaultConfig()
+		configs[i].PhpVMSBaseURL = "https://" + site + ".example.com"
+		configs[i].PhpVMSAPIKey = secret.New("key-" + site)
+		configs[i].UDPBindPort = 47000 + i
+		configs[i].WebAddr = site
+	}
+	live := NewLive(configs[0])
+
+	stop := make(chan struct{})
+	var readers sync.WaitGroup
+	for range 4 {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// A snapshot is never half reloaded
+				cfg := live.Get()
+				want := "https://a.example.com"
+				if cfg.PhpVMSAPIKey.Reveal() == "key-b" {
+					want = "https://b.example.com"
+				}
+				if cfg.PhpVMSBaseURL != want {
+					t.Errorf("Expected %s with %s, got %s", want, cfg.PhpVMSAPIKey.Reveal(), cfg.PhpVMSBaseURL)
+					return
+				}
+				_ = cfg.SelectedAircraftID
+			}
+		}()
+	}
+
+	var writers sync.WaitGroup
+	writers.Add(2)
+	go func() {
+		defer writers.Done()
+		for i := range 1000 {
+			next := configs[i%2]
+			live.Update(func(cfg *Config) {
+				cfg.Apply(next)
+			})
+		}
+	}()
+	go func() {
+		defer writers.Done()
+		for i := range 1000 {
+			live.Update(func(cfg *Config) {
+				cfg.SelectedAircraftID = i
+			})
+		}
+	}()
+	writers.Wait()
+	close(stop)
+	readers.Wait()
+
+	cfg := live.Get()
+	if cfg.PhpVMSBaseURL != "https://b.example.com" || cfg.UDPBindPort != 47001 {
+		t.Errorf("Expected the last reload to be applied, got %s port %d", cfg.PhpVMSBaseURL, cfg.UDPBindPort)
+	}
+	if cfg.SelectedAircraftID != 999 {
+		t.Errorf("Expected no update to be lost, got aircraft %d", cfg.SelectedAircraftID)
+	}
+	// Only what can change while running is applied
+	if cfg.WebAddr != "a" {
+		t.Errorf("Expected WEB_ADDR to wait for a restart, got %s", cfg.WebAddr)
+	}
+	if configs[0].PhpVMSBaseURL != "https://a.example.com" {
+		t.Errorf("Expected the first snapshot to be left as it was, got %s", configs[0].PhpVMSBaseURL)
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+)
+
+// keyCommandTimeout leaves time to unlock a password manager.
+const keyCommandTimeout = time.Minute
+
+// encryptedPrefix marks an encrypted preference value.
+const encryptedPrefix = "enc:"
+
+// ResolveAPIKey reads the API key from PHPVMS_API_KEY_FILE or runs
+// PHPVMS_API_KEY_COMMAND, unless it was given directly. It's only done when
+// the key is needed, as the command may prompt to unlock a password manager.
+func (c *Config) ResolveAPIKey(ctx context.Context) error {
+	var key string
+	var err error
+	switch {
+	case c.PhpVMSAPIKey.IsSet():
+		return nil
+	case c.PhpVMSAPIKeyFile != "":
+		if key, err = readKeyFile(c.PhpVMSAPIKeyFile); err != nil {
+			return err
+		}
+		if key == "" {
+			return fmt.Errorf("PHPVMS_API_KEY_FILE %s is empty", c.PhpVMSAPIKeyFile)
+		}
+	case c.PhpVMSAPIKeyCommand != "":
+		if key, err = runKeyCommand(ctx, c.PhpVMSAPIKeyCommand); err != nil {
+			return err
+		}
+		if key == "" {
+			return fmt.Errorf("PHPVMS_API_KEY_COMMAND printed nothing")
+		}
+	default:
+		return nil
+	}
+
+	c.PhpVMSAPIKey = secret.New(key)
+	return nil
+}
+
+// APIKeySource describes where the API key comes from, without giving it
+// away.
+func (c *Config) APIKeySource() string {
+	switch {
+	case c.PhpVMSAPIKeyFile != "":
+		return "file " + c.PhpVMSAPIKeyFile
+	case c.PhpVMSAPIKeyCommand != "":
+		return "command"
+	case c.apiKeyEnv != "":
+		return c.apiKeyEnv
+	case c.PhpVMSAPIKey.IsSet():
+		return "PHPVMS_API_KEY"
+	}
+	return ""
+}
+
+// readKeyFile reads the key from the first line of a file, refusing to if
+// other users can read it.
+func readKeyFile(path string) (string, error) {
+	if err := checkPrivate(path); err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read API key file: %w", err)
+	}
+	return firstLine(data), nil
+}
+
+// runKeyCommand runs a command such as `pass show phpvms` and takes the key
+// from the first line it prints. The terminal is passed through, so the
+// command can ask for a passphrase.
+func runKeyCommand(ctx context.Context, command string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, keyCommandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout bytes.Buffer
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("failed to run PHPVMS_API_KEY_COMMAND: %w", err)
+	}
+	return firstLine(stdout.Bytes()), nil
+}
+
+func firstLine(data []byte) string {
+	line, _, _ := strings.Cut(string(data), "\n")
+	return strings.TrimSpace(line)
+}
+
+// checkPrivate returns an error if other users can read the file. Windows
+// permissions don't map onto modes, so they aren't checked.
+func checkPrivate(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("failed to check permissions: %w", err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0o004 != 0 {
+		return fmt.Errorf("%s is readable by other users, run chmod 600 %s", path, path)
+	}
+	return nil
+}
+
+// KeyFileWarnings warns about the .env file and config file if they hold an
+// API key that other users can read.
+func KeyFileWarnings(dotEnvPath string, file *File, profile string) []string {
+	var warnings []string
+	dotEnvPath = DotEnvPath(dotEnvPath)
+	if values, err := godotenv.Read(dotEnvPath); err == nil && values["PHPVMS_API_KEY"] != "" {
+		if err := checkPrivate(dotEnvPath); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%v, as it holds PHPVMS_API_KEY", err))
+		}
+	}
+	if file.Path != "" && file.Profiles[profile].APIKey != "" {
+		if err := checkPrivate(file.Path); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%v, as profile %s holds an API key", err, profile))
+		}
+	}
+	return warnings
+}
+
+// preferenceCipher encrypts preferences with a key derived from the API key,
+// so they can only be read by someone who has it.
+func (c *Config) preferenceCipher() (cipher.AEAD, error) {
+	if !c.PhpVMSAPIKey.IsSet() {
+		return nil, errors.New("the API key is needed to encrypt or decrypt preferences")
+	}
+	key, err := hkdf.Key(sha256.New, []byte(c.PhpVMSAPIKey.Reveal()), nil, "phpvms-xplane preferences", 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptPreference encrypts a preference's value, bound to its key so values
+// can't be swapped between keys.
+func (c *Config) encryptPreference(key, value string) (string, error) {
+	aead, err := c.preferenceCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(key))
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (c *Config) decryptPreference(key, value string) (string, error) {
+	aead, err := c.preferenceCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, encryptedPrefix))
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted preference %s", key)
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(key))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt preference %s, was the API key changed?", key)
+	}
+	return string(plain), nil
+}
@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+)
+
+func TestResolveAPIKeyFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes aren't checked on Windows")
+	}
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte("filekey\nignored\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.PhpVMSAPIKeyFile = path
+	if err := cfg.ResolveAPIKey(context.Background()); err != nil {
+		t.Fatalf("ResolveAPIKey() error = %v", err)
+	}
+	if cfg.PhpVMSAPIKey.Reveal() != "filekey" {
+		t.Errorf("Expected the first line of the file, got %q", cfg.PhpVMSAPIKey.Reveal())
+	}
+
+	if err := os.Chmod(path, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg = DefaultConfig()
+	cfg.PhpVMSAPIKeyFile = path
+	err := cfg.ResolveAPIKey(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "readable by other users") {
+		t.Errorf("Expected a world-readable key file to be rejected, got %v", err)
+	}
+	if cfg.PhpVMSAPIKey.IsSet() {
+		t.Error("Expected no key from a world-readable file")
+	}
+}
+
+func TestResolveAPIKeyCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr string
+	}{
+		{name: "prints key", command: "echo cmdkey", want: "cmdkey"},
+		{name: "fails", command: "exit 1", wantErr: "failed to run PHPVMS_API_KEY_COMMAND"},
+		{name: "prints nothing", command: "exit 0", wantErr: "printed nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.PhpVMSAPIKeyCommand = tt.command
+			err := cfg.ResolveAPIKey(context.Background())
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				if cfg.PhpVMSAPIKey.IsSet() {
+					t.Error("Expected no key to be set")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveAPIKey() error = %v", err)
+			}
+			if cfg.PhpVMSAPIKey.Reveal() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, cfg.PhpVMSAPIKey.Reveal())
+			}
+		})
+	}
+}
+
+func TestKeyFileWarnings(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes aren't checked on Windows")
+	}
+	dir := t.TempDir()
+	dotEnv := filepath.Join(dir, ".env")
+	if err := os.WriteFile(dotEnv, []byte("PHPVMS_API_KEY=abc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("profiles:\n  va:\n    api_key: abc\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := LoadFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	warnings := KeyFileWarnings(dotEnv, file, "va")
+	if len(warnings) != 2 {
+		t.Fatalf("Expected warnings for both files, got %q", warnings)
+	}
+	if !strings.Contains(warnings[0], dotEnv) || !strings.Contains(warnings[1], "profile va") {
+		t.Errorf("Unexpected warnings %q", warnings)
+	}
+
+	for _, path := range []string{dotEnv, configPath} {
+		if err := os.Chmod(path, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if warnings := KeyFileWarnings(dotEnv, file, "va"); len(warnings) != 0 {
+		t.Errorf("Expected no warnings for private files, got %q", warnings)
+	}
+}
+
+func TestEncryptedPreferences(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prefs")
+	cfg := DefaultConfig()
+	cfg.Profile = "va"
+	cfg.PhpVMSAPIKey = secret.New("apikey123")
+	cfg.PrefsEncrypted = true
+	cfg.SelectedAirlineID = 3
+	cfg.SelectedAircraftID = 42
+	if err := cfg.SavePreferences(path); err != nil {
+		t.Fatalf("SavePreferences() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "=42") || strings.Count(string(data), "="+encryptedPrefix) != 2 {
+		t.Errorf("Expected encrypted values, got %s", data)
+	}
+
+	loaded := DefaultConfig()
+	loaded.Profile = "va"
+	loaded.PhpVMSAPIKey = secret.New("apikey123")
+	if err := loaded.LoadPreferences(path); err != nil {
+		t.Fatalf("LoadPreferences() error = %v", err)
+	}
+	if loaded.SelectedAirlineID != 3 || loaded.SelectedAircraftID != 42 {
+		t.Errorf("Expected airline 3 and aircraft 42, got %d and %d", loaded.SelectedAirlineID, loaded.SelectedAircraftID)
+	}
+
+	wrongKey := DefaultConfig()
+	wrongKey.Profile = "va"
+	wrongKey.PhpVMSAPIKey = secret.New("otherkey")
+	if err := wrongKey.LoadPreferences(path); err == nil || !strings.Contains(err.Error(), "was the API key changed") {
+		t.Errorf("Expected the wrong API key to fail to decrypt, got %v", err)
+	}
+
+	noKey := DefaultConfig()
+	noKey.Profile = "va"
+	if err := noKey.LoadPreferences(path); err == nil {
+		t.Error("Expected decrypting without an API key to fail")
+	}
+
+	// A value moved to another key doesn't decrypt
+	value, err := cfg.encryptPreference("va.SELECTED_AIRLINE_ID", "3")
+	if err != nil {
+		t.Fatalf("encryptPreference() error = %v", err)
+	}
+	if _, err := cfg.decryptPreference("va.SELECTED_AIRCRAFT_ID", value); err == nil {
+		t.Error("Expected a value under the wrong key name to fail to decrypt")
+	}
+	if _, err := cfg.decryptPreference("va.SELECTED_AIRLINE_ID", encryptedPrefix+"not base64!"); err == nil {
+		t.Error("Expected an invalid value to fail to decrypt")
+	}
+}
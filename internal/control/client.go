@@ -0,0 +1,195 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// Client is a Backend that sends commands to a running PXP over its control
+// socket or loopback port.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the control socket at path.
+func NewClient(path, token string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", path)
+		},
+	}
+
+	// The host is ignored, the transport always dials the socket
+	return newClient("http://pxp", token, transport)
+}
+
+// NewHTTPClient returns a client for the control API on the loopback address
+// addr.
+func NewHTTPClient(addr, token string) *Client {
+	return newClient("http://"+addr, token, http.DefaultTransport)
+}
+
+func newClient(baseURL, token string, transport http.RoundTripper) *Client {
+	return &Client{
+		BaseURL: baseURL,
+		Token:   token,
+		HTTPClient: &http.Client{
+			Transport: transport,
+			// Filing flushes the ACARS log and waits on phpVMS, so allow
+			// more than a single API call's worth of time
+			Timeout: time.Minute,
+		},
+	}
+}
+
+// Dial returns a client if a PXP is listening on the socket at path.
+func Dial(path, token string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return NewClient(path, token), nil
+}
+
+// DialHTTP returns a client if a PXP is listening on the loopback address
+// addr.
+func DialHTTP(addr, token string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		return nil, err
+	}
+	conn.Close()
+	return NewHTTPClient(addr, token), nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body interface{}, result interface{}) error {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %w", err)
+		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bodyReader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach PXP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil || errResp.Error == "" {
+			return fmt.Errorf("PXP returned status %d", resp.StatusCode)
+		}
+		return fmt.Errorf("%s", errResp.Error)
+	}
+
+	if result != nil {
+		if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := c.do(ctx, http.MethodGet, "/v1/status", nil, &status)
+	return status, err
+}
+
+func (c *Client) Snapshot(ctx context.Context) (MetricsSnapshot, error) {
+	var snapshot MetricsSnapshot
+	err := c.do(ctx, http.MethodGet, "/v1/metrics", nil, &snapshot)
+	return snapshot, err
+}
+
+func (c *Client) ActivePIREP(ctx context.Context) (ActivePIREP, error) {
+	var active ActivePIREP
+	err := c.do(ctx, http.MethodGet, "/v1/pirep", nil, &active)
+	return active, err
+}
+
+func (c *Client) Prefile(ctx context.Context, data api.PrefilePIREPRequest) (string, error) {
+	var response prefileResponse
+	err := c.do(ctx, http.MethodPost, "/v1/prefile", data, &response)
+	return response.PirepID, err
+}
+
+func (c *Client) File(ctx context.Context, request FileRequest) (api.FilePIREPRequest, error) {
+	var data api.FilePIREPRequest
+	err := c.do(ctx, http.MethodPost, "/v1/file", request, &data)
+	return data, err
+}
+
+func (c *Client) Cancel(ctx context.Context, pirepID string) (string, error) {
+	var response pirepRequest
+	err := c.do(ctx, http.MethodPost, "/v1/cancel", pirepRequest{PirepID: pirepID}, &response)
+	return response.PirepID, err
+}
+
+func (c *Client) Resume(ctx context.Context, pirepID string) (models.ListedPIREP, error) {
+	var pirep models.ListedPIREP
+	err := c.do(ctx, http.MethodPost, "/v1/resume", pirepRequest{PirepID: pirepID}, &pirep)
+	return pirep, err
+}
+
+func (c *Client) Reset(ctx context.Context) (string, error) {
+	var response pirepRequest
+	err := c.do(ctx, http.MethodPost, "/v1/reset", nil, &response)
+	return response.PirepID, err
+}
+
+func (c *Client) PIREPs(ctx context.Context) ([]models.ListedPIREP, error) {
+	var pireps []models.ListedPIREP
+	err := c.do(ctx, http.MethodGet, "/v1/pireps", nil, &pireps)
+	return pireps, err
+}
+
+func (c *Client) Fleet(ctx context.Context) ([]models.AircraftFleet, error) {
+	var fleet []models.AircraftFleet
+	err := c.do(ctx, http.MethodGet, "/v1/fleet", nil, &fleet)
+	return fleet, err
+}
+
+func (c *Client) Airlines(ctx context.Context) ([]models.Airline, error) {
+	var airlines []models.Airline
+	err := c.do(ctx, http.MethodGet, "/v1/airlines", nil, &airlines)
+	return airlines, err
+}
+
+func (c *Client) LogLevel(ctx context.Context) (string, error) {
+	var response logLevelRequest
+	err := c.do(ctx, http.MethodGet, "/v1/log-level", nil, &response)
+	return response.Level, err
+}
+
+func (c *Client) SetLogLevel(ctx context.Context, level string) (string, error) {
+	var response logLevelRequest
+	err := c.do(ctx, http.MethodPost, "/v1/log-level", logLevelRequest{Level: level}, &response)
+	return response.Level, err
+}
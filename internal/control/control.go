@@ -0,0 +1,97 @@
+package control
+
+import (
+	"context"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// Backend runs PIREP commands, either in a running PXP (through its control
+// server) or straight against the phpVMS API.
+type Backend interface {
+	Status(ctx context.Context) (Status, error)
+	Snapshot(ctx context.Context) (MetricsSnapshot, error)
+	ActivePIREP(ctx context.Context) (ActivePIREP, error)
+	Prefile(ctx context.Context, data api.PrefilePIREPRequest) (string, error)
+	File(ctx context.Context, request FileRequest) (api.FilePIREPRequest, error)
+	Cancel(ctx context.Context, pirepID string) (string, error)
+	Resume(ctx context.Context, pirepID string) (models.ListedPIREP, error)
+	Reset(ctx context.Context) (string, error)
+	PIREPs(ctx context.Context) ([]models.ListedPIREP, error)
+	Fleet(ctx context.Context) ([]models.AircraftFleet, error)
+	Airlines(ctx context.Context) ([]models.Airline, error)
+	LogLevel(ctx context.Context) (string, error)
+	SetLogLevel(ctx context.Context, level string) (string, error)
+}
+
+type Status struct {
+	Running      bool       `json:"running"` // answered by a running PXP
+	PirepID      string     `json:"pirep_id,omitempty"`
+	State        string     `json:"state,omitempty"`
+	Phase        string     `json:"phase,omitempty"`
+	FlightNumber string     `json:"flight_number,omitempty"`
+	Departure    string     `json:"departure,omitempty"`
+	Arrival      string     `json:"arrival,omitempty"`
+	Score        *int       `json:"score,omitempty"`
+	Violations   int        `json:"violations"`
+	FlightTime   *int       `json:"flight_time,omitempty"` // minutes
+	Distance     *int       `json:"distance,omitempty"`    // nm
+	Fuel         *int       `json:"fuel,omitempty"`        // kg on board
+	LastPacket   *time.Time `json:"last_packet,omitempty"`
+	PendingACARS int        `json:"pending_acars"`
+}
+
+// MetricsSnapshot is the latest telemetry, with the API errors as text so it
+// survives JSON.
+type MetricsSnapshot struct {
+	udp.MetricsSnapshot
+	UpdateFlightErr   string `json:"update_flight_err,omitempty"`
+	UpdatePositionErr string `json:"update_position_err,omitempty"`
+}
+
+// ActivePIREP is the PIREP a running PXP is tracking.
+type ActivePIREP struct {
+	PirepID     string            `json:"pirep_id"`
+	State       string            `json:"state"`
+	Track       *track.Meta       `json:"track,omitempty"`
+	InitialFuel int               `json:"initial_fuel"` // kg
+	Score       int               `json:"score"`
+	Violations  []rules.Violation `json:"violations"`
+}
+
+// FileRequest overrides the values PXP would otherwise file from telemetry.
+// Unset fields keep the telemetry values.
+type FileRequest struct {
+	PirepID      string                 `json:"pirep_id,omitempty"`
+	FlightTime   *int                   `json:"flight_time,omitempty"`  // minutes
+	FuelUsedKg   *int                   `json:"fuel_used_kg,omitempty"` // kg
+	Distance     *int                   `json:"distance,omitempty"`     // nm
+	LandingRate  *float64               `json:"landing_rate,omitempty"` // ft/min
+	BlockOffTime string                 `json:"block_off_time,omitempty"`
+	BlockOnTime  string                 `json:"block_on_time,omitempty"`
+	ZFWKg        *int                   `json:"zfw_kg,omitempty"`
+	Notes        string                 `json:"notes,omitempty"`
+	Fares        []api.PIREPFare        `json:"fares,omitempty"`
+	Fields       map[string]interface{} `json:"fields,omitempty"`
+}
+
+type pirepRequest struct {
+	PirepID string `json:"pirep_id,omitempty"`
+}
+
+type prefileResponse struct {
+	PirepID string `json:"pirep_id"`
+}
+
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
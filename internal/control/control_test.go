@@ -0,0 +1,274 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+type fakeBackend struct {
+	filed FileRequest
+}
+
+func (b *fakeBackend) Status(context.Context) (Status, error) {
+	score := 95
+	return Status{Running: true, PirepID: "abc", State: "IN_PROGRESS", Score: &score}, nil
+}
+
+func (b *fakeBackend) Snapshot(context.Context) (MetricsSnapshot, error) {
+	return MetricsSnapshot{MetricsSnapshot: udp.MetricsSnapshot{PacketsAny: 42}, UpdateFlightErr: "timeout"}, nil
+}
+
+func (b *fakeBackend) ActivePIREP(context.Context) (ActivePIREP, error) {
+	return ActivePIREP{PirepID: "abc", State: "IN_PROGRESS", Score: 95}, nil
+}
+
+func (b *fakeBackend) Prefile(_ context.Context, data api.PrefilePIREPRequest) (string, error) {
+	return data.FlightNumber + "-pirep", nil
+}
+
+func (b *fakeBackend) File(_ context.Context, request FileRequest) (api.FilePIREPRequest, error) {
+	b.filed = request
+	return api.FilePIREPRequest{FlightTime: *request.FlightTime, Notes: request.Notes}, nil
+}
+
+func (b *fakeBackend) Cancel(context.Context, string) (string, error) {
+	return "", errors.New("no active PIREP to cancel")
+}
+
+func (b *fakeBackend) Resume(_ context.Context, pirepID string) (models.ListedPIREP, error) {
+	return models.ListedPIREP{ID: pirepID}, nil
+}
+
+func (b *fakeBackend) Reset(context.Context) (string, error) {
+	return "abc", nil
+}
+
+func (b *fakeBackend) PIREPs(context.Context) ([]models.ListedPIREP, error) {
+	return []models.ListedPIREP{{ID: "abc"}, {ID: "def"}}, nil
+}
+
+func (b *fakeBackend) Fleet(context.Context) ([]models.AircraftFleet, error) {
+	return nil, nil
+}
+
+func (b *fakeBackend) Airlines(context.Context) ([]models.Airline, error) {
+	return []models.Airline{{ID: 1, ICAO: "QFA"}}, nil
+}
+
+func (b *fakeBackend) LogLevel(context.Context) (string, error) {
+	return "info", nil
+}
+
+func (b *fakeBackend) SetLogLevel(_ context.Context, level string) (string, error) {
+	return level, nil
+}
+
+func TestClientServerRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pxp.sock")
+	backend := &fakeBackend{}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	served := make(chan error, 1)
+	go func() { served <- NewServer(backend, "secret", nil).Serve(ctx, path) }()
+
+	var client *Client
+	for i := 0; i < 50; i++ {
+		var err error
+		if client, err = Dial(path, "secret"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if client == nil {
+		t.Fatalf("Control socket never came up")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the control socket to be private, got %v (%v)", info.Mode(), err)
+	}
+
+	status, err := client.Status(ctx)
+	if err != nil || status.PirepID != "abc" || status.Score == nil || *status.Score != 95 {
+		t.Errorf("Unexpected status %+v (%v)", status, err)
+	}
+
+	snapshot, err := client.Snapshot(ctx)
+	if err != nil || snapshot.PacketsAny != 42 || snapshot.UpdateFlightErr != "timeout" {
+		t.Errorf("Unexpected metrics %+v (%v)", snapshot, err)
+	}
+
+	if active, err := client.ActivePIREP(ctx); err != nil || active.PirepID != "abc" || active.Score != 95 {
+		t.Errorf("Unexpected active PIREP %+v (%v)", active, err)
+	}
+
+	if reset, err := client.Reset(ctx); err != nil || reset != "abc" {
+		t.Errorf("Expected abc to be reset, got %q (%v)", reset, err)
+	}
+
+	if _, err := NewClient(path, "wrong").Status(ctx); err == nil || err.Error() != "invalid or missing control token" {
+		t.Errorf("Expected a wrong token to be refused, got %v", err)
+	}
+
+	pirepID, err := client.Prefile(ctx, api.PrefilePIREPRequest{FlightNumber: "123"})
+	if err != nil || pirepID != "123-pirep" {
+		t.Errorf("Expected prefiled ID 123-pirep, got %q (%v)", pirepID, err)
+	}
+
+	flightTime := 95
+	data, err := client.File(ctx, FileRequest{FlightTime: &flightTime, Notes: "Smooth"})
+	if err != nil || data.FlightTime != 95 || backend.filed.Notes != "Smooth" {
+		t.Errorf("Unexpected file result %+v (%v)", data, err)
+	}
+
+	if _, err := client.Cancel(ctx, ""); err == nil || err.Error() != "no active PIREP to cancel" {
+		t.Errorf("Expected backend error to be passed through, got %v", err)
+	}
+
+	if level, err := client.SetLogLevel(ctx, "info,api=debug"); err != nil || level != "info,api=debug" {
+		t.Errorf("Expected log level info,api=debug, got %q (%v)", level, err)
+	}
+
+	if pireps, err := client.PIREPs(ctx); err != nil || len(pireps) != 2 {
+		t.Errorf("Expected 2 PIREPs, got %v (%v)", pireps, err)
+	}
+
+	// A second server must not take over the socket
+	if err := NewServer(backend, "secret", nil).Serve(ctx, path); err == nil {
+		t.Errorf("Expected second server to refuse a socket in use")
+	}
+
+	cancel()
+	if err := <-served; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+	if _, err := Dial(path, "secret"); err == nil {
+		t.Errorf("Expected socket to be gone after shutdown")
+	}
+}
+
+func TestOpenAPIDoesNotNeedToken(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	NewServer(&fakeBackend{}, "secret", nil).Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/openapi.json", nil))
+
+	var spec struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	if err := json.NewDecoder(recorder.Body).Decode(&spec); err != nil {
+		t.Fatalf("Failed to decode OpenAPI description: %v", err)
+	}
+	if recorder.Code != http.StatusOK || spec.OpenAPI == "" {
+		t.Errorf("Expected OpenAPI description, got status %d", recorder.Code)
+	}
+	for _, path := range []string{"/v1/status", "/v1/metrics", "/v1/pirep", "/v1/prefile", "/v1/file", "/v1/cancel", "/v1/reset"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("Expected %s to be described", path)
+		}
+	}
+}
+
+func TestListenAndServeRefusesNonLoopback(t *testing.T) {
+	server := NewServer(&fakeBackend{}, "secret", nil)
+	if err := server.ListenAndServe(context.Background(), "0.0.0.0:0"); err == nil {
+		t.Errorf("Expected a non-loopback address to be refused")
+	}
+
+	server.Token = ""
+	if err := server.ListenAndServe(context.Background(), "127.0.0.1:0"); err == nil {
+		t.Errorf("Expected listening without a token to be refused")
+	}
+}
+
+func TestServeRefusesEmptyToken(t *testing.T) {
+	server := NewServer(&fakeBackend{}, "", nil)
+	if err := server.Serve(context.Background(), filepath.Join(t.TempDir(), "pxp.sock")); err == nil {
+		t.Errorf("Expected serving the socket without a token to be refused")
+	}
+
+	request := httptest.NewRequest(http.MethodGet, "/v1/status", nil)
+	request.Header.Set("Authorization", "Bearer ")
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected an empty token to be refused, got status %d", recorder.Code)
+	}
+}
+
+func TestLoadToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "control-token")
+
+	token, err := LoadToken(path)
+	if err != nil || len(token) != 64 {
+		t.Fatalf("Expected a new 64 character token, got %q (%v)", token, err)
+	}
+	if again, err := LoadToken(path); err != nil || again != token {
+		t.Errorf("Expected the saved token %q, got %q (%v)", token, again, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected token file to be private, got %v (%v)", info.Mode(), err)
+	}
+}
+
+func TestLocalFileChecksBeforeResuming(t *testing.T) {
+	requests := 0
+	phpVMS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer phpVMS.Close()
+
+	local := &Local{Service: service.NewFlightService(api.NewClient(phpVMS.URL, secret.New("key"), nil), nil)}
+	flightTime := 95
+	_, err := local.File(context.Background(), FileRequest{FlightTime: &flightTime})
+	if err == nil || err.Error() != "fuel used is unknown, set it explicitly" {
+		t.Errorf("Expected missing fuel to be refused, got %v", err)
+	}
+	if requests != 0 || local.Service.GetActivePirepID() != nil {
+		t.Errorf("Expected nothing resumed, got %d requests and active PIREP %v", requests, local.Service.GetActivePirepID())
+	}
+}
+
+func TestLocalCancelDoesNotResume(t *testing.T) {
+	phpVMS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/pireps":
+			w.Write([]byte(`{"data": [{"id": "abc", "flight_number": "123", "state": 0}]}`))
+		case "/api/pireps/abc/cancel":
+			w.Write([]byte(`{}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer phpVMS.Close()
+
+	dir := t.TempDir()
+	flightService := service.NewFlightService(api.NewClient(phpVMS.URL, secret.New("key"), nil), nil)
+	flightService.Recorder = track.NewRecorder(track.NewStore(filepath.Join(dir, "tracks")), nil)
+	flightService.Logbook = logbook.NewStore(filepath.Join(dir, "logbook.json"))
+
+	cancelled, err := (&Local{Service: flightService}).Cancel(context.Background(), "")
+	if err != nil || cancelled != "abc" {
+		t.Fatalf("Expected abc to be cancelled, got %q (%v)", cancelled, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "tracks")); !os.IsNotExist(err) {
+		t.Errorf("Expected no track for a one-shot cancel, got %v", err)
+	}
+	if entries, err := flightService.Logbook.List(); err != nil || len(entries) != 0 {
+		t.Errorf("Expected no logbook entry for a one-shot cancel, got %+v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,309 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+const lbsPerKg = 2.20462
+
+// Local runs commands in this process. With Metrics set it is the running
+// PXP behind the control socket; without, it talks to phpVMS directly and
+// picks up the latest in-progress PIREP when a command needs one.
+type Local struct {
+	Service *service.FlightService
+	Metrics *udp.Metrics
+	Levels  *logging.Levels
+}
+
+func NewLocal(flightService *service.FlightService, metrics *udp.Metrics) *Local {
+	return &Local{Service: flightService, Metrics: metrics}
+}
+
+func (l *Local) Status(ctx context.Context) (Status, error) {
+	status := Status{Running: l.Metrics != nil}
+	if l.Service.Outbox != nil {
+		status.PendingACARS = l.Service.Outbox.Pending()
+	}
+
+	if l.Metrics != nil {
+		snapshot := l.Metrics.Snapshot()
+		status.FlightTime = snapshot.LastFlightTime
+		status.Distance = snapshot.LastDistance
+		status.Fuel = snapshot.LastFuel
+		status.LastPacket = snapshot.LastPacketTime
+		if snapshot.LastStatus != nil {
+			status.Phase = *snapshot.LastStatus
+		}
+	}
+
+	if id := l.Service.GetActivePirepID(); id != nil {
+		status.PirepID = *id
+		status.State = l.Service.StateMachine.Get().String()
+		if meta, ok := l.Service.Recorder.Meta(); ok {
+			status.FlightNumber = meta.FlightNumber
+			status.Departure = meta.Departure
+			status.Arrival = meta.Arrival
+		}
+
+		score, violations := l.Service.GetScore()
+		status.Score = &score
+		status.Violations = len(violations)
+		return status, nil
+	}
+
+	if l.Metrics != nil {
+		return status, nil
+	}
+
+	// Nothing is running, so report what phpVMS has in progress
+	pirep, err := l.Service.FindInProgressPIREP(ctx, "")
+	if errors.Is(err, service.ErrNoInProgressPIREP) {
+		return status, nil
+	}
+	if err != nil {
+		return status, err
+	}
+	status.PirepID = pirep.ID
+	status.State = models.PirepState(pirep.State).String()
+	status.FlightNumber = pirep.FlightNumber
+	status.Departure = pirep.DptAirportID
+	status.Arrival = pirep.ArrAirportID
+	return status, nil
+}
+
+func (l *Local) Snapshot(ctx context.Context) (MetricsSnapshot, error) {
+	if l.Metrics == nil {
+		return MetricsSnapshot{}, fmt.Errorf("PXP is not running")
+	}
+
+	snapshot := MetricsSnapshot{MetricsSnapshot: l.Metrics.Snapshot()}
+	if err := snapshot.MetricsSnapshot.UpdateFlightErr; err != nil && *err != nil {
+		snapshot.UpdateFlightErr = (*err).Error()
+	}
+	if err := snapshot.MetricsSnapshot.UpdatePositionErr; err != nil && *err != nil {
+		snapshot.UpdatePositionErr = (*err).Error()
+	}
+	return snapshot, nil
+}
+
+func (l *Local) ActivePIREP(ctx context.Context) (ActivePIREP, error) {
+	id := l.Service.GetActivePirepID()
+	if id == nil {
+		return ActivePIREP{}, fmt.Errorf("no active PIREP")
+	}
+
+	active := ActivePIREP{
+		PirepID:     *id,
+		State:       l.Service.StateMachine.Get().String(),
+		InitialFuel: l.Service.GetInitialFuel(),
+	}
+	if meta, ok := l.Service.Recorder.Meta(); ok {
+		active.Track = &meta
+	}
+	active.Score, active.Violations = l.Service.GetScore()
+	if active.Violations == nil {
+		active.Violations = []rules.Violation{}
+	}
+	return active, nil
+}
+
+func (l *Local) Prefile(ctx context.Context, data api.PrefilePIREPRequest) (string, error) {
+	pirepID, err := l.Service.Prefile(ctx, data)
+	if err != nil {
+		return "", err
+	}
+	return *pirepID, nil
+}
+
+func (l *Local) File(ctx context.Context, request FileRequest) (api.FilePIREPRequest, error) {
+	if l.Service.GetActivePirepID() == nil && l.Metrics == nil {
+		// Nothing is running, so the request has to carry what filing needs.
+		// Check it before looking the PIREP up in phpVMS.
+		data := api.FilePIREPRequest{SourceName: l.Service.SourceName}
+		applyFileRequest(&data, request)
+		if err := checkFiling(data); err != nil {
+			return data, err
+		}
+	}
+
+	if _, err := l.activate(ctx, request.PirepID); err != nil {
+		return api.FilePIREPRequest{}, err
+	}
+
+	data, err := l.Service.DraftFiling()
+	if err != nil {
+		return data, err
+	}
+
+	if l.Metrics != nil {
+		snapshot := l.Metrics.Snapshot()
+		if snapshot.LastFlightTime != nil && *snapshot.LastFlightTime > 0 {
+			data.FlightTime = *snapshot.LastFlightTime
+		}
+		if snapshot.LastDistance != nil {
+			data.Distance = *snapshot.LastDistance
+		}
+		if initialFuel := l.Service.GetInitialFuel(); initialFuel > 0 && snapshot.LastFuel != nil {
+			data.FuelUsedLbs = kgToLbs(max(0, initialFuel-*snapshot.LastFuel))
+		}
+	}
+
+	applyFileRequest(&data, request)
+	return data, l.Submit(ctx, data)
+}
+
+// Submit files data for the active PIREP as given. The TUI calls it once the
+// pilot has reviewed the draft.
+func (l *Local) Submit(ctx context.Context, data api.FilePIREPRequest) error {
+	if err := checkFiling(data); err != nil {
+		return err
+	}
+	return l.Service.FileFlight(ctx, data)
+}
+
+// checkFiling rejects filing values phpVMS needs but PXP couldn't work out.
+func checkFiling(data api.FilePIREPRequest) error {
+	if data.FlightTime <= 0 {
+		return fmt.Errorf("flight time is unknown, set it explicitly")
+	}
+	if data.FuelUsedLbs <= 0 {
+		return fmt.Errorf("fuel used is unknown, set it explicitly")
+	}
+	return nil
+}
+
+func applyFileRequest(data *api.FilePIREPRequest, request FileRequest) {
+	if request.FlightTime != nil {
+		data.FlightTime = *request.FlightTime
+	}
+	if request.FuelUsedKg != nil {
+		data.FuelUsedLbs = kgToLbs(*request.FuelUsedKg)
+	}
+	if request.Distance != nil {
+		data.Distance = *request.Distance
+	}
+	if request.LandingRate != nil {
+		data.LandingRate = request.LandingRate
+	}
+	if request.BlockOffTime != "" {
+		data.BlockOffTime = request.BlockOffTime
+	}
+	if request.BlockOnTime != "" {
+		data.BlockOnTime = request.BlockOnTime
+	}
+	if request.ZFWKg != nil {
+		data.ZFWLbs = kgToLbs(*request.ZFWKg)
+	}
+	if request.Notes != "" {
+		data.Notes = request.Notes
+	}
+	if len(request.Fares) > 0 {
+		data.Fares = request.Fares
+	}
+	if len(request.Fields) > 0 {
+		data.Fields = request.Fields
+	}
+}
+
+func (l *Local) Cancel(ctx context.Context, pirepID string) (string, error) {
+	id, err := l.activate(ctx, pirepID)
+	if err != nil {
+		return "", err
+	}
+	return id, l.Service.CancelFlight(ctx)
+}
+
+func (l *Local) Resume(ctx context.Context, pirepID string) (models.ListedPIREP, error) {
+	if active := l.Service.GetActivePirepID(); active != nil {
+		return models.ListedPIREP{}, fmt.Errorf("PIREP %s is already active", *active)
+	}
+
+	pirep, err := l.Service.FindInProgressPIREP(ctx, pirepID)
+	if err != nil {
+		return pirep, err
+	}
+	l.Service.ResumePIREP(pirep)
+	return pirep, nil
+}
+
+// Reset forgets the active PIREP without touching it in phpVMS, and returns
+// its ID, or "" if there wasn't one.
+func (l *Local) Reset(ctx context.Context) (string, error) {
+	if l.Metrics == nil {
+		return "", fmt.Errorf("PXP is not running")
+	}
+
+	var pirepID string
+	if active := l.Service.GetActivePirepID(); active != nil {
+		pirepID = *active
+	}
+	l.Service.ResetActivePirep()
+	return pirepID, nil
+}
+
+func (l *Local) PIREPs(ctx context.Context) ([]models.ListedPIREP, error) {
+	return l.Service.GetPIREPs(ctx)
+}
+
+func (l *Local) Fleet(ctx context.Context) ([]models.AircraftFleet, error) {
+	return l.Service.GetFleet(ctx)
+}
+
+func (l *Local) Airlines(ctx context.Context) ([]models.Airline, error) {
+	return l.Service.GetAirlines(ctx)
+}
+
+func (l *Local) LogLevel(ctx context.Context) (string, error) {
+	if l.Levels == nil {
+		return "", fmt.Errorf("PXP is not running")
+	}
+	return l.Levels.String(), nil
+}
+
+func (l *Local) SetLogLevel(ctx context.Context, level string) (string, error) {
+	if l.Levels == nil {
+		return "", fmt.Errorf("PXP is not running")
+	}
+	if err := l.Levels.Set(level); err != nil {
+		return "", err
+	}
+	return l.Levels.String(), nil
+}
+
+// activate makes sure the PIREP a command is for is the active one. A running
+// PXP only acts on the PIREP it is tracking; otherwise the PIREP is looked up
+// in phpVMS and activated just for this command. It returns the ID of the
+// PIREP it activated.
+func (l *Local) activate(ctx context.Context, pirepID string) (string, error) {
+	if active := l.Service.GetActivePirepID(); active != nil {
+		if pirepID != "" && pirepID != *active {
+			return "", fmt.Errorf("PIREP %s is active, not %s", *active, pirepID)
+		}
+		return *active, nil
+	}
+
+	if l.Metrics != nil {
+		return "", fmt.Errorf("no active PIREP")
+	}
+
+	pirep, err := l.Service.FindInProgressPIREP(ctx, pirepID)
+	if err != nil {
+		return "", err
+	}
+	l.Service.ActivatePIREP(pirep)
+	return pirep.ID, nil
+}
+
+func kgToLbs(kg int) int {
+	return int(math.Ceil(float64(kg) * lbsPerKg))
+}
@@ -0,0 +1,274 @@
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Server exposes a Backend as JSON over HTTP, on a unix socket or a loopback
+// port, so CLI commands and other local tools can drive a running PXP. Every
+// request except the OpenAPI description needs the token as a bearer token.
+type Server struct {
+	Backend Backend
+	Token   string
+	Logger  *slog.Logger
+}
+
+func NewServer(backend Backend, token string, logger *slog.Logger) *Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Server{
+		Backend: backend,
+		Token:   token,
+		Logger:  logger,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/openapi.json", s.handleOpenAPI)
+	mux.Handle("GET /v1/status", s.authorize(s.handleStatus))
+	mux.Handle("GET /v1/metrics", s.authorize(s.handleMetrics))
+	mux.Handle("GET /v1/pirep", s.authorize(s.handleActivePIREP))
+	mux.Handle("POST /v1/prefile", s.authorize(s.handlePrefile))
+	mux.Handle("POST /v1/file", s.authorize(s.handleFile))
+	mux.Handle("POST /v1/cancel", s.authorize(s.handleCancel))
+	mux.Handle("POST /v1/resume", s.authorize(s.handleResume))
+	mux.Handle("POST /v1/reset", s.authorize(s.handleReset))
+	mux.Handle("GET /v1/pireps", s.authorize(s.handlePIREPs))
+	mux.Handle("GET /v1/fleet", s.authorize(s.handleFleet))
+	mux.Handle("GET /v1/airlines", s.authorize(s.handleAirlines))
+	mux.Handle("GET /v1/log-level", s.authorize(s.handleLogLevel))
+	mux.Handle("POST /v1/log-level", s.authorize(s.handleSetLogLevel))
+	return mux
+}
+
+func (s *Server) authorize(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || s.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) != 1 {
+			s.writeJSON(w, http.StatusUnauthorized, errorResponse{Error: "invalid or missing control token"})
+			return
+		}
+		next(w, r)
+	})
+}
+
+// Serve listens on the unix socket at path until ctx is cancelled. A socket
+// left behind by a PXP that didn't shut down cleanly is replaced, but one
+// that is still answering is not. Like ListenAndServe, it refuses to serve
+// without a token.
+func (s *Server) Serve(ctx context.Context, path string) error {
+	if s.Token == "" {
+		return fmt.Errorf("a control token is required to listen on %s", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("another PXP is already listening on %s", path)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+
+	// Bind in a private directory and only move the socket into place once
+	// it's restricted, so it's never reachable with looser permissions
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".pxp-")
+	if err != nil {
+		return fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	bindPath := filepath.Join(dir, "sock")
+	listener, err := net.Listen("unix", bindPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	if err := os.Chmod(bindPath, 0o600); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to restrict control socket: %w", err)
+	}
+	if err := os.Rename(bindPath, path); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to move control socket into place: %w", err)
+	}
+	defer os.Remove(path)
+
+	s.Logger.Info("Control socket listening", "path", path)
+	return s.serve(ctx, listener)
+}
+
+// ListenAndServe listens on the loopback address addr until ctx is
+// cancelled. Other interfaces are refused, the control API is not meant to be
+// reachable from the network.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid control address: %w", err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("control address %s is not a loopback address", addr)
+	}
+	if s.Token == "" {
+		return fmt.Errorf("a control token is required to listen on %s", addr)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control address: %w", err)
+	}
+
+	s.Logger.Info("Control API listening", "addr", listener.Addr().String())
+	return s.serve(ctx, listener)
+}
+
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := s.Backend.Status(r.Context())
+	s.respond(w, status, err)
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := s.Backend.Snapshot(r.Context())
+	s.respond(w, snapshot, err)
+}
+
+func (s *Server) handleActivePIREP(w http.ResponseWriter, r *http.Request) {
+	active, err := s.Backend.ActivePIREP(r.Context())
+	s.respond(w, active, err)
+}
+
+func (s *Server) handlePrefile(w http.ResponseWriter, r *http.Request) {
+	var request api.PrefilePIREPRequest
+	if !s.decode(w, r, &request) {
+		return
+	}
+	pirepID, err := s.Backend.Prefile(r.Context(), request)
+	s.respond(w, prefileResponse{PirepID: pirepID}, err)
+}
+
+func (s *Server) handleFile(w http.ResponseWriter, r *http.Request) {
+	var request FileRequest
+	if !s.decode(w, r, &request) {
+		return
+	}
+	data, err := s.Backend.File(r.Context(), request)
+	s.respond(w, data, err)
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	var request pirepRequest
+	if !s.decode(w, r, &request) {
+		return
+	}
+	pirepID, err := s.Backend.Cancel(r.Context(), request.PirepID)
+	s.respond(w, pirepRequest{PirepID: pirepID}, err)
+}
+
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	var request pirepRequest
+	if !s.decode(w, r, &request) {
+		return
+	}
+	pirep, err := s.Backend.Resume(r.Context(), request.PirepID)
+	s.respond(w, pirep, err)
+}
+
+func (s *Server) handleReset(w http.ResponseWriter, r *http.Request) {
+	pirepID, err := s.Backend.Reset(r.Context())
+	s.respond(w, pirepRequest{PirepID: pirepID}, err)
+}
+
+func (s *Server) handlePIREPs(w http.ResponseWriter, r *http.Request) {
+	pireps, err := s.Backend.PIREPs(r.Context())
+	s.respond(w, pireps, err)
+}
+
+func (s *Server) handleFleet(w http.ResponseWriter, r *http.Request) {
+	fleet, err := s.Backend.Fleet(r.Context())
+	s.respond(w, fleet, err)
+}
+
+func (s *Server) handleAirlines(w http.ResponseWriter, r *http.Request) {
+	airlines, err := s.Backend.Airlines(r.Context())
+	s.respond(w, airlines, err)
+}
+
+func (s *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	level, err := s.Backend.LogLevel(r.Context())
+	s.respond(w, logLevelRequest{Level: level}, err)
+}
+
+func (s *Server) handleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var request logLevelRequest
+	if !s.decode(w, r, &request) {
+		return
+	}
+	level, err := s.Backend.SetLogLevel(r.Context(), request.Level)
+	s.respond(w, logLevelRequest{Level: level}, err)
+}
+
+func (s *Server) decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		s.writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+		return false
+	}
+	return true
+}
+
+func (s *Server) respond(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		s.Logger.Debug("Control request failed", "error", err)
+		s.writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+		return
+	}
+	s.writeJSON(w, http.StatusOK, v)
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.Logger.Debug("Failed to write control response", "error", err)
+	}
+}
@@ -0,0 +1,346 @@
+// Package doctor checks PXP's setup end to end, from the configuration to
+// phpVMS, the UDP port, X-Plane and SimBrief.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+	Skip Status = "skip"
+)
+
+// Result is the outcome of one check, with a hint at how to fix it if it
+// didn't pass.
+type Result struct {
+	Check  string `json:"check"`
+	Status Status `json:"status"`
+	Detail string `json:"detail"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+// Report is every check's result, in the order they were run.
+type Report []Result
+
+// Failed is whether any check failed.
+func (r Report) Failed() bool {
+	for _, result := range r {
+		if result.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+func (r Report) Write(w io.Writer) {
+	counts := map[Status]int{}
+	for _, result := range r {
+		counts[result.Status]++
+		fmt.Fprintf(w, "%-4s  %-18s %s\n", strings.ToUpper(string(result.Status)), result.Check, result.Detail)
+		if result.Hint != "" {
+			fmt.Fprintf(w, "%24s %s\n", "→", result.Hint)
+		}
+	}
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed, %d skipped\n", counts[Pass], counts[Warn], counts[Fail], counts[Skip])
+}
+
+// Options are what the checks need.
+type Options struct {
+	// Config is nil if it couldn't be loaded, with ConfigErr saying why
+	Config    *config.Config
+	ConfigErr error
+	// Listen is how long to wait for a packet from X-Plane
+	Listen time.Duration
+	// Running returns the telemetry of a PXP that's already running, which
+	// will have the UDP port. It's nil if there isn't one.
+	Running func(ctx context.Context) (udp.MetricsSnapshot, error)
+}
+
+// Run runs every check. Checks that depend on one that failed are skipped.
+func Run(ctx context.Context, opts Options) Report {
+	var report Report
+	cfg := opts.Config
+	if cfg == nil {
+		report = append(report, Result{"Configuration", Fail, opts.ConfigErr.Error(),
+			"Fix the config file, .env file or environment variable it names"})
+		for _, check := range []string{"API key", "phpVMS reachable", "API key accepted", "UDP port", "X-Plane packets", "SimBrief"} {
+			report = append(report, Result{Check: check, Status: Skip, Detail: "Needs a configuration that loads"})
+		}
+		return report
+	}
+
+	report = append(report, checkConfig(cfg))
+
+	keyResult := checkAPIKey(ctx, cfg)
+	report = append(report, keyResult)
+	if cfg.PhpVMSBaseURL == "" || keyResult.Status != Pass {
+		report = append(report,
+			Result{Check: "phpVMS reachable", Status: Skip, Detail: "Needs PHPVMS_BASE_URL and an API key"},
+			Result{Check: "API key accepted", Status: Skip, Detail: "Needs PHPVMS_BASE_URL and an API key"})
+	} else {
+		client := api.NewClient(cfg.PhpVMSBaseURL, cfg.PhpVMSAPIKey, nil)
+		reachable, accepted := checkPhpVMS(ctx, client, cfg.PhpVMSBaseURL)
+		report = append(report, reachable, accepted)
+	}
+
+	report = append(report, checkUDP(ctx, cfg, opts)...)
+	report = append(report, checkSimBrief(ctx, api.NewClient("", cfg.PhpVMSAPIKey, nil), cfg.SimbriefUserID))
+	return report
+}
+
+func checkConfig(cfg *config.Config) Result {
+	result := Result{Check: "Configuration", Status: Pass, Detail: "Valid"}
+	if cfg.Profile != "" {
+		result.Detail = "Valid, using profile " + cfg.Profile
+	}
+	if err := cfg.Validate(); err != nil {
+		result.Status = Fail
+		result.Detail = err.Error()
+		result.Hint = "Set it in .env, the environment or the config file profile, as described under Configuration in the README"
+	}
+	return result
+}
+
+func checkAPIKey(ctx context.Context, cfg *config.Config) Result {
+	result := Result{Check: "API key"}
+	if cfg.APIKeySource() == "" {
+		result.Status = Fail
+		result.Detail = "Not set"
+		result.Hint = "Set PHPVMS_API_KEY, PHPVMS_API_KEY_FILE or PHPVMS_API_KEY_COMMAND to the key from your profile on the VA's site"
+		return result
+	}
+	if err := cfg.ResolveAPIKey(ctx); err != nil {
+		result.Status = Fail
+		result.Detail = err.Error()
+		result.Hint = "Check the key file's permissions, or run the key command yourself to see why it fails"
+		return result
+	}
+	if !cfg.PhpVMSAPIKey.IsSet() {
+		result.Status = Fail
+		result.Detail = cfg.APIKeySource() + " is empty"
+		result.Hint = "Set it to the key from your profile on the VA's site"
+		return result
+	}
+	result.Status = Pass
+	result.Detail = "From " + cfg.APIKeySource()
+	return result
+}
+
+// UserClient is the part of the API client checkPhpVMS uses.
+type UserClient interface {
+	GetCurrentUser(ctx context.Context) (map[string]interface{}, error)
+}
+
+// checkPhpVMS asks phpVMS who the API key belongs to, which tells both
+// whether phpVMS can be reached and whether it accepts the key.
+func checkPhpVMS(ctx context.Context, client UserClient, baseURL string) (Result, Result) {
+	reachable := Result{Check: "phpVMS reachable", Status: Pass, Detail: baseURL}
+	accepted := Result{Check: "API key accepted", Status: Skip, Detail: "Needs phpVMS to be reachable"}
+	baseURLHint := "PHPVMS_BASE_URL should be the VA's site, e.g. https://myva.com, without /api"
+
+	user, err := client.GetCurrentUser(ctx)
+	var statusErr *api.StatusError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &statusErr) && (statusErr.StatusCode == 401 || statusErr.StatusCode == 403):
+		accepted.Status = Fail
+		accepted.Detail = fmt.Sprintf("phpVMS rejected the API key (status %d)", statusErr.StatusCode)
+		accepted.Hint = "Copy the API key from your profile on the VA's site; it changes if you regenerate it"
+		return reachable, accepted
+	case errors.As(err, &statusErr) && statusErr.StatusCode == 404:
+		reachable.Status = Fail
+		reachable.Detail = fmt.Sprintf("No phpVMS API at %s/api/user (status 404)", strings.TrimSuffix(baseURL, "/"))
+		reachable.Hint = baseURLHint
+		return reachable, accepted
+	case errors.As(err, &statusErr):
+		reachable.Status = Warn
+		reachable.Detail = fmt.Sprintf("phpVMS returned an error (status %d)", statusErr.StatusCode)
+		reachable.Hint = "The VA's site may be having problems; try again later or ask the VA"
+		return reachable, accepted
+	case errors.As(err, &syntaxErr):
+		reachable.Status = Fail
+		reachable.Detail = "Got a web page rather than the phpVMS API"
+		reachable.Hint = baseURLHint
+		return reachable, accepted
+	case err != nil:
+		reachable.Status = Fail
+		reachable.Detail = err.Error()
+		reachable.Hint = "Check PHPVMS_BASE_URL and your internet connection"
+		return reachable, accepted
+	}
+
+	accepted.Status = Pass
+	accepted.Detail = "Signed in"
+	if data, ok := user["data"].(map[string]interface{}); ok {
+		name, _ := data["name"].(string)
+		ident, _ := data["ident"].(string)
+		accepted.Detail = strings.TrimSpace(fmt.Sprintf("Signed in as %s %s", ident, name))
+	}
+	return reachable, accepted
+}
+
+// checkUDP binds the UDP port and waits for a packet from X-Plane, or asks
+// the running PXP that has the port what it last received.
+func checkUDP(ctx context.Context, cfg *config.Config, opts Options) []Result {
+	port := Result{Check: "UDP port"}
+	addr := net.JoinHostPort(cfg.UDPBindHost, fmt.Sprint(cfg.UDPBindPort))
+	udpAddr, err := net.ResolveUDPAddr("udp", addr)
+	if err == nil {
+		var conn *net.UDPConn
+		if conn, err = net.ListenUDP("udp", udpAddr); err == nil {
+			defer conn.Close()
+			port.Status = Pass
+			port.Detail = addr + " is free"
+			return []Result{port, listenForPacket(ctx, conn, opts.Listen, cfg.UDPBindPort)}
+		}
+	}
+
+	if errors.Is(err, syscall.EADDRINUSE) && opts.Running != nil {
+		if snapshot, err := opts.Running(ctx); err == nil {
+			port.Status = Pass
+			port.Detail = addr + " is in use by the running PXP"
+			return []Result{port, lastPacket(snapshot, cfg.UDPBindPort)}
+		}
+	}
+
+	port.Status = Fail
+	port.Detail = fmt.Sprintf("Can't listen on %s: %v", addr, err)
+	port.Hint = "UDP_BIND_HOST must be an address of this computer, or 0.0.0.0"
+	if errors.Is(err, syscall.EADDRINUSE) {
+		port.Hint = "Another program has the port, perhaps another ACARS client. Close it, or change UDP_BIND_PORT and the port in the Lua script"
+	}
+	return []Result{port, {Check: "X-Plane packets", Status: Skip, Detail: "Needs the UDP port"}}
+}
+
+func listenForPacket(ctx context.Context, conn *net.UDPConn, wait time.Duration, port int) Result {
+	result := Result{Check: "X-Plane packets"}
+	deadline := time.Now().Add(wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline)
+
+	buffer := make([]byte, 64*1024)
+	n, sender, err := conn.ReadFromUDP(buffer)
+	if err != nil {
+		result.Status = Warn
+		result.Detail = fmt.Sprintf("Nothing received in %s", wait.Round(time.Second))
+		result.Hint = noPacketsHint(port)
+		return result
+	}
+	return describePacket(buffer[:n], sender.String())
+}
+
+// describePacket decodes a packet as PXP would, and says what it's from.
+func describePacket(data []byte, sender string) Result {
+	result := Result{Check: "X-Plane packets"}
+	payload, err := udp.DecodePayload(data)
+	if err != nil {
+		result.Status = Fail
+		result.Detail = fmt.Sprintf("Received %d bytes from %s that aren't from the PXP Lua script", len(data), sender)
+		result.Hint = "X-Plane's own Data Output isn't supported. Install docs/flywithlua_phpvms_udp.lua in FlyWithLua's Scripts folder and turn Data Output off for this port"
+		return result
+	}
+	if payload.Status == "" && payload.Position == nil {
+		result.Status = Warn
+		result.Detail = fmt.Sprintf("Received a packet from %s without a status or position", sender)
+		result.Hint = "Update the Lua script to the one in docs/flywithlua_phpvms_udp.lua"
+		return result
+	}
+
+	result.Status = Pass
+	result.Detail = fmt.Sprintf("From %s", sender)
+	if payload.Simulator != nil {
+		result.Detail += ": " + describeSimulator(*payload.Simulator)
+	}
+	if payload.Status != "" {
+		result.Detail += ", " + payload.Status
+	}
+	return result
+}
+
+// lastPacket is what the running PXP last received, which counts if it was
+// recent.
+func lastPacket(snapshot udp.MetricsSnapshot, port int) Result {
+	result := Result{Check: "X-Plane packets"}
+	if snapshot.LastPacketTime == nil || time.Since(*snapshot.LastPacketTime) > time.Minute {
+		result.Status = Warn
+		result.Detail = "The running PXP hasn't received anything in the last minute"
+		result.Hint = noPacketsHint(port)
+		return result
+	}
+
+	result.Status = Pass
+	result.Detail = "The running PXP is receiving packets"
+	if snapshot.LastSender != nil {
+		result.Detail += " from " + *snapshot.LastSender
+	}
+	if snapshot.LastSimulator != nil {
+		result.Detail += ": " + describeSimulator(*snapshot.LastSimulator)
+	}
+	return result
+}
+
+func noPacketsHint(port int) string {
+	return fmt.Sprintf("Start X-Plane with FlyWithLua and docs/flywithlua_phpvms_udp.lua, set to send to this computer on port %d. A firewall may be blocking UDP", port)
+}
+
+func describeSimulator(simulator udp.Simulator) string {
+	return strings.Join(strings.Fields(fmt.Sprintf("%s %s %s", simulator, simulator.AircraftICAO, simulator.TailNumber)), " ")
+}
+
+// OFPClient is the part of the API client checkSimBrief uses.
+type OFPClient interface {
+	GetSimbriefOFP(ctx context.Context, simbriefUserID string) (*models.SimBriefOFP, error)
+}
+
+func checkSimBrief(ctx context.Context, client OFPClient, userID string) Result {
+	result := Result{Check: "SimBrief"}
+	if userID == "" {
+		result.Status = Skip
+		result.Detail = "SIMBRIEF_USER_ID isn't set"
+		result.Hint = "Set it to your numeric Pilot ID from SimBrief's Account Settings to prefile from OFPs"
+		return result
+	}
+
+	ofp, err := client.GetSimbriefOFP(ctx, userID)
+	var statusErr *api.StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == 400 {
+		result.Status = Fail
+		result.Detail = fmt.Sprintf("SimBrief has no OFP for user %s", userID)
+		result.Hint = "Check SIMBRIEF_USER_ID is your numeric Pilot ID, not your username, and generate a flight"
+		return result
+	}
+	if err != nil {
+		result.Status = Fail
+		result.Detail = err.Error()
+		result.Hint = "Check your internet connection, or try again if SimBrief is down"
+		return result
+	}
+
+	result.Status = Pass
+	result.Detail = "Fetched the latest OFP"
+	if plan, err := flightplan.FromOFP(ofp); err == nil {
+		result.Detail = fmt.Sprintf("Latest OFP is %s %s-%s", plan.FlightNumber, plan.Origin, plan.Destination)
+	}
+	return result
+}
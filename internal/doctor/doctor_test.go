@@ -0,0 +1,97 @@
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+)
+
+func TestCheckPhpVMS(t *testing.T) {
+	tests := []struct {
+		name              string
+		status            int
+		body              string
+		expectReachable   Status
+		expectAccepted    Status
+		expectInDetail    string
+		expectBaseURLHint bool
+	}{
+		{"signed in", 200, `{"data":{"ident":"QFA0001","name":"Ada Lovelace"}}`, Pass, Pass, "QFA0001 Ada Lovelace", false},
+		{"rejected key", 401, `{}`, Pass, Fail, "status 401", false},
+		{"wrong path", 404, `{}`, Fail, Skip, "status 404", true},
+		{"server error", 500, `{}`, Warn, Skip, "status 500", false},
+		{"web page", 200, `<html></html>`, Fail, Skip, "web page", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/user" || r.Header.Get("X-API-Key") != "key" {
+					t.Errorf("Unexpected request to %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := api.NewClient(server.URL, secret.New("key"), nil)
+			reachable, accepted := checkPhpVMS(context.Background(), client, server.URL)
+			if reachable.Status != tt.expectReachable || accepted.Status != tt.expectAccepted {
+				t.Fatalf("Expected %s/%s, got %+v %+v", tt.expectReachable, tt.expectAccepted, reachable, accepted)
+			}
+			if !strings.Contains(reachable.Detail+accepted.Detail, tt.expectInDetail) {
+				t.Errorf("Expected detail to contain %q, got %q and %q", tt.expectInDetail, reachable.Detail, accepted.Detail)
+			}
+			if tt.expectBaseURLHint != strings.Contains(reachable.Hint, "without /api") {
+				t.Errorf("Unexpected hint %q", reachable.Hint)
+			}
+		})
+	}
+}
+
+func TestDescribePacket(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		expectStatus Status
+		expectDetail string
+	}{
+		{"lua script", `{"status":"BOARDING","simulator":{"name":"X-Plane","version":12010,"aircraft_icao":"B738","tail_number":"VH-XZA"}}`, Pass, "X-Plane 12 B738 VH-XZA, BOARDING"},
+		{"data output", "DATA*\x00\x00\x00\x00", Fail, "aren't from the PXP Lua script"},
+		{"empty json", `{}`, Warn, "without a status or position"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := describePacket([]byte(tt.data), "127.0.0.1:50000")
+			if result.Status != tt.expectStatus || !strings.Contains(result.Detail, tt.expectDetail) {
+				t.Errorf("Expected %s containing %q, got %+v", tt.expectStatus, tt.expectDetail, result)
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := Report{
+		{Check: "Configuration", Status: Pass, Detail: "Valid"},
+		{Check: "UDP port", Status: Fail, Detail: "In use", Hint: "Close the other program"},
+		{Check: "SimBrief", Status: Skip, Detail: "Not set"},
+	}
+	if !report.Failed() || report[:1].Failed() {
+		t.Errorf("Expected only a report with a failure to have failed")
+	}
+
+	var out bytes.Buffer
+	report.Write(&out)
+	for _, expected := range []string{"PASS  Configuration", "FAIL  UDP port", "→ Close the other program", "1 passed, 0 warnings, 1 failed, 1 skipped"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+}
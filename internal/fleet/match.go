@@ -0,0 +1,175 @@
+package fleet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// Hints are what the SimBrief OFP and the simulator say about the aircraft
+// being flown. Any of them may be empty.
+type Hints struct {
+	// Registration, AircraftType and Airline are from the OFP
+	Registration string
+	AircraftType string
+	Airline      string
+	// TailNumber and SimAircraftType are what X-Plane reports
+	TailNumber      string
+	SimAircraftType string
+}
+
+// Match finds the fleet aircraft and airline the hints point to. The OFP's
+// registration wins over the simulator's tail number, since the OFP is what's
+// being flown, and a type only picks an aircraft if it's the only one of that
+// type. Aircraft that can't be flown, e.g. stored or in use, are never picked.
+// The airline is the OFP's if the VA has it, otherwise the aircraft's
+// subfleet's.
+func Match(hints Hints, subfleets []models.AircraftFleet, airlines []models.Airline) (*models.Aircraft, *models.Airline) {
+	var aircraft *models.Aircraft
+	var subfleet *models.AircraftFleet
+	for _, registration := range []string{hints.Registration, hints.TailNumber} {
+		if aircraft, subfleet = findRegistration(subfleets, registration); aircraft != nil {
+			break
+		}
+	}
+	if aircraft == nil {
+		for _, aircraftType := range []string{hints.AircraftType, hints.SimAircraftType} {
+			if aircraft, subfleet = findOnlyOfType(subfleets, aircraftType); aircraft != nil {
+				break
+			}
+		}
+	}
+
+	airline := findAirline(airlines, func(airline models.Airline) bool {
+		return hints.Airline != "" && strings.EqualFold(airline.ICAO, hints.Airline)
+	})
+	if airline == nil && subfleet != nil {
+		airline = findAirline(airlines, func(airline models.Airline) bool { return airline.ID == subfleet.AirlineId })
+	}
+	return aircraft, airline
+}
+
+// Mismatches describes where the hints disagree with the selected aircraft
+// and airline, e.g. an OFP planned for one registration while X-Plane is
+// flying another, or one Match passed over because it can't be flown.
+// aircraft and airline are nil if none is selected.
+func Mismatches(hints Hints, subfleets []models.AircraftFleet, aircraft *models.Aircraft, airline *models.Airline) []string {
+	var mismatches []string
+	type source struct{ name, registration string }
+	sources := []source{{"The OFP's", hints.Registration}}
+	if !sameRegistration(hints.Registration, hints.TailNumber) {
+		sources = append(sources, source{"X-Plane's", hints.TailNumber})
+	}
+	for _, source := range sources {
+		if source.registration == "" {
+			continue
+		}
+		switch found := findAnyRegistration(subfleets, source.registration); {
+		case found != nil && !found.Available():
+			mismatches = append(mismatches, fmt.Sprintf("%s %s is %s", source.name, found.Registration, found.StatusText()))
+		case found == nil && aircraft == nil:
+			mismatches = append(mismatches, fmt.Sprintf("%s %s isn't in the fleet", source.name, source.registration))
+		}
+	}
+
+	if aircraft != nil {
+		if hints.Registration != "" && !sameRegistration(hints.Registration, aircraft.Registration) {
+			mismatches = append(mismatches, fmt.Sprintf("OFP is planned for %s, not %s", hints.Registration, aircraft.Registration))
+		} else if hints.Registration == "" && hints.AircraftType != "" && !strings.EqualFold(hints.AircraftType, aircraft.ICAO) {
+			mismatches = append(mismatches, fmt.Sprintf("OFP is planned for %s, not %s", hints.AircraftType, aircraft.ICAO))
+		}
+
+		// A tail number is more telling than a type, but not every aircraft
+		// sets one
+		if hints.TailNumber != "" && !sameRegistration(hints.TailNumber, aircraft.Registration) {
+			mismatches = append(mismatches, fmt.Sprintf("X-Plane is flying %s, not %s", hints.TailNumber, aircraft.Registration))
+		} else if hints.TailNumber == "" && hints.SimAircraftType != "" && !strings.EqualFold(hints.SimAircraftType, aircraft.ICAO) {
+			mismatches = append(mismatches, fmt.Sprintf("X-Plane is flying %s, not %s", hints.SimAircraftType, aircraft.ICAO))
+		}
+	}
+
+	if hints.Airline != "" && (airline == nil || !strings.EqualFold(hints.Airline, airline.ICAO)) {
+		selected := "no airline"
+		if airline != nil {
+			selected = airline.ICAO
+		}
+		mismatches = append(mismatches, fmt.Sprintf("OFP is for %s, not %s", strings.ToUpper(hints.Airline), selected))
+	}
+	return mismatches
+}
+
+func findAirline(airlines []models.Airline, matches func(models.Airline) bool) *models.Airline {
+	for i := range airlines {
+		if matches(airlines[i]) {
+			return &airlines[i]
+		}
+	}
+	return nil
+}
+
+func findRegistration(subfleets []models.AircraftFleet, registration string) (*models.Aircraft, *models.AircraftFleet) {
+	if registration == "" {
+		return nil, nil
+	}
+	for i := range subfleets {
+		for j := range subfleets[i].Aircraft {
+			aircraft := &subfleets[i].Aircraft[j]
+			if aircraft.Available() && sameRegistration(aircraft.Registration, registration) {
+				return aircraft, &subfleets[i]
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findAnyRegistration finds the aircraft with a registration whether or not it
+// can be flown.
+func findAnyRegistration(subfleets []models.AircraftFleet, registration string) *models.Aircraft {
+	for i := range subfleets {
+		for j := range subfleets[i].Aircraft {
+			if sameRegistration(subfleets[i].Aircraft[j].Registration, registration) {
+				return &subfleets[i].Aircraft[j]
+			}
+		}
+	}
+	return nil
+}
+
+func findOnlyOfType(subfleets []models.AircraftFleet, aircraftType string) (*models.Aircraft, *models.AircraftFleet) {
+	if aircraftType == "" {
+		return nil, nil
+	}
+	var aircraft *models.Aircraft
+	var subfleet *models.AircraftFleet
+	for i := range subfleets {
+		for j := range subfleets[i].Aircraft {
+			if !subfleets[i].Aircraft[j].Available() || !strings.EqualFold(subfleets[i].Aircraft[j].ICAO, aircraftType) {
+				continue
+			}
+			if aircraft != nil {
+				return nil, nil
+			}
+			aircraft, subfleet = &subfleets[i].Aircraft[j], &subfleets[i]
+		}
+	}
+	return aircraft, subfleet
+}
+
+// sameRegistration compares registrations ignoring case and punctuation, as
+// X-Plane often drops the hyphen, e.g. VHXZB for VH-XZB.
+func sameRegistration(a, b string) bool {
+	return a != "" && normaliseRegistration(a) == normaliseRegistration(b)
+}
+
+func normaliseRegistration(registration string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, registration)
+}
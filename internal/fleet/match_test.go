@@ -0,0 +1,95 @@
+package fleet
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+func testFleet() ([]models.AircraftFleet, []models.Airline) {
+	subfleets := []models.AircraftFleet{
+		{Id: 1, AirlineId: 1, Type: "B738", Aircraft: []models.Aircraft{
+			{ID: 11, Registration: "VH-XZB", ICAO: "B738"},
+			{ID: 12, Registration: "VH-XZC", ICAO: "B738"},
+		}},
+		{Id: 2, AirlineId: 2, Type: "A320", Aircraft: []models.Aircraft{
+			{ID: 21, Registration: "VH-VFN", ICAO: "A320"},
+		}},
+		{Id: 3, AirlineId: 1, Type: "DH8D", Aircraft: []models.Aircraft{
+			{ID: 31, Registration: "VH-QOA", ICAO: "DH8D"},
+			{ID: 32, Registration: "VH-QOB", ICAO: "DH8D", Status: models.AircraftStatusStored},
+			{ID: 33, Registration: "VH-QOC", ICAO: "DH8D", State: models.AircraftStateInUse},
+		}},
+	}
+	airlines := []models.Airline{{ID: 1, ICAO: "QFA"}, {ID: 2, ICAO: "JST"}}
+	return subfleets, airlines
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		hints    Hints
+		aircraft int
+		airline  int
+	}{
+		{"OFP registration and airline", Hints{Registration: "VH-XZC", Airline: "QFA"}, 12, 1},
+		{"OFP registration wins over the sim", Hints{Registration: "VH-XZC", TailNumber: "VHVFN"}, 12, 1},
+		{"sim tail without hyphen", Hints{TailNumber: "vhvfn"}, 21, 2},
+		{"unknown registration falls back to the only one of a type", Hints{Registration: "VH-ZZZ", SimAircraftType: "A320"}, 21, 2},
+		{"type with several aircraft", Hints{AircraftType: "B738"}, 0, 0},
+		{"stored registration isn't picked", Hints{Registration: "VH-QOB"}, 0, 0},
+		{"only available one of a type", Hints{Registration: "VH-QOC", AircraftType: "DH8D"}, 31, 1},
+		{"OFP airline without an aircraft", Hints{Airline: "JST"}, 0, 2},
+		{"unknown OFP airline uses the subfleet's", Hints{Registration: "VH-XZB", Airline: "VOZ"}, 11, 1},
+		{"nothing", Hints{}, 0, 0},
+	}
+
+	subfleets, airlines := testFleet()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aircraft, airline := Match(tt.hints, subfleets, airlines)
+			aircraftID, airlineID := 0, 0
+			if aircraft != nil {
+				aircraftID = aircraft.ID
+			}
+			if airline != nil {
+				airlineID = airline.ID
+			}
+			if aircraftID != tt.aircraft || airlineID != tt.airline {
+				t.Errorf("Expected aircraft %d and airline %d, got %d and %d", tt.aircraft, tt.airline, aircraftID, airlineID)
+			}
+		})
+	}
+}
+
+func TestMismatches(t *testing.T) {
+	subfleets, airlines := testFleet()
+	xzb, vfn := &subfleets[0].Aircraft[0], &subfleets[1].Aircraft[0]
+	qfa := &airlines[0]
+
+	tests := []struct {
+		name     string
+		hints    Hints
+		aircraft *models.Aircraft
+		want     []string
+	}{
+		{"all agree", Hints{Registration: "VH-XZB", TailNumber: "VHXZB", Airline: "QFA"}, xzb, nil},
+		{"sim flying another aircraft", Hints{Registration: "VH-XZB", TailNumber: "VH-XZC"}, xzb, []string{"X-Plane is flying VH-XZC, not VH-XZB"}},
+		{"another aircraft selected", Hints{Registration: "VH-XZB", AircraftType: "B738"}, vfn, []string{"OFP is planned for VH-XZB, not VH-VFN"}},
+		{"sim type only", Hints{SimAircraftType: "A20N"}, vfn, []string{"X-Plane is flying A20N, not A320"}},
+		{"not in the fleet", Hints{Registration: "VH-ZZZ"}, nil, []string{"The OFP's VH-ZZZ isn't in the fleet"}},
+		{"other airline", Hints{Airline: "jst"}, xzb, []string{"OFP is for JST, not QFA"}},
+		{"stored OFP aircraft", Hints{Registration: "VH-QOB"}, nil, []string{"The OFP's VH-QOB is stored"}},
+		{"sim aircraft in use", Hints{Registration: "VH-XZB", TailNumber: "VHQOC"}, xzb, []string{"X-Plane's VH-QOC is in use", "X-Plane is flying VHQOC, not VH-XZB"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Mismatches(tt.hints, subfleets, tt.aircraft, qfa)
+			if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package flightplan
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/julietrb1/phpvms-xplane/internal/atomicfile"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+const (
+	fmsTypeAirport = 1
+	fmsTypeNDB     = 2
+	fmsTypeVOR     = 3
+	fmsTypeFix     = 11
+	fmsTypeLatLon  = 28
+)
+
+type fmsEntry struct {
+	Type     int
+	Ident    string
+	Via      string
+	Altitude float64
+	Lat      float64
+	Lon      float64
+}
+
+func FMSFileName(ofp *models.SimBriefOFP) string {
+	return fmt.Sprintf("%s%s.fms", deref(ofp.Origin.ICAOCode), deref(ofp.Destination.ICAOCode))
+}
+
+func ExportFMS(dir string, ofp *models.SimBriefOFP) (string, error) {
+	if ofp == nil {
+		return "", fmt.Errorf("no OFP loaded")
+	}
+	if dir == "" {
+		dir = "."
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create FMS output directory: %w", err)
+	}
+
+	path := filepath.Join(dir, FMSFileName(ofp))
+	if err := atomicfile.Write(path, 0o644, func(w io.Writer) error {
+		return WriteFMS(w, ofp)
+	}); err != nil {
+		return "", fmt.Errorf("failed to write FMS file: %w", err)
+	}
+
+	return path, nil
+}
+
+func WriteFMS(w io.Writer, ofp *models.SimBriefOFP) error {
+	if ofp == nil {
+		return fmt.Errorf("no OFP loaded")
+	}
+
+	origin := deref(ofp.Origin.ICAOCode)
+	destination := deref(ofp.Destination.ICAOCode)
+	if origin == "" || destination == "" {
+		return fmt.Errorf("OFP is missing origin or destination")
+	}
+
+	originEntry, err := airportEntry(origin, "ADEP", ofp.Origin.Elevation, ofp.Origin.PosLat, ofp.Origin.PosLong)
+	if err != nil {
+		return fmt.Errorf("invalid origin: %w", err)
+	}
+
+	destinationEntry, err := airportEntry(destination, "ADES", ofp.Destination.Elevation, ofp.Destination.PosLat, ofp.Destination.PosLong)
+	if err != nil {
+		return fmt.Errorf("invalid destination: %w", err)
+	}
+
+	sid := deref(ofp.General.SidIdent)
+	star := deref(ofp.General.StarIdent)
+
+	entries := []fmsEntry{originEntry}
+	for _, fix := range ofp.Navlog.Fix {
+		if fix.Type == "apt" && (fix.Ident == origin || fix.Ident == destination) {
+			continue
+		}
+		if fix.IsSidStar == "1" && ((sid != "" && fix.ViaAirway == sid) || (star != "" && fix.ViaAirway == star)) {
+			continue
+		}
+
+		fixType, ok := fmsFixType(fix.Type)
+		if !ok {
+			continue
+		}
+
+		lat, err := strconv.ParseFloat(fix.PosLat, 64)
+		if err != nil {
+			return fmt.Errorf("invalid latitude for fix %s: %w", fix.Ident, err)
+		}
+		lon, err := strconv.ParseFloat(fix.PosLong, 64)
+		if err != nil {
+			return fmt.Errorf("invalid longitude for fix %s: %w", fix.Ident, err)
+		}
+		altitude, _ := strconv.ParseFloat(fix.AltitudeFeet, 64)
+
+		entries = append(entries, fmsEntry{
+			Type:     fixType,
+			Ident:    fix.Ident,
+			Via:      fmsVia(fix.ViaAirway),
+			Altitude: altitude,
+			Lat:      lat,
+			Lon:      lon,
+		})
+	}
+	entries = append(entries, destinationEntry)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, "I\n1100 Version\n")
+	if ofp.Params.Airac != "" {
+		fmt.Fprintf(bw, "CYCLE %s\n", ofp.Params.Airac)
+	}
+	fmt.Fprintf(bw, "ADEP %s\n", origin)
+	if ofp.Origin.PlanRwy != "" {
+		fmt.Fprintf(bw, "DEPRWY RW%s\n", ofp.Origin.PlanRwy)
+	}
+	if sid != "" {
+		fmt.Fprintf(bw, "SID %s\n", sid)
+		if trans := deref(ofp.General.SidTrans); trans != "" {
+			fmt.Fprintf(bw, "SIDTRANS %s\n", trans)
+		}
+	}
+	fmt.Fprintf(bw, "ADES %s\n", destination)
+	if ofp.Destination.PlanRwy != "" {
+		fmt.Fprintf(bw, "DESRWY RW%s\n", ofp.Destination.PlanRwy)
+	}
+	if star != "" {
+		fmt.Fprintf(bw, "STAR %s\n", star)
+		if trans := deref(ofp.General.StarTrans); trans != "" {
+			fmt.Fprintf(bw, "STARTRANS %s\n", trans)
+		}
+	}
+	fmt.Fprintf(bw, "NUMENR %d\n", len(entries))
+	for _, entry := range entries {
+		fmt.Fprintf(bw, "%d %s %s %.6f %.6f %.6f\n",
+			entry.Type, entry.Ident, entry.Via, entry.Altitude, entry.Lat, entry.Lon)
+	}
+
+	return bw.Flush()
+}
+
+func airportEntry(icao, via, elevation, lat, lon string) (fmsEntry, error) {
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil {
+		return fmsEntry{}, fmt.Errorf("invalid latitude: %w", err)
+	}
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil {
+		return fmsEntry{}, fmt.Errorf("invalid longitude: %w", err)
+	}
+	altitude, _ := strconv.ParseFloat(elevation, 64)
+
+	return fmsEntry{
+		Type:     fmsTypeAirport,
+		Ident:    icao,
+		Via:      via,
+		Altitude: altitude,
+		Lat:      latitude,
+		Lon:      longitude,
+	}, nil
+}
+
+func fmsFixType(simbriefType string) (int, bool) {
+	switch strings.ToLower(simbriefType) {
+	case "apt":
+		return fmsTypeAirport, true
+	case "ndb":
+		return fmsTypeNDB, true
+	case "vor":
+		return fmsTypeVOR, true
+	case "wpt":
+		return fmsTypeFix, true
+	case "ltlg":
+		return fmsTypeLatLon, true
+	default:
+		// Pseudo-waypoints such as TOC and TOD have no place in the FMS
+		return 0, false
+	}
+}
+
+func fmsVia(airway string) string {
+	if airway == "" || airway == "DCT" {
+		return "DRCT"
+	}
+	return airway
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,97 @@
+package flightplan
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+const testOFP = `{
+	"params": {"airac": "2309"},
+	"general": {"sid_ident": "KAMPI1", "sid_trans": null, "star_ident": "BOREE3", "star_trans": "BOREE"},
+	"origin": {"icao_code": "YSSY", "elevation": "21", "pos_lat": "-33.946111", "pos_long": "151.177222", "plan_rwy": "34L"},
+	"destination": {"icao_code": "YMML", "elevation": "434", "pos_lat": "-37.673333", "pos_long": "144.843333", "plan_rwy": "16"},
+	"navlog": {"fix": [
+		{"ident": "KAMPI", "type": "wpt", "via_airway": "KAMPI1", "is_sid_star": "1", "pos_lat": "-34.1", "pos_long": "150.9", "altitude_feet": "9000"},
+		{"ident": "TOC", "type": "toc", "via_airway": "H65", "is_sid_star": "0", "pos_lat": "-34.5", "pos_long": "150.2", "altitude_feet": "36000"},
+		{"ident": "WOL", "type": "vor", "via_airway": "H65", "is_sid_star": "0", "pos_lat": "-34.558", "pos_long": "150.791", "altitude_feet": "36000"},
+		{"ident": "3600S", "type": "ltlg", "via_airway": "DCT", "is_sid_star": "0", "pos_lat": "-36.0", "pos_long": "148.0", "altitude_feet": "36000"},
+		{"ident": "BOREE", "type": "wpt", "via_airway": "BOREE3", "is_sid_star": "1", "pos_lat": "-37.0", "pos_long": "145.5", "altitude_feet": "12000"},
+		{"ident": "YMML", "type": "apt", "via_airway": "BOREE3", "is_sid_star": "1", "pos_lat": "-37.673333", "pos_long": "144.843333", "altitude_feet": "434"}
+	]}
+}`
+
+func TestWriteFMS(t *testing.T) {
+	var ofp models.SimBriefOFP
+	if err := json.Unmarshal([]byte(testOFP), &ofp); err != nil {
+		t.Fatalf("Failed to decode test OFP: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := WriteFMS(&sb, &ofp); err != nil {
+		t.Fatalf("WriteFMS() error = %v", err)
+	}
+
+	expected := `I
+1100 Version
+CYCLE 2309
+ADEP YSSY
+DEPRWY RW34L
+SID KAMPI1
+ADES YMML
+DESRWY RW16
+STAR BOREE3
+STARTRANS BOREE
+NUMENR 4
+1 YSSY ADEP 21.000000 -33.946111 151.177222
+3 WOL H65 36000.000000 -34.558000 150.791000
+28 3600S DRCT 36000.000000 -36.000000 148.000000
+1 YMML ADES 434.000000 -37.673333 144.843333
+`
+	if sb.String() != expected {
+		t.Errorf("Unexpected FMS output:\n%s\nexpected:\n%s", sb.String(), expected)
+	}
+
+	if name := FMSFileName(&ofp); name != "YSSYYMML.fms" {
+		t.Errorf("Expected file name YSSYYMML.fms, got %s", name)
+	}
+}
+
+func TestWriteFMSMissingOrigin(t *testing.T) {
+	var ofp models.SimBriefOFP
+	if err := WriteFMS(&strings.Builder{}, &ofp); err == nil {
+		t.Errorf("Expected error for OFP without origin")
+	}
+	if _, err := ExportFMS(t.TempDir(), nil); err == nil {
+		t.Errorf("Expected error for a nil OFP")
+	}
+}
+
+func TestExportFMS(t *testing.T) {
+	var ofp models.SimBriefOFP
+	if err := json.Unmarshal([]byte(testOFP), &ofp); err != nil {
+		t.Fatalf("Failed to decode test OFP: %v", err)
+	}
+
+	dir := t.TempDir()
+	path, err := ExportFMS(dir, &ofp)
+	if err != nil {
+		t.Fatalf("ExportFMS() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.HasPrefix(string(data), "I\n1100 Version") {
+		t.Errorf("Expected the plan at %s, got %q, %v", path, data, err)
+	}
+
+	// A plan that can't be written leaves nothing behind
+	ofp.Destination.ICAOCode = nil
+	if _, err := ExportFMS(dir, &ofp); err == nil {
+		t.Fatal("Expected error for OFP without destination")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "YSSYYMML.fms" {
+		t.Errorf("Expected only YSSYYMML.fms in %s, got %v", dir, entries)
+	}
+}
@@ -0,0 +1,79 @@
+package flightplan
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// Plan is the part of a SimBrief OFP needed to prefile a PIREP.
+type Plan struct {
+	Origin       string `json:"origin"`
+	Destination  string `json:"destination"`
+	Alternate    string `json:"alternate,omitempty"`
+	FlightNumber string `json:"flight_number"`
+	Route        string `json:"route"`
+	Distance     int    `json:"distance"`   // nm
+	Altitude     int    `json:"altitude"`   // ft
+	BlockFuel    int    `json:"block_fuel"` // kg
+	FlightTime   int    `json:"flight_time"`
+	ZFW          int    `json:"zfw,omitempty"` // kg
+	Passengers   int    `json:"passengers,omitempty"`
+	Airline      string `json:"airline,omitempty"` // ICAO
+	Registration string `json:"registration,omitempty"`
+	AircraftType string `json:"aircraft_type,omitempty"` // ICAO
+}
+
+func FromOFP(ofp *models.SimBriefOFP) (Plan, error) {
+	if ofp == nil {
+		return Plan{}, fmt.Errorf("no OFP")
+	}
+
+	routeDistance, err := strconv.Atoi(ofp.General.RouteDistance)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to parse route distance: %w", err)
+	}
+
+	initialAltitude, err := strconv.Atoi(ofp.General.InitialAltitude)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to parse initial altitude: %w", err)
+	}
+
+	blockFuel, err := strconv.Atoi(ofp.Fuel.PlanRamp)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to parse block fuel: %w", err)
+	}
+
+	flightTime, err := strconv.Atoi(ofp.Times.EstTimeEnroute)
+	if err != nil {
+		return Plan{}, fmt.Errorf("failed to parse flight time: %w", err)
+	}
+
+	if ofp.Origin.ICAOCode == nil {
+		return Plan{}, fmt.Errorf("failed to parse origin ICAO code")
+	}
+
+	if ofp.Destination.ICAOCode == nil {
+		return Plan{}, fmt.Errorf("failed to parse destination ICAO code")
+	}
+
+	plan := Plan{
+		Origin:       *ofp.Origin.ICAOCode,
+		Destination:  *ofp.Destination.ICAOCode,
+		Alternate:    deref(ofp.Alternate.ICAOCode),
+		FlightNumber: ofp.General.FlightNumber,
+		Route:        ofp.General.Route,
+		Distance:     routeDistance,
+		Altitude:     initialAltitude,
+		BlockFuel:    blockFuel,
+		FlightTime:   flightTime,
+		Airline:      deref(ofp.General.ICAOAirline),
+		Registration: ofp.Aircraft.Reg,
+		AircraftType: ofp.Aircraft.ICAOCode,
+	}
+	// Weights are nice to have, so don't fail if they're missing
+	plan.ZFW, _ = strconv.Atoi(ofp.Weights.EstZfw)
+	plan.Passengers, _ = strconv.Atoi(ofp.Weights.PaxCount)
+	return plan, nil
+}
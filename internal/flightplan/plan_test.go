@@ -0,0 +1,55 @@
+package flightplan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+func TestFromOFP(t *testing.T) {
+	var ofp models.SimBriefOFP
+	input := `{
+		"general": {"icao_airline": "QFA", "flight_number": "QFA401", "route": "KAMPI1 WOL H65 BOREE3", "route_distance": "385", "initial_altitude": "36000"},
+		"origin": {"icao_code": "YSSY"},
+		"destination": {"icao_code": "YMML"},
+		"alternate": {"icao_code": "YMAV"},
+		"aircraft": {"icao_code": "B738", "reg": "VH-XZB"},
+		"fuel": {"plan_ramp": "6400"},
+		"times": {"est_time_enroute": "72"},
+		"weights": {"est_zfw": "61200", "pax_count": "168"}
+	}`
+	if err := json.Unmarshal([]byte(input), &ofp); err != nil {
+		t.Fatalf("Failed to decode test OFP: %v", err)
+	}
+
+	plan, err := FromOFP(&ofp)
+	if err != nil {
+		t.Fatalf("FromOFP() error = %v", err)
+	}
+
+	want := Plan{
+		Origin:       "YSSY",
+		Destination:  "YMML",
+		Alternate:    "YMAV",
+		FlightNumber: "QFA401",
+		Route:        "KAMPI1 WOL H65 BOREE3",
+		Distance:     385,
+		Altitude:     36000,
+		BlockFuel:    6400,
+		FlightTime:   72,
+		ZFW:          61200,
+		Passengers:   168,
+		Airline:      "QFA",
+		Registration: "VH-XZB",
+		AircraftType: "B738",
+	}
+	if plan != want {
+		t.Errorf("Expected %+v, got %+v", want, plan)
+	}
+
+	ofp.Destination.ICAOCode = nil
+	if _, err := FromOFP(&ofp); err == nil {
+		t.Errorf("Expected error for OFP without destination")
+	}
+}
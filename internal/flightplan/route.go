@@ -0,0 +1,130 @@
+package flightplan
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+const earthRadiusNM = 3440.065
+
+// Fix is a point on the planned route.
+type Fix struct {
+	Ident    string
+	Type     string
+	Lat      float64
+	Lon      float64
+	Altitude float64 // ft
+}
+
+// Progress is how far along a route an aircraft is.
+type Progress struct {
+	Next      int     // index of the next fix, -1 without a route
+	ToNext    float64 // nm
+	Remaining float64 // nm to the last fix
+}
+
+// Route returns the origin followed by the OFP's navlog fixes, skipping any
+// without a position.
+func Route(ofp *models.SimBriefOFP) []Fix {
+	if ofp == nil {
+		return nil
+	}
+
+	var route []Fix
+	origin := deref(ofp.Origin.ICAOCode)
+	lat, latErr := strconv.ParseFloat(ofp.Origin.PosLat, 64)
+	lon, lonErr := strconv.ParseFloat(ofp.Origin.PosLong, 64)
+	if latErr == nil && lonErr == nil {
+		elevation, _ := strconv.ParseFloat(ofp.Origin.Elevation, 64)
+		route = append(route, Fix{Ident: origin, Type: "apt", Lat: lat, Lon: lon, Altitude: elevation})
+	}
+
+	for _, fix := range ofp.Navlog.Fix {
+		if fix.Type == "apt" && fix.Ident == origin && len(route) > 0 {
+			continue
+		}
+		lat, err := strconv.ParseFloat(fix.PosLat, 64)
+		if err != nil {
+			continue
+		}
+		lon, err := strconv.ParseFloat(fix.PosLong, 64)
+		if err != nil {
+			continue
+		}
+		altitude, _ := strconv.ParseFloat(fix.AltitudeFeet, 64)
+		route = append(route, Fix{Ident: fix.Ident, Type: fix.Type, Lat: lat, Lon: lon, Altitude: altitude})
+	}
+	return route
+}
+
+// Airports returns the origin, destination and alternate that have a position.
+func Airports(ofp *models.SimBriefOFP) []Fix {
+	if ofp == nil {
+		return nil
+	}
+
+	candidates := []struct {
+		icao, lat, lon, elevation string
+	}{
+		{deref(ofp.Origin.ICAOCode), ofp.Origin.PosLat, ofp.Origin.PosLong, ofp.Origin.Elevation},
+		{deref(ofp.Destination.ICAOCode), ofp.Destination.PosLat, ofp.Destination.PosLong, ofp.Destination.Elevation},
+		{deref(ofp.Alternate.ICAOCode), ofp.Alternate.PosLat, ofp.Alternate.PosLong, ofp.Alternate.Elevation},
+	}
+
+	var airports []Fix
+	for _, candidate := range candidates {
+		lat, latErr := strconv.ParseFloat(candidate.lat, 64)
+		lon, lonErr := strconv.ParseFloat(candidate.lon, 64)
+		if candidate.icao == "" || latErr != nil || lonErr != nil {
+			continue
+		}
+		elevation, _ := strconv.ParseFloat(candidate.elevation, 64)
+		airports = append(airports, Fix{Ident: candidate.icao, Type: "apt", Lat: lat, Lon: lon, Altitude: elevation})
+	}
+	return airports
+}
+
+// RouteProgress finds the next fix for an aircraft at lat/lon. The next fix is
+// the nearest one, unless the aircraft is already on the leg after it.
+func RouteProgress(route []Fix, lat, lon float64) Progress {
+	if len(route) == 0 {
+		return Progress{Next: -1}
+	}
+
+	nearest := 0
+	nearestDistance := math.Inf(1)
+	for i, fix := range route {
+		if distance := DistanceNM(lat, lon, fix.Lat, fix.Lon); distance < nearestDistance {
+			nearest, nearestDistance = i, distance
+		}
+	}
+
+	next := nearest
+	if nearest+1 < len(route) {
+		following := route[nearest+1]
+		if DistanceNM(lat, lon, following.Lat, following.Lon) < DistanceNM(route[nearest].Lat, route[nearest].Lon, following.Lat, following.Lon) {
+			next = nearest + 1
+		}
+	}
+
+	progress := Progress{Next: next, ToNext: DistanceNM(lat, lon, route[next].Lat, route[next].Lon)}
+	progress.Remaining = progress.ToNext
+	for i := next; i+1 < len(route); i++ {
+		progress.Remaining += DistanceNM(route[i].Lat, route[i].Lon, route[i+1].Lat, route[i+1].Lon)
+	}
+	return progress
+}
+
+// DistanceNM is the great circle distance between two points.
+func DistanceNM(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1 := lat1 * math.Pi / 180
+	phi2 := lat2 * math.Pi / 180
+	deltaPhi := (lat2 - lat1) * math.Pi / 180
+	deltaLambda := (lon2 - lon1) * math.Pi / 180
+
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * earthRadiusNM * math.Asin(math.Sqrt(a))
+}
@@ -0,0 +1,48 @@
+package flightplan
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+func TestRoute(t *testing.T) {
+	var ofp models.SimBriefOFP
+	if err := json.Unmarshal([]byte(testOFP), &ofp); err != nil {
+		t.Fatalf("Failed to decode test OFP: %v", err)
+	}
+
+	route := Route(&ofp)
+	if len(route) != 7 {
+		t.Fatalf("Expected the origin and 6 fixes, got %d", len(route))
+	}
+	if route[0].Ident != "YSSY" || route[6].Ident != "YMML" {
+		t.Errorf("Expected YSSY to YMML, got %s to %s", route[0].Ident, route[6].Ident)
+	}
+
+	if airports := Airports(&ofp); len(airports) != 2 || airports[1].Ident != "YMML" {
+		t.Errorf("Expected YSSY and YMML without an alternate, got %+v", airports)
+	}
+
+	// Past 3600S, so BOREE is next even though 3600S is nearer
+	progress := RouteProgress(route, -36.3, 147.4)
+	if route[progress.Next].Ident != "BOREE" {
+		t.Errorf("Expected BOREE to be next, got %s", route[progress.Next].Ident)
+	}
+	if progress.Remaining <= progress.ToNext {
+		t.Errorf("Expected remaining distance past the next fix, got %.0f to next and %.0f remaining", progress.ToNext, progress.Remaining)
+	}
+
+	if progress := RouteProgress(nil, 0, 0); progress.Next != -1 {
+		t.Errorf("Expected no next fix without a route, got %d", progress.Next)
+	}
+}
+
+func TestDistanceNM(t *testing.T) {
+	// YSSY to YMML is about 381 nm
+	if distance := DistanceNM(-33.946111, 151.177222, -37.673333, 144.843333); math.Abs(distance-381) > 2 {
+		t.Errorf("Expected about 381 nm, got %.1f", distance)
+	}
+}
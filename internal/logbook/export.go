@@ -0,0 +1,87 @@
+package logbook
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{
+	"pirep_id", "flight_number", "callsign", "departure", "arrival", "alternate",
+	"registration", "aircraft_type", "prefiled_at", "block_off", "takeoff",
+	"landing", "block_on", "flight_time_min", "block_fuel_kg", "fuel_used_kg",
+	"distance_nm", "landing_rate_fpm", "score", "state", "route", "track_path",
+}
+
+func WriteCSV(w io.Writer, entries []Entry) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, entry := range entries {
+		record := []string{
+			entry.PirepID,
+			entry.FlightNumber,
+			entry.Callsign,
+			entry.Departure,
+			entry.Arrival,
+			entry.Alternate,
+			entry.Registration,
+			entry.AircraftType,
+			formatTime(&entry.PrefiledAt),
+			formatTime(entry.BlockOff),
+			formatTime(entry.Takeoff),
+			formatTime(entry.Landing),
+			formatTime(entry.BlockOn),
+			strconv.Itoa(entry.FlightTime),
+			strconv.FormatFloat(entry.BlockFuel, 'f', 0, 64),
+			strconv.FormatFloat(entry.FuelUsed, 'f', 0, 64),
+			strconv.FormatFloat(entry.Distance, 'f', 0, 64),
+			formatOptionalFloat(entry.LandingRate),
+			formatOptionalInt(entry.Score),
+			entry.State,
+			entry.Route,
+			entry.TrackPath,
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func WriteJSON(w io.Writer, entries []Entry) error {
+	if entries == nil {
+		entries = []Entry{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', 0, 64)
+}
+
+func formatOptionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.Itoa(*value)
+}
@@ -0,0 +1,193 @@
+package logbook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var entriesBucket = []byte("entries")
+
+var ErrNotFound = errors.New("logbook entry not found")
+
+type Entry struct {
+	PirepID      string     `json:"pirep_id"`
+	FlightNumber string     `json:"flight_number"`
+	Callsign     string     `json:"callsign,omitempty"`
+	AirlineID    int        `json:"airline_id,omitempty"`
+	Departure    string     `json:"departure"`
+	Arrival      string     `json:"arrival"`
+	Alternate    string     `json:"alternate,omitempty"`
+	Route        string     `json:"route,omitempty"`
+	AircraftID   int        `json:"aircraft_id"`
+	Registration string     `json:"registration,omitempty"`
+	AircraftType string     `json:"aircraft_type,omitempty"`
+	PrefiledAt   time.Time  `json:"prefiled_at"`
+	BlockOff     *time.Time `json:"block_off,omitempty"`
+	Takeoff      *time.Time `json:"takeoff,omitempty"`
+	Landing      *time.Time `json:"landing,omitempty"`
+	BlockOn      *time.Time `json:"block_on,omitempty"`
+	FiledAt      *time.Time `json:"filed_at,omitempty"`
+	FlightTime   int        `json:"flight_time"`  // minutes
+	BlockFuel    float64    `json:"block_fuel"`   // kg
+	FuelUsed     float64    `json:"fuel_used"`    // kg
+	Distance     float64    `json:"distance"`     // nm
+	LandingRate  *float64   `json:"landing_rate"` // ft/min
+	Score        *int       `json:"score,omitempty"`
+	Notes        string     `json:"notes,omitempty"`
+	State        string     `json:"state"` // phpVMS PIREP state, e.g. PENDING
+	TrackPath    string     `json:"track_path,omitempty"`
+}
+
+// Store is a bbolt-backed logbook. The database is opened for each
+// operation rather than held open, so the CLI can read the logbook while
+// PXP is running.
+type Store struct {
+	Path string
+}
+
+func NewStore(path string) *Store {
+	return &Store{Path: path}
+}
+
+func (s *Store) open(readOnly bool) (*bolt.DB, error) {
+	if !readOnly {
+		if err := os.MkdirAll(filepath.Dir(s.Path), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create logbook directory: %w", err)
+		}
+	} else if _, err := os.Stat(s.Path); os.IsNotExist(err) {
+		return nil, os.ErrNotExist
+	}
+
+	db, err := bolt.Open(s.Path, 0o600, &bolt.Options{Timeout: 2 * time.Second, ReadOnly: readOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open logbook: %w", err)
+	}
+	return db, nil
+}
+
+func (s *Store) Put(entry Entry) error {
+	if entry.PirepID == "" {
+		return fmt.Errorf("logbook entry has no PIREP ID")
+	}
+
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode logbook entry: %w", err)
+	}
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(entriesBucket)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(entry.PirepID), data)
+	})
+}
+
+// Update applies fn to an existing entry and saves the result.
+func (s *Store) Update(pirepID string, fn func(*Entry)) error {
+	db, err := s.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists(entriesBucket)
+		if err != nil {
+			return err
+		}
+
+		data := bucket.Get([]byte(pirepID))
+		if data == nil {
+			return ErrNotFound
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			return fmt.Errorf("failed to decode logbook entry: %w", err)
+		}
+		fn(&entry)
+
+		updated, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to encode logbook entry: %w", err)
+		}
+		return bucket.Put([]byte(pirepID), updated)
+	})
+}
+
+func (s *Store) Get(pirepID string) (*Entry, error) {
+	db, err := s.open(true)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entry *Entry
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		if bucket == nil {
+			return ErrNotFound
+		}
+		data := bucket.Get([]byte(pirepID))
+		if data == nil {
+			return ErrNotFound
+		}
+		entry = &Entry{}
+		return json.Unmarshal(data, entry)
+	})
+	return entry, err
+}
+
+// List returns every entry, newest first.
+func (s *Store) List() ([]Entry, error) {
+	db, err := s.open(true)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entries []Entry
+	err = db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(_, data []byte) error {
+			var entry Entry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				return fmt.Errorf("failed to decode logbook entry: %w", err)
+			}
+			entries = append(entries, entry)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PrefiledAt.After(entries[j].PrefiledAt)
+	})
+	return entries, nil
+}
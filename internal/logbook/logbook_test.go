@@ -0,0 +1,108 @@
+package logbook
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testEntries() []Entry {
+	start := time.Date(2025, 8, 21, 10, 0, 0, 0, time.UTC)
+	rate := -142.0
+	return []Entry{
+		{PirepID: "a", FlightNumber: "QFA1", Departure: "YSSY", Arrival: "YMML", Registration: "VH-ABC", PrefiledAt: start, FlightTime: 85, Distance: 380, FuelUsed: 3200, LandingRate: &rate, State: "ACCEPTED"},
+		{PirepID: "b", FlightNumber: "QFA2", Departure: "YMML", Arrival: "YSSY", Registration: "VH-ABC", PrefiledAt: start.Add(4 * time.Hour), FlightTime: 80, Distance: 380, FuelUsed: 3100, State: "PENDING"},
+		{PirepID: "c", FlightNumber: "QFA7", Departure: "YSSY", Arrival: "YBBN", Registration: "VH-XYZ", PrefiledAt: start.Add(24 * time.Hour), FlightTime: 75, Distance: 400, FuelUsed: 3300, State: "CANCELLED"},
+	}
+}
+
+func TestStorePutUpdateList(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "logbook.db"))
+
+	if entries, err := store.List(); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected empty logbook before first write, got %d entries (err %v)", len(entries), err)
+	}
+
+	for _, entry := range testEntries() {
+		if err := store.Put(entry); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+	}
+
+	if err := store.Update("b", func(entry *Entry) { entry.State = "ACCEPTED" }); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := store.Update("missing", func(*Entry) {}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound updating a missing entry, got %v", err)
+	}
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 3 || entries[0].PirepID != "c" {
+		t.Fatalf("Expected 3 entries newest first, got %+v", entries)
+	}
+	if entries[1].State != "ACCEPTED" {
+		t.Errorf("Expected updated state ACCEPTED, got %s", entries[1].State)
+	}
+	if entries[2].LandingRate == nil || *entries[2].LandingRate != -142 {
+		t.Errorf("Expected landing rate to round-trip, got %v", entries[2].LandingRate)
+	}
+}
+
+func TestSortAndFilter(t *testing.T) {
+	entries := testEntries()
+
+	Sort(entries, SortByFlightTime, false)
+	if entries[0].PirepID != "c" || entries[2].PirepID != "a" {
+		t.Errorf("Expected shortest flight first, got %s..%s", entries[0].PirepID, entries[2].PirepID)
+	}
+
+	filtered := Filter(entries, "ybbn")
+	if len(filtered) != 1 || filtered[0].PirepID != "c" {
+		t.Errorf("Expected only the Brisbane flight, got %+v", filtered)
+	}
+}
+
+func TestTotals(t *testing.T) {
+	byAircraft := TotalsByAircraft(testEntries())
+	if len(byAircraft) != 2 || byAircraft[0].Key != "VH-ABC" || byAircraft[0].Flights != 2 || byAircraft[0].FlightTime != 165 {
+		t.Errorf("Unexpected aircraft totals: %+v", byAircraft)
+	}
+
+	byAirport := TotalsByAirport(testEntries())
+	if byAirport[0].Key != "YSSY" || byAirport[0].Flights != 3 {
+		t.Errorf("Expected YSSY with 3 flights first, got %+v", byAirport[0])
+	}
+}
+
+func TestExport(t *testing.T) {
+	var csvOut bytes.Buffer
+	if err := WriteCSV(&csvOut, testEntries()); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&csvOut).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	if len(records) != 4 || len(records[1]) != len(csvHeader) {
+		t.Fatalf("Expected header plus 3 rows, got %d", len(records))
+	}
+	if records[1][17] != "-142" || records[2][17] != "" {
+		t.Errorf("Expected landing rate column, got %q and %q", records[1][17], records[2][17])
+	}
+
+	var jsonOut bytes.Buffer
+	if err := WriteJSON(&jsonOut, nil); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	var decoded []Entry
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil || decoded == nil {
+		t.Errorf("Expected an empty JSON array, got %q", jsonOut.String())
+	}
+}
@@ -0,0 +1,153 @@
+package logbook
+
+import (
+	"sort"
+	"strings"
+)
+
+type SortField int
+
+const (
+	SortByDate SortField = iota
+	SortByFlight
+	SortByRoute
+	SortByAircraft
+	SortByFlightTime
+	SortByDistance
+	SortByLandingRate
+)
+
+var sortFieldNames = []string{"date", "flight", "route", "aircraft", "flight time", "distance", "landing rate"}
+
+func (f SortField) String() string {
+	if int(f) < len(sortFieldNames) {
+		return sortFieldNames[f]
+	}
+	return "unknown"
+}
+
+func (f SortField) Next() SortField {
+	return SortField((int(f) + 1) % len(sortFieldNames))
+}
+
+func Sort(entries []Entry, field SortField, descending bool) {
+	less := func(a, b Entry) bool {
+		switch field {
+		case SortByFlight:
+			return a.FlightNumber < b.FlightNumber
+		case SortByRoute:
+			return a.Departure+a.Arrival < b.Departure+b.Arrival
+		case SortByAircraft:
+			return a.Registration < b.Registration
+		case SortByFlightTime:
+			return a.FlightTime < b.FlightTime
+		case SortByDistance:
+			return a.Distance < b.Distance
+		case SortByLandingRate:
+			// Softest landings (closest to zero) sort first
+			return landingRateOrZero(a) > landingRateOrZero(b)
+		default:
+			return a.PrefiledAt.Before(b.PrefiledAt)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if descending {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
+
+// Filter keeps entries whose flight number, callsign, airports, aircraft or
+// state contain query, ignoring case.
+func Filter(entries []Entry, query string) []Entry {
+	query = strings.ToUpper(strings.TrimSpace(query))
+	if query == "" {
+		return entries
+	}
+
+	var filtered []Entry
+	for _, entry := range entries {
+		haystack := strings.ToUpper(strings.Join([]string{
+			entry.FlightNumber,
+			entry.Callsign,
+			entry.Departure,
+			entry.Arrival,
+			entry.Registration,
+			entry.AircraftType,
+			entry.State,
+		}, " "))
+		if strings.Contains(haystack, query) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+type Total struct {
+	Key        string
+	Flights    int
+	FlightTime int     // minutes
+	Distance   float64 // nm
+	FuelUsed   float64 // kg
+}
+
+func TotalsByAircraft(entries []Entry) []Total {
+	return totals(entries, func(entry Entry) []string {
+		if entry.Registration == "" {
+			return []string{entry.AircraftType}
+		}
+		return []string{entry.Registration}
+	})
+}
+
+// TotalsByAirport counts each flight against both its departure and
+// arrival airport.
+func TotalsByAirport(entries []Entry) []Total {
+	return totals(entries, func(entry Entry) []string {
+		if entry.Departure == entry.Arrival {
+			return []string{entry.Departure}
+		}
+		return []string{entry.Departure, entry.Arrival}
+	})
+}
+
+func totals(entries []Entry, keys func(Entry) []string) []Total {
+	byKey := map[string]*Total{}
+	for _, entry := range entries {
+		for _, key := range keys(entry) {
+			if key == "" {
+				continue
+			}
+			total, ok := byKey[key]
+			if !ok {
+				total = &Total{Key: key}
+				byKey[key] = total
+			}
+			total.Flights++
+			total.FlightTime += entry.FlightTime
+			total.Distance += entry.Distance
+			total.FuelUsed += entry.FuelUsed
+		}
+	}
+
+	result := make([]Total, 0, len(byKey))
+	for _, total := range byKey {
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Flights != result[j].Flights {
+			return result[i].Flights > result[j].Flights
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+func landingRateOrZero(entry Entry) float64 {
+	if entry.LandingRate == nil {
+		return 0
+	}
+	return *entry.LandingRate
+}
@@ -0,0 +1,97 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is a log record kept by a Buffer.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs are the record's attributes as key=value text.
+	Attrs string
+}
+
+// Buffer is a slog.Handler that keeps the most recent records in memory, so
+// they can be shown in the TUI rather than only in the log file.
+type Buffer struct {
+	ring *ring
+	text slog.Handler
+}
+
+// ring holds entries oldest first once it has wrapped at next.
+type ring struct {
+	mutex   sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	pending Entry
+}
+
+func NewBuffer(size int) *Buffer {
+	r := &ring{entries: make([]Entry, max(1, size))}
+	// The text handler formats the attributes, including any from WithAttrs
+	// and WithGroup, and writes them to the ring.
+	text := slog.NewTextHandler(r, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, attr slog.Attr) slog.Attr {
+			if len(groups) == 0 && (attr.Key == slog.TimeKey || attr.Key == slog.LevelKey || attr.Key == slog.MessageKey) {
+				return slog.Attr{}
+			}
+			return attr
+		},
+	})
+	return &Buffer{ring: r, text: text}
+}
+
+func (b *Buffer) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (b *Buffer) Handle(ctx context.Context, record slog.Record) error {
+	b.ring.mutex.Lock()
+	defer b.ring.mutex.Unlock()
+
+	b.ring.pending = Entry{Time: record.Time, Level: record.Level, Message: record.Message}
+	return b.text.Handle(ctx, record)
+}
+
+func (b *Buffer) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Buffer{ring: b.ring, text: b.text.WithAttrs(attrs)}
+}
+
+func (b *Buffer) WithGroup(name string) slog.Handler {
+	return &Buffer{ring: b.ring, text: b.text.WithGroup(name)}
+}
+
+// Entries returns the kept records, oldest first.
+func (b *Buffer) Entries() []Entry {
+	b.ring.mutex.Lock()
+	defer b.ring.mutex.Unlock()
+
+	if !b.ring.full {
+		return append([]Entry(nil), b.ring.entries[:b.ring.next]...)
+	}
+	entries := make([]Entry, 0, len(b.ring.entries))
+	entries = append(entries, b.ring.entries[b.ring.next:]...)
+	return append(entries, b.ring.entries[:b.ring.next]...)
+}
+
+// Write is called by the text handler from within Handle, which holds the
+// mutex.
+func (r *ring) Write(p []byte) (int, error) {
+	entry := r.pending
+	entry.Attrs = strings.TrimSpace(string(p))
+
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
@@ -0,0 +1,198 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const redacted = "[REDACTED]"
+
+// minSecretLength keeps values too short to be real secrets from redacting
+// ordinary text.
+const minSecretLength = 4
+
+// sensitiveKeys are attribute keys whose values are never logged.
+var sensitiveKeys = map[string]bool{
+	"api_key":       true,
+	"apikey":        true,
+	"x-api-key":     true,
+	"authorization": true,
+	"token":         true,
+	"password":      true,
+}
+
+// redactor replaces secrets in text. Secrets can be added while it is in use.
+type redactor struct {
+	mutex    sync.Mutex
+	secrets  []string
+	replacer atomic.Pointer[strings.Replacer]
+}
+
+func newRedactor(secrets []string) *redactor {
+	r := &redactor{}
+	r.add(secrets...)
+	return r
+}
+
+func (r *redactor) add(secrets ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, secret := range secrets {
+		if len(secret) >= minSecretLength {
+			r.secrets = append(r.secrets, secret)
+		}
+	}
+	pairs := make([]string, 0, 2*len(r.secrets))
+	for _, secret := range r.secrets {
+		pairs = append(pairs, secret, redacted)
+	}
+	r.replacer.Store(strings.NewReplacer(pairs...))
+}
+
+func (r *redactor) Replace(text string) string {
+	return r.replacer.Load().Replace(text)
+}
+
+// handler filters records by Levels and redacts secrets before passing them
+// on.
+type handler struct {
+	next     slog.Handler
+	levels   *Levels
+	redactor *redactor
+}
+
+func newHandler(next slog.Handler, levels *Levels, redactor *redactor) *handler {
+	return &handler{next: next, levels: levels, redactor: redactor}
+}
+
+func (h *handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.minimum()
+}
+
+func (h *handler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.levels.enabled(record.Level, record.PC) {
+		return nil
+	}
+
+	clean := slog.NewRecord(record.Time, record.Level, h.redactor.Replace(record.Message), record.PC)
+	record.Attrs(func(attr slog.Attr) bool {
+		clean.AddAttrs(h.redact(attr))
+		return true
+	})
+	return h.next.Handle(ctx, clean)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		clean[i] = h.redact(attr)
+	}
+	return &handler{next: h.next.WithAttrs(clean), levels: h.levels, redactor: h.redactor}
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	return &handler{next: h.next.WithGroup(name), levels: h.levels, redactor: h.redactor}
+}
+
+func (h *handler) redact(attr slog.Attr) slog.Attr {
+	if sensitiveKeys[strings.ToLower(attr.Key)] {
+		return slog.String(attr.Key, redacted)
+	}
+
+	value := attr.Value.Resolve()
+	switch value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, h.redactor.Replace(value.String()))
+	case slog.KindGroup:
+		group := value.Group()
+		clean := make([]any, len(group))
+		for i, member := range group {
+			clean[i] = h.redact(member)
+		}
+		return slog.Group(attr.Key, clean...)
+	case slog.KindAny:
+		return slog.Attr{Key: attr.Key, Value: h.redactAny(value)}
+	}
+	return slog.Attr{Key: attr.Key, Value: value}
+}
+
+// redactAny handles errors and headers, which are how secrets would most
+// likely end up in a log.
+func (h *handler) redactAny(value slog.Value) slog.Value {
+	switch v := value.Any().(type) {
+	case error:
+		text := v.Error()
+		if clean := h.redactor.Replace(text); clean != text {
+			return slog.AnyValue(errors.New(clean))
+		}
+	case http.Header:
+		return slog.AnyValue(h.redactHeader(v))
+	case map[string][]string:
+		return slog.AnyValue(h.redactHeader(v))
+	case fmt.Stringer:
+		text := v.String()
+		if clean := h.redactor.Replace(text); clean != text {
+			return slog.StringValue(clean)
+		}
+	}
+	return value
+}
+
+func (h *handler) redactHeader(header map[string][]string) http.Header {
+	clean := make(http.Header, len(header))
+	for name, values := range header {
+		if sensitiveKeys[strings.ToLower(name)] {
+			clean[name] = []string{redacted}
+			continue
+		}
+		for _, value := range values {
+			clean[name] = append(clean[name], h.redactor.Replace(value))
+		}
+	}
+	return clean
+}
+
+// teeHandler passes records on to several handlers.
+type teeHandler []slog.Handler
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t teeHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, h := range t {
+		if h.Enabled(ctx, record.Level) {
+			errs = append(errs, h.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	handlers := make(teeHandler, len(t))
+	for i, h := range t {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
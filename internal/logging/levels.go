@@ -0,0 +1,157 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// levelSpec is a default level with overrides for some packages.
+type levelSpec struct {
+	level    slog.Level
+	packages map[string]slog.Level
+}
+
+// Levels decides which records are logged. It takes a default level and
+// per-package overrides such as "info,api=debug,udp=warn", where packages are
+// named by the last element of their import path. It can be changed while
+// PXP runs.
+type Levels struct {
+	spec atomic.Pointer[levelSpec]
+
+	// packageOf caches the package a call site belongs to
+	packageOf sync.Map // uintptr -> string
+}
+
+func NewLevels(spec string) (*Levels, error) {
+	levels := &Levels{}
+	if err := levels.Set(spec); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// ParseLevels checks spec without applying it.
+func ParseLevels(spec string) error {
+	_, err := parseSpec(spec)
+	return err
+}
+
+func (l *Levels) Set(spec string) error {
+	parsed, err := parseSpec(spec)
+	if err != nil {
+		return err
+	}
+	l.spec.Store(parsed)
+	return nil
+}
+
+func (l *Levels) String() string {
+	spec := l.spec.Load()
+	parts := []string{levelName(spec.level)}
+	names := make([]string, 0, len(spec.packages))
+	for name := range spec.packages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, name+"="+levelName(spec.packages[name]))
+	}
+	return strings.Join(parts, ",")
+}
+
+// minimum is the lowest level any package logs at.
+func (l *Levels) minimum() slog.Level {
+	spec := l.spec.Load()
+	minimum := spec.level
+	for _, level := range spec.packages {
+		minimum = min(minimum, level)
+	}
+	return minimum
+}
+
+// enabled reports whether a record logged from pc at level is wanted.
+func (l *Levels) enabled(level slog.Level, pc uintptr) bool {
+	spec := l.spec.Load()
+	if len(spec.packages) == 0 || pc == 0 {
+		return level >= spec.level
+	}
+	if packageLevel, ok := spec.packages[l.packageName(pc)]; ok {
+		return level >= packageLevel
+	}
+	return level >= spec.level
+}
+
+func (l *Levels) packageName(pc uintptr) string {
+	if name, ok := l.packageOf.Load(pc); ok {
+		return name.(string)
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	name := packageFromFunction(frame.Function)
+	l.packageOf.Store(pc, name)
+	return name
+}
+
+// packageFromFunction turns "example.com/x/internal/api.(*Client).Do" into
+// "api".
+func packageFromFunction(function string) string {
+	if slash := strings.LastIndex(function, "/"); slash >= 0 {
+		function = function[slash+1:]
+	}
+	if dot := strings.Index(function, "."); dot >= 0 {
+		function = function[:dot]
+	}
+	return function
+}
+
+func parseSpec(spec string) (*levelSpec, error) {
+	parsed := &levelSpec{level: slog.LevelInfo, packages: map[string]slog.Level{}}
+	for i, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		name, value, isPackage := strings.Cut(part, "=")
+		if !isPackage {
+			value = name
+		}
+		level, err := parseLevel(value)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case !isPackage && i == 0:
+			parsed.level = level
+		case !isPackage:
+			return nil, fmt.Errorf("only the first log level may be given without a package: %q", part)
+		default:
+			parsed.packages[strings.TrimSpace(name)] = level
+		}
+	}
+	return parsed, nil
+}
+
+func parseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("log level must be one of: debug, info, warn, error, got %q", value)
+}
+
+func levelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
@@ -1,33 +1,98 @@
 package logging
 
 import (
+	"io"
 	"log/slog"
 	"os"
-	"strings"
+	"time"
 )
 
-func SetupLogger(level string) *slog.Logger {
-	var logLevel slog.Level
-	switch strings.ToLower(level) {
-	case "debug":
-		logLevel = slog.LevelDebug
-	case "info":
-		logLevel = slog.LevelInfo
-	case "warn":
-		logLevel = slog.LevelWarn
-	case "error":
-		logLevel = slog.LevelError
-	default:
-		logLevel = slog.LevelInfo
+type Options struct {
+	// Level is a default level with optional per-package overrides, e.g.
+	// "info,api=debug".
+	Level string
+	// Format is "text" or "json".
+	Format string
+	// File is where logs go, rotated by MaxSize (MB), MaxAge (days) and
+	// MaxBackups. Empty means Output, or stdout.
+	File       string
+	Output     io.Writer
+	MaxSize    int
+	MaxAge     int
+	MaxBackups int
+	// Secrets are values, such as the API key, never to be written out.
+	Secrets []string
+	// BufferSize is how many recent records to also keep in Buffer, for the
+	// TUI. Zero keeps none.
+	BufferSize int
+}
+
+// Logging is a configured logger along with what is needed to adjust it while
+// PXP runs.
+type Logging struct {
+	Logger   *slog.Logger
+	Levels   *Levels
+	Buffer   *Buffer
+	file     *RotatingFile
+	redactor *redactor
+}
+
+func New(opts Options) (*Logging, error) {
+	levels, err := NewLevels(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	logging := &Logging{Levels: levels, redactor: newRedactor(opts.Secrets)}
+	var out io.Writer = os.Stdout
+	if opts.Output != nil {
+		out = opts.Output
+	}
+	if opts.File != "" {
+		maxAge := time.Duration(opts.MaxAge) * 24 * time.Hour
+		logging.file, err = NewRotatingFile(opts.File, int64(opts.MaxSize)*1024*1024, maxAge, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		out = logging.file
+	}
+
+	// Levels does the filtering, so let everything through here
+	handlerOpts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var next slog.Handler
+	if opts.Format == "json" {
+		next = slog.NewJSONHandler(out, handlerOpts)
+	} else {
+		next = slog.NewTextHandler(out, handlerOpts)
 	}
 
-	opts := &slog.HandlerOptions{
-		Level: logLevel,
+	if opts.BufferSize > 0 {
+		logging.Buffer = NewBuffer(opts.BufferSize)
+		next = teeHandler{next, logging.Buffer}
 	}
 
-	handler := slog.NewTextHandler(os.Stdout, opts)
-	logger := slog.New(handler)
-	slog.SetDefault(logger)
+	logging.Logger = slog.New(newHandler(next, levels, logging.redactor))
+	slog.SetDefault(logging.Logger)
+	return logging, nil
+}
 
-	return logger
+// Redact adds secrets that only became known after logging was set up.
+func (l *Logging) Redact(secrets ...string) {
+	l.redactor.add(secrets...)
+}
+
+func (l *Logging) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// SetupLogger logs to stdout at level, for commands that don't run the TUI.
+func SetupLogger(level string) *slog.Logger {
+	logging, err := New(Options{Level: level})
+	if err != nil {
+		logging, _ = New(Options{Level: "info"})
+	}
+	return logging.Logger
 }
@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevels(t *testing.T) {
+	levels, err := NewLevels("warn,logging=debug,api=error")
+	if err != nil {
+		t.Fatalf("NewLevels() error = %v", err)
+	}
+	if levels.String() != "warn,api=error,logging=debug" {
+		t.Errorf("Expected levels warn,api=error,logging=debug, got %s", levels.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "pxp.log")
+	logging, err := New(Options{Level: "warn,logging=debug", File: path})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer logging.Close()
+
+	// This test is in the logging package, so debug gets through
+	logging.Logger.Debug("kept")
+	logging.Levels.Set("warn")
+	logging.Logger.Debug("dropped")
+	logging.Logger.Warn("warned")
+
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "kept") || strings.Contains(string(data), "dropped") || !strings.Contains(string(data), "warned") {
+		t.Errorf("Unexpected log output:\n%s", data)
+	}
+
+	for _, spec := range []string{"verbose", "info,api=loud", "api=debug,info"} {
+		if err := ParseLevels(spec); err == nil {
+			t.Errorf("Expected %q to be rejected", spec)
+		}
+	}
+}
+
+func TestPackageFromFunction(t *testing.T) {
+	for function, expected := range map[string]string{
+		"github.com/julietrb1/phpvms-xplane/internal/api.(*Client).doRequest": "api",
+		"github.com/julietrb1/phpvms-xplane/internal/udp.NewListener.func1":   "udp",
+		"main.main": "main",
+	} {
+		if name := packageFromFunction(function); name != expected {
+			t.Errorf("Expected package %s for %s, got %s", expected, function, name)
+		}
+	}
+}
+
+func TestRedaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pxp.log")
+	logging, err := New(Options{Level: "debug", Format: "json", File: path, Secrets: []string{"apikey123"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer logging.Close()
+	logging.Redact("token456")
+
+	header := http.Header{"X-Api-Key": {"apikey123"}, "Accept": {"application/json"}}
+	logging.Logger.Info("Calling with apikey123",
+		"headers", header,
+		"X-API-Key", "anything",
+		"error", errors.New("bad key apikey123"),
+		"url", "http://pxp/?token=token456",
+	)
+
+	data, _ := os.ReadFile(path)
+	text := string(data)
+	if strings.Contains(text, "apikey123") || strings.Contains(text, "token456") || strings.Contains(text, "anything") {
+		t.Errorf("Expected secrets to be redacted, got:\n%s", text)
+	}
+	if !strings.Contains(text, "application/json") || !strings.Contains(text, `"msg":"Calling with [REDACTED]"`) {
+		t.Errorf("Expected the rest to be logged as JSON, got:\n%s", text)
+	}
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pxp.log")
+
+	file, err := NewRotatingFile(path, 10, 0, 2)
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer file.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	file.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	for i := 0; i < 5; i++ {
+		file.Write([]byte("12345678\n"))
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("Expected the log and 2 backups, got %d files", len(entries))
+	}
+	if data, _ := os.ReadFile(path); string(data) != "12345678\n" {
+		t.Errorf("Expected only the last write in the current file, got %q", data)
+	}
+
+	// Backups past their age are removed on the next rotation
+	file.MaxAge = time.Hour
+	now = now.Add(2 * time.Hour)
+	file.Write([]byte("12345678\n"))
+	entries, _ = os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("Expected old backups to be removed, got %d files", len(entries))
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	logging, err := New(Options{Level: "debug", File: filepath.Join(t.TempDir(), "pxp.log"), BufferSize: 3, Secrets: []string{"apikey123"}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer logging.Close()
+
+	logger := logging.Logger.With("pirep", "abc").WithGroup("api")
+	for i := 1; i <= 4; i++ {
+		logger.Info("Request", "attempt", i, "key", "apikey123")
+	}
+
+	entries := logging.Buffer.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries, got %d", len(entries))
+	}
+	if entries[0].Attrs != "pirep=abc api.attempt=2 api.key=[REDACTED]" {
+		t.Errorf("Expected the oldest kept entry to be attempt 2, got %q", entries[0].Attrs)
+	}
+	if entries[2].Message != "Request" || entries[2].Level != slog.LevelInfo {
+		t.Errorf("Unexpected entry %+v", entries[2])
+	}
+}
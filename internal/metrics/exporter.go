@@ -0,0 +1,283 @@
+package metrics
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+// requestBuckets are the upper bounds, in seconds, of the API latency
+// histogram. The API client gives up after 10s.
+var requestBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+var pirepStates = []service.PirepState{
+	service.PIREPStateInProgress,
+	service.PIREPStatePending,
+	service.PIREPStateAccepted,
+	service.PIREPStateCancelled,
+	service.PIREPStateDeleted,
+	service.PIREPStateDraft,
+	service.PIREPStateRejected,
+	service.PIREPStatePaused,
+}
+
+type requestKey struct {
+	method   string
+	endpoint string
+	status   string
+}
+
+type requestStats struct {
+	count   uint64
+	sum     float64
+	buckets []uint64 // cumulative, one per requestBuckets entry
+}
+
+// Exporter serves PXP's telemetry, API and PIREP metrics in the Prometheus
+// text format.
+type Exporter struct {
+	Metrics *udp.Metrics
+	Service *service.FlightService
+	Logger  *slog.Logger
+
+	mutex    sync.Mutex
+	requests map[requestKey]*requestStats
+}
+
+func NewExporter(metrics *udp.Metrics, flightService *service.FlightService, logger *slog.Logger) *Exporter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Exporter{
+		Metrics:  metrics,
+		Service:  flightService,
+		Logger:   logger,
+		requests: make(map[requestKey]*requestStats),
+	}
+}
+
+// ObserveRequest records a phpVMS or SimBrief request. It is an
+// api.RequestObserver.
+func (e *Exporter) ObserveRequest(method, endpoint string, status int, duration time.Duration) {
+	key := requestKey{method: method, endpoint: endpoint, status: "error"}
+	if status > 0 {
+		key.status = strconv.Itoa(status)
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	stats, ok := e.requests[key]
+	if !ok {
+		stats = &requestStats{buckets: make([]uint64, len(requestBuckets))}
+		e.requests[key] = stats
+	}
+	seconds := duration.Seconds()
+	stats.count++
+	stats.sum += seconds
+	for i, bound := range requestBuckets {
+		if seconds <= bound {
+			stats.buckets[i]++
+		}
+	}
+}
+
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := e.Write(w); err != nil {
+			e.Logger.Debug("Failed to write metrics", "error", err)
+		}
+	})
+	return mux
+}
+
+// Serve listens on addr until ctx is cancelled.
+func (e *Exporter) Serve(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on metrics address: %w", err)
+	}
+
+	server := &http.Server{Handler: e.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	e.Logger.Info("Metrics listening", "addr", listener.Addr().String())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Write writes every metric to w in the Prometheus text format.
+func (e *Exporter) Write(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	if e.Metrics != nil {
+		e.writeTelemetry(out)
+	}
+	e.writeRequests(out)
+	if e.Service != nil {
+		e.writeService(out)
+	}
+	return out.Flush()
+}
+
+func (e *Exporter) writeTelemetry(out *bufio.Writer) {
+	snapshot := e.Metrics.Snapshot()
+
+	writeHeader(out, "pxp_udp_packets_total", "counter", "UDP packets received from X-Plane.")
+	writeSample(out, "pxp_udp_packets_total", nil, float64(snapshot.PacketsAny))
+	writeHeader(out, "pxp_udp_decode_errors_total", "counter", "UDP packets that could not be decoded.")
+	writeSample(out, "pxp_udp_decode_errors_total", nil, float64(snapshot.PacketsErr))
+
+	// Until X-Plane has sent something, the flight values are only zero
+	if snapshot.LastPacketTime == nil {
+		return
+	}
+	writeHeader(out, "pxp_udp_last_packet_timestamp_seconds", "gauge", "When the last UDP packet was received.")
+	writeSample(out, "pxp_udp_last_packet_timestamp_seconds", nil, float64(snapshot.LastPacketTime.Unix()))
+
+	if position := snapshot.LastPosition; position != nil {
+		writeOptionalGauge(out, "pxp_flight_altitude_feet", "Altitude above mean sea level.", position.AltMSL)
+		writeOptionalGauge(out, "pxp_flight_altitude_agl_feet", "Altitude above ground level.", position.AltAGL)
+		writeOptionalGauge(out, "pxp_flight_ground_speed_knots", "Ground speed.", position.GS)
+		writeOptionalGauge(out, "pxp_flight_indicated_airspeed_knots", "Indicated airspeed.", position.IAS)
+		writeOptionalGauge(out, "pxp_flight_vertical_speed_fpm", "Vertical speed.", position.VSFPM)
+		writeOptionalGauge(out, "pxp_flight_heading_degrees", "Heading.", position.Heading)
+	}
+	if snapshot.LastFuel != nil {
+		writeHeader(out, "pxp_flight_fuel_kg", "gauge", "Fuel on board.")
+		writeSample(out, "pxp_flight_fuel_kg", nil, float64(*snapshot.LastFuel))
+	}
+	if snapshot.LastDistance != nil {
+		writeHeader(out, "pxp_flight_distance_nm", "gauge", "Distance flown.")
+		writeSample(out, "pxp_flight_distance_nm", nil, float64(*snapshot.LastDistance))
+	}
+	if snapshot.LastFlightTime != nil {
+		writeHeader(out, "pxp_flight_time_minutes", "gauge", "Flight time.")
+		writeSample(out, "pxp_flight_time_minutes", nil, float64(*snapshot.LastFlightTime))
+	}
+}
+
+func (e *Exporter) writeRequests(out *bufio.Writer) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	keys := make([]requestKey, 0, len(e.requests))
+	for key := range e.requests {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].endpoint != keys[j].endpoint {
+			return keys[i].endpoint < keys[j].endpoint
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+
+	writeHeader(out, "pxp_api_requests_total", "counter", "API requests by endpoint and status, status is \"error\" if there was no response.")
+	for _, key := range keys {
+		writeSample(out, "pxp_api_requests_total", key.labels(), float64(e.requests[key].count))
+	}
+
+	writeHeader(out, "pxp_api_request_duration_seconds", "histogram", "API request latency by endpoint and status.")
+	for _, key := range keys {
+		stats := e.requests[key]
+		for i, bound := range requestBuckets {
+			labels := append(key.labels(), "le", strconv.FormatFloat(bound, 'g', -1, 64))
+			writeSample(out, "pxp_api_request_duration_seconds_bucket", labels, float64(stats.buckets[i]))
+		}
+		writeSample(out, "pxp_api_request_duration_seconds_bucket", append(key.labels(), "le", "+Inf"), float64(stats.count))
+		writeSample(out, "pxp_api_request_duration_seconds_sum", key.labels(), stats.sum)
+		writeSample(out, "pxp_api_request_duration_seconds_count", key.labels(), float64(stats.count))
+	}
+}
+
+func (e *Exporter) writeService(out *bufio.Writer) {
+	if e.Service.Outbox != nil {
+		writeHeader(out, "pxp_acars_outbox_depth", "gauge", "ACARS log entries waiting to be posted.")
+		writeSample(out, "pxp_acars_outbox_depth", nil, float64(e.Service.Outbox.Pending()))
+	}
+
+	active := 0.0
+	if e.Service.GetActivePirepID() != nil {
+		active = 1
+	}
+	writeHeader(out, "pxp_pirep_active", "gauge", "Whether a PIREP is being tracked.")
+	writeSample(out, "pxp_pirep_active", nil, active)
+
+	current := e.Service.StateMachine.Get()
+	writeHeader(out, "pxp_pirep_state", "gauge", "The state of the tracked PIREP, 1 for the current state.")
+	for _, state := range pirepStates {
+		value := 0.0
+		if active == 1 && state == current {
+			value = 1
+		}
+		writeSample(out, "pxp_pirep_state", []string{"state", state.String()}, value)
+	}
+
+	if active == 1 {
+		score, violations := e.Service.GetScore()
+		writeHeader(out, "pxp_pirep_score", "gauge", "Score of the tracked PIREP so far.")
+		writeSample(out, "pxp_pirep_score", nil, float64(score))
+		writeHeader(out, "pxp_pirep_violations", "gauge", "Rule violations on the tracked PIREP so far.")
+		writeSample(out, "pxp_pirep_violations", nil, float64(len(violations)))
+	}
+}
+
+func (key requestKey) labels() []string {
+	return []string{"endpoint", key.endpoint, "method", key.method, "status", key.status}
+}
+
+func writeOptionalGauge(out *bufio.Writer, name, help string, value *float64) {
+	if value == nil {
+		return
+	}
+	writeHeader(out, name, "gauge", help)
+	writeSample(out, name, nil, *value)
+}
+
+func writeHeader(out *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(out, "# TYPE %s %s\n", name, kind)
+}
+
+// writeSample writes one sample, with labels given as name, value pairs.
+func writeSample(out *bufio.Writer, name string, labels []string, value float64) {
+	out.WriteString(name)
+	if len(labels) > 0 {
+		out.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			fmt.Fprintf(out, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		out.WriteByte('}')
+	}
+	fmt.Fprintf(out, " %s\n", strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+func TestExporterWrite(t *testing.T) {
+	phpvms := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/file") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer phpvms.Close()
+
+	client := api.NewClient(phpvms.URL, secret.New("key"), nil)
+	flightService := service.NewFlightService(client, nil)
+	udpMetrics := udp.NewMetrics()
+	udpMetrics.PacketsAny.Add(12)
+	udpMetrics.PacketsErr.Add(2)
+	udpMetrics.LastPacketTime.Store(time.Now().Unix())
+	udpMetrics.LastFuel.Store(5400)
+	altitude := 36000.0
+	udpMetrics.LastPosition.Store(&udp.Position{AltMSL: &altitude})
+
+	exporter := NewExporter(udpMetrics, flightService, nil)
+	client.Observer = exporter.ObserveRequest
+
+	ctx := context.Background()
+	client.UpdatePIREP(ctx, "aBc123", api.FlightUpdateRequest{})
+	client.UpdatePIREP(ctx, "xYz789", api.FlightUpdateRequest{})
+	client.FilePIREP(ctx, "aBc123", api.FilePIREPRequest{})
+	exporter.ObserveRequest(http.MethodGet, "/api/user", 0, 3*time.Second)
+	flightService.SetActivePirepID("aBc123")
+
+	var out strings.Builder
+	if err := exporter.Write(&out); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	text := out.String()
+
+	for _, expected := range []string{
+		"pxp_udp_packets_total 12\n",
+		"pxp_udp_decode_errors_total 2\n",
+		"pxp_flight_altitude_feet 36000\n",
+		"pxp_flight_fuel_kg 5400\n",
+		`pxp_api_requests_total{endpoint="/api/pireps/{id}",method="PUT",status="200"} 2` + "\n",
+		`pxp_api_requests_total{endpoint="/api/pireps/{id}/file",method="POST",status="400"} 1` + "\n",
+		`pxp_api_requests_total{endpoint="/api/user",method="GET",status="error"} 1` + "\n",
+		`pxp_api_request_duration_seconds_bucket{endpoint="/api/user",method="GET",status="error",le="2.5"} 0` + "\n",
+		`pxp_api_request_duration_seconds_bucket{endpoint="/api/user",method="GET",status="error",le="5"} 1` + "\n",
+		`pxp_api_request_duration_seconds_count{endpoint="/api/user",method="GET",status="error"} 1` + "\n",
+		"pxp_pirep_active 1\n",
+		`pxp_pirep_state{state="IN_PROGRESS"} 1` + "\n",
+		`pxp_pirep_state{state="PENDING"} 0` + "\n",
+		"pxp_pirep_score 100\n",
+		"# TYPE pxp_api_request_duration_seconds histogram\n",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected output to contain %q, got:\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "aBc123") {
+		t.Errorf("Expected PIREP IDs to be left out of endpoint labels")
+	}
+}
+
+func TestExporterWriteBeforeFirstPacket(t *testing.T) {
+	var out strings.Builder
+	if err := NewExporter(udp.NewMetrics(), nil, nil).Write(&out); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "pxp_udp_packets_total 0\n") {
+		t.Errorf("Expected packet counter, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "pxp_flight_") {
+		t.Errorf("Expected no flight gauges before the first packet, got:\n%s", out.String())
+	}
+}
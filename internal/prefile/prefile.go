@@ -0,0 +1,175 @@
+package prefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+type FlightType struct {
+	Code string
+	Name string
+}
+
+// FlightTypes are phpVMS's flight type codes.
+var FlightTypes = []FlightType{
+	{"J", "Scheduled passenger"},
+	{"F", "Scheduled cargo"},
+	{"C", "Charter passenger only"},
+	{"A", "Additional cargo/mail"},
+	{"E", "VIP"},
+	{"G", "Additional passenger"},
+	{"H", "Charter cargo/mail"},
+	{"I", "Ambulance"},
+	{"K", "Training"},
+	{"M", "Mail service"},
+	{"O", "Charter special handling"},
+	{"P", "Positioning"},
+	{"T", "Technical test"},
+	{"W", "Military"},
+	{"X", "Technical stop"},
+	{"S", "Shuttle"},
+	{"B", "Additional shuttle"},
+	{"Q", "Cargo in cabin"},
+	{"R", "Additional cargo in cabin"},
+	{"L", "Charter cargo in cabin"},
+	{"D", "General aviation"},
+	{"N", "Air taxi"},
+	{"Y", "Company specific"},
+	{"Z", "Other"},
+}
+
+// FindFlightType returns the flight type with code, ignoring case.
+func FindFlightType(code string) (FlightType, bool) {
+	for _, flightType := range FlightTypes {
+		if strings.EqualFold(flightType.Code, code) {
+			return flightType, true
+		}
+	}
+	return FlightType{}, false
+}
+
+// Networks are the online networks a flight can be flown on.
+var Networks = []string{"Offline", "VATSIM", "IVAO", "PilotEdge"}
+
+// FindNetwork returns the network's name as it is sent to phpVMS, ignoring
+// case.
+func FindNetwork(name string) (string, bool) {
+	for _, network := range Networks {
+		if strings.EqualFold(network, name) {
+			return network, true
+		}
+	}
+	return "", false
+}
+
+// Field is a custom PIREP field sent when prefiling. Value is the default, and
+// may use the placeholders in Values.
+type Field struct {
+	Name     string   `json:"name"`
+	Value    string   `json:"value"`
+	Options  []string `json:"options,omitempty"`
+	Required bool     `json:"required,omitempty"`
+}
+
+// Template is a VA's custom prefile fields.
+type Template struct {
+	Fields []Field `json:"fields"`
+}
+
+// Values fill in the placeholders in field values: {simulator}, {network},
+// {callsign} and {flight_type}.
+type Values struct {
+	Simulator  string
+	Network    string
+	Callsign   string
+	FlightType string
+}
+
+// Default is the template used without a PREFILE_FIELDS_FILE, matching what
+// vmsACARS sends.
+func Default() *Template {
+	return &Template{Fields: []Field{
+		{Name: "Simulator", Value: "{simulator}"},
+		{Name: "Unlimited Fuel", Value: "Off", Options: []string{"On", "Off"}},
+		{Name: "Network Online", Value: "{network}", Options: Networks},
+		{Name: "Network Callsign Check", Value: "0"},
+		{Name: "Network Callsign Used", Value: "{callsign}"},
+	}}
+}
+
+func Load(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prefile fields: %w", err)
+	}
+
+	var template Template
+	if err := json.Unmarshal(data, &template); err != nil {
+		return nil, fmt.Errorf("%s: failed to parse prefile fields: %w", path, err)
+	}
+	if err := template.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &template, nil
+}
+
+func (t *Template) Validate() error {
+	seen := map[string]bool{}
+	for i, field := range t.Fields {
+		if field.Name == "" {
+			return fmt.Errorf("field %d has no name", i+1)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("duplicate field name %q", field.Name)
+		}
+		seen[field.Name] = true
+
+		// Defaults with placeholders can only be checked once they're filled in
+		if !strings.Contains(field.Value, "{") && field.Value != "" && len(field.Options) > 0 &&
+			!slices.Contains(field.Options, field.Value) {
+			return fmt.Errorf("field %q defaults to %q, which isn't one of its options", field.Name, field.Value)
+		}
+	}
+	return nil
+}
+
+// Fill fills in the template with values, replacing defaults with any
+// overrides by field name, and checks the result. Overrides for fields the
+// template doesn't have are sent as they are.
+func (t *Template) Fill(values Values, overrides map[string]string) (map[string]interface{}, error) {
+	replacer := strings.NewReplacer(
+		"{simulator}", values.Simulator,
+		"{network}", values.Network,
+		"{callsign}", values.Callsign,
+		"{flight_type}", values.FlightType,
+	)
+
+	fields := make(map[string]interface{}, len(t.Fields))
+	var problems []string
+	for _, field := range t.Fields {
+		value, ok := overrides[field.Name]
+		if !ok {
+			value = replacer.Replace(field.Value)
+		}
+
+		switch {
+		case value == "" && field.Required:
+			problems = append(problems, field.Name+" is required")
+		case value != "" && len(field.Options) > 0 && !slices.Contains(field.Options, value):
+			problems = append(problems, fmt.Sprintf("%s must be one of %s", field.Name, strings.Join(field.Options, ", ")))
+		}
+		fields[field.Name] = value
+	}
+	for name, value := range overrides {
+		if _, ok := fields[name]; !ok {
+			fields[name] = value
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid prefile fields: %s", strings.Join(problems, "; "))
+	}
+	return fields, nil
+}
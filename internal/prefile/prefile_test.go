@@ -0,0 +1,89 @@
+package prefile
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultFields(t *testing.T) {
+	fields, err := Default().Fill(Values{Simulator: "X-Plane 12", Network: "IVAO", Callsign: "QFA401"}, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := map[string]string{
+		"Simulator":             "X-Plane 12",
+		"Unlimited Fuel":        "Off",
+		"Network Online":        "IVAO",
+		"Network Callsign Used": "QFA401",
+	}
+	for name, value := range want {
+		if fields[name] != value {
+			t.Errorf("Expected %s to be %q, got %v", name, value, fields[name])
+		}
+	}
+}
+
+func TestFieldsOverridesAndValidation(t *testing.T) {
+	template := &Template{Fields: []Field{
+		{Name: "Gate", Required: true},
+		{Name: "Unlimited Fuel", Value: "Off", Options: []string{"On", "Off"}},
+	}}
+
+	if _, err := template.Fill(Values{}, nil); err == nil || !strings.Contains(err.Error(), "Gate is required") {
+		t.Errorf("Expected a missing required field error, got %v", err)
+	}
+
+	_, err := template.Fill(Values{}, map[string]string{"Gate": "12", "Unlimited Fuel": "Maybe"})
+	if err == nil || !strings.Contains(err.Error(), "Unlimited Fuel must be one of On, Off") {
+		t.Errorf("Expected an invalid option error, got %v", err)
+	}
+
+	fields, err := template.Fill(Values{}, map[string]string{"Gate": "12", "Unlimited Fuel": "On", "Stand": "B4"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if fields["Gate"] != "12" || fields["Unlimited Fuel"] != "On" || fields["Stand"] != "B4" {
+		t.Errorf("Expected overrides to be used, got %v", fields)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.json")
+	os.WriteFile(valid, []byte(`{"fields": [{"name": "Simulator", "value": "{simulator}"}, {"name": "Gate", "required": true}]}`), 0o644)
+	template, err := Load(valid)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(template.Fields) != 2 || !template.Fields[1].Required {
+		t.Errorf("Expected two fields with Gate required, got %+v", template.Fields)
+	}
+
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(invalid, []byte(`{"fields": [{"name": "Fuel", "value": "Lots", "options": ["On", "Off"]}]}`), 0o644)
+	if _, err := Load(invalid); err == nil {
+		t.Errorf("Expected a default outside the options to be rejected")
+	}
+
+	duplicate := filepath.Join(dir, "duplicate.json")
+	os.WriteFile(duplicate, []byte(`{"fields": [{"name": "Gate"}, {"name": "Gate"}]}`), 0o644)
+	if _, err := Load(duplicate); err == nil {
+		t.Errorf("Expected duplicate field names to be rejected")
+	}
+}
+
+func TestFindFlightTypeAndNetwork(t *testing.T) {
+	if flightType, ok := FindFlightType("f"); !ok || flightType.Name != "Scheduled cargo" {
+		t.Errorf("Expected scheduled cargo, got %+v", flightType)
+	}
+	if _, ok := FindFlightType("V"); ok {
+		t.Errorf("Expected V not to be a flight type")
+	}
+	if network, ok := FindNetwork("vatsim"); !ok || network != "VATSIM" {
+		t.Errorf("Expected VATSIM, got %q", network)
+	}
+}
@@ -0,0 +1,195 @@
+package rules
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const MaxScore = 100
+
+type Sample struct {
+	Time   time.Time
+	Phase  string
+	Lat    float64
+	Lon    float64
+	Values map[Metric]float64
+}
+
+type Violation struct {
+	RuleID      string    `json:"rule_id"`
+	Description string    `json:"description"`
+	Time        time.Time `json:"time"`
+	Phase       string    `json:"phase"`
+	Points      int       `json:"points"`
+	Detail      string    `json:"detail,omitempty"`
+	Lat         float64   `json:"lat"`
+	Lon         float64   `json:"lon"`
+}
+
+func (v Violation) String() string {
+	s := fmt.Sprintf("%s (-%d)", v.Description, v.Points)
+	if v.Detail != "" {
+		s += ": " + v.Detail
+	}
+	return s
+}
+
+type ruleState struct {
+	since time.Time
+	fired bool
+	count int
+}
+
+// Engine evaluates telemetry samples against a rule set and keeps the
+// violations for the current flight.
+type Engine struct {
+	mutex      sync.RWMutex
+	rules      []Rule
+	states     map[string]*ruleState
+	violations []Violation
+}
+
+func NewEngine(ruleSet *RuleSet) *Engine {
+	if ruleSet == nil {
+		ruleSet = Default()
+	}
+
+	return &Engine{
+		rules:  ruleSet.Rules,
+		states: map[string]*ruleState{},
+	}
+}
+
+// Evaluate checks a sample against every rule and returns the violations it
+// caused. A rule fires once its conditions have held for SustainSeconds, and
+// won't fire again until they stop holding.
+func (e *Engine) Evaluate(sample Sample) []Violation {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var fired []Violation
+	for _, rule := range e.rules {
+		state, ok := e.states[rule.ID]
+		if !ok {
+			state = &ruleState{}
+			e.states[rule.ID] = state
+		}
+
+		if !rule.appliesTo(sample.Phase) || !matchesAll(rule.When, sample.Values) {
+			state.since = time.Time{}
+			state.fired = false
+			continue
+		}
+
+		if state.since.IsZero() {
+			state.since = sample.Time
+		}
+		if state.fired || (state.count > 0 && !rule.Repeat) {
+			continue
+		}
+		if sample.Time.Sub(state.since) < time.Duration(rule.SustainSeconds*float64(time.Second)) {
+			continue
+		}
+
+		state.fired = true
+		state.count++
+		violation := Violation{
+			RuleID:      rule.ID,
+			Description: rule.Description,
+			Time:        sample.Time,
+			Phase:       sample.Phase,
+			Points:      rule.Points,
+			Detail:      detail(rule.When, sample.Values),
+			Lat:         sample.Lat,
+			Lon:         sample.Lon,
+		}
+		e.violations = append(e.violations, violation)
+		fired = append(fired, violation)
+	}
+	return fired
+}
+
+// Restore reloads violations recorded earlier in a flight, such as after PXP
+// restarts mid-flight, so they still count towards the score.
+func (e *Engine) Restore(violations []Violation) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, violation := range violations {
+		state, ok := e.states[violation.RuleID]
+		if !ok {
+			state = &ruleState{}
+			e.states[violation.RuleID] = state
+		}
+		state.count++
+		e.violations = append(e.violations, violation)
+	}
+}
+
+func (e *Engine) Reset() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.states = map[string]*ruleState{}
+	e.violations = nil
+}
+
+func (e *Engine) Violations() []Violation {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return append([]Violation(nil), e.violations...)
+}
+
+func (e *Engine) Score() int {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	score := MaxScore
+	for _, violation := range e.violations {
+		score -= violation.Points
+	}
+	return max(0, score)
+}
+
+// Summary lists the violations, worst first, for the PIREP fields and
+// final ACARS log.
+func (e *Engine) Summary() string {
+	violations := e.Violations()
+	if len(violations) == 0 {
+		return "No violations"
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Points > violations[j].Points
+	})
+	parts := make([]string, len(violations))
+	for i, violation := range violations {
+		parts[i] = fmt.Sprintf("%s (-%d)", violation.Description, violation.Points)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func matchesAll(conditions []Condition, values map[Metric]float64) bool {
+	for _, condition := range conditions {
+		if !condition.Matches(values) {
+			return false
+		}
+	}
+	return true
+}
+
+func detail(conditions []Condition, values map[Metric]float64) string {
+	var parts []string
+	seen := map[Metric]bool{}
+	for _, condition := range conditions {
+		if seen[condition.Metric] {
+			continue
+		}
+		seen[condition.Metric] = true
+		parts = append(parts, fmt.Sprintf("%s %.0f", condition.Metric, values[condition.Metric]))
+	}
+	return strings.Join(parts, ", ")
+}
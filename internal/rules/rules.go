@@ -0,0 +1,222 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+)
+
+type Metric string
+
+const (
+	MetricAltMSL        Metric = "altitude_msl"  // ft
+	MetricAltAGL        Metric = "altitude_agl"  // ft
+	MetricIAS           Metric = "ias"           // kt
+	MetricGS            Metric = "gs"            // kt
+	MetricVS            Metric = "vs"            // ft/min
+	MetricOnGround      Metric = "on_ground"     // 1 or 0
+	MetricLandingRate   Metric = "landing_rate"  // ft/min, only present on the touchdown sample
+	MetricBeaconLights  Metric = "beacon_lights" // 1 or 0
+	MetricNavLights     Metric = "nav_lights"
+	MetricStrobeLights  Metric = "strobe_lights"
+	MetricLandingLights Metric = "landing_lights"
+	MetricTaxiLights    Metric = "taxi_lights"
+)
+
+var Metrics = []Metric{
+	MetricAltMSL, MetricAltAGL, MetricIAS, MetricGS, MetricVS, MetricOnGround, MetricLandingRate,
+	MetricBeaconLights, MetricNavLights, MetricStrobeLights, MetricLandingLights, MetricTaxiLights,
+}
+
+var operators = []string{"<", "<=", ">", ">=", "==", "!="}
+
+type Condition struct {
+	Metric Metric  `json:"metric"`
+	Op     string  `json:"op"`
+	Value  float64 `json:"value"`
+}
+
+func (c Condition) Matches(values map[Metric]float64) bool {
+	actual, ok := values[c.Metric]
+	if !ok {
+		// Metrics the bridge doesn't send never break a rule
+		return false
+	}
+
+	switch c.Op {
+	case "<":
+		return actual < c.Value
+	case "<=":
+		return actual <= c.Value
+	case ">":
+		return actual > c.Value
+	case ">=":
+		return actual >= c.Value
+	case "==":
+		return actual == c.Value
+	case "!=":
+		return actual != c.Value
+	default:
+		return false
+	}
+}
+
+func (c Condition) String() string {
+	return fmt.Sprintf("%s %s %g", c.Metric, c.Op, c.Value)
+}
+
+// Rule deducts Points when every condition in When holds during one of
+// Phases (or any phase, if none are listed) for at least SustainSeconds.
+type Rule struct {
+	ID             string      `json:"id"`
+	Description    string      `json:"description"`
+	Phases         []string    `json:"phases,omitempty"`
+	When           []Condition `json:"when"`
+	SustainSeconds float64     `json:"sustain_seconds,omitempty"`
+	Points         int         `json:"points"`
+	Repeat         bool        `json:"repeat,omitempty"` // deduct again each time the rule is broken anew
+}
+
+func (r Rule) appliesTo(phase string) bool {
+	return len(r.Phases) == 0 || slices.Contains(r.Phases, phase)
+}
+
+type RuleSet struct {
+	Rules []Rule `json:"rules"`
+}
+
+func Load(path string) (*RuleSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rule file: %w", err)
+	}
+	defer file.Close()
+
+	ruleSet, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ruleSet, nil
+}
+
+func Parse(r io.Reader) (*RuleSet, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	var ruleSet RuleSet
+	if err := decoder.Decode(&ruleSet); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	if err := ruleSet.Validate(); err != nil {
+		return nil, err
+	}
+	return &ruleSet, nil
+}
+
+func (rs *RuleSet) Validate() error {
+	seen := map[string]bool{}
+	for i, rule := range rs.Rules {
+		if rule.ID == "" {
+			return fmt.Errorf("rule %d has no id", i+1)
+		}
+		if seen[rule.ID] {
+			return fmt.Errorf("duplicate rule id %q", rule.ID)
+		}
+		seen[rule.ID] = true
+
+		if len(rule.When) == 0 {
+			return fmt.Errorf("rule %q has no conditions", rule.ID)
+		}
+		for _, condition := range rule.When {
+			if !slices.Contains(Metrics, condition.Metric) {
+				return fmt.Errorf("rule %q uses unknown metric %q", rule.ID, condition.Metric)
+			}
+			if !slices.Contains(operators, condition.Op) {
+				return fmt.Errorf("rule %q uses unknown operator %q", rule.ID, condition.Op)
+			}
+		}
+		if rule.Points < 0 {
+			return fmt.Errorf("rule %q has negative points", rule.ID)
+		}
+		if rule.SustainSeconds < 0 {
+			return fmt.Errorf("rule %q has negative sustain_seconds", rule.ID)
+		}
+	}
+	return nil
+}
+
+func (rs *RuleSet) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(rs)
+}
+
+// Default is the rule set used when RULES_FILE isn't set. It covers the
+// checks most VAs grade on.
+func Default() *RuleSet {
+	// Only the statuses the bridge sends. It reports TXI until the takeoff
+	// roll passes 50 kt IAS, so the taxi speed rule leaves TXI out rather
+	// than firing on every takeoff.
+	airborne := []string{"TOF", "ENR", "TEN", "FIN", "LDG"}
+	return &RuleSet{Rules: []Rule{
+		{
+			ID:             "overspeed-10k",
+			Description:    "IAS above 250 kt below 10,000 ft",
+			Phases:         airborne,
+			When:           []Condition{{MetricAltMSL, "<", 10000}, {MetricIAS, ">", 250}},
+			SustainSeconds: 5,
+			Points:         5,
+			Repeat:         true,
+		},
+		{
+			ID:             "taxi-speed",
+			Description:    "Taxi speed above 30 kt after landing",
+			Phases:         []string{"LAN", "ARR"},
+			When:           []Condition{{MetricOnGround, "==", 1}, {MetricGS, ">", 30}},
+			SustainSeconds: 3,
+			Points:         3,
+			Repeat:         true,
+		},
+		{
+			ID:          "hard-landing",
+			Description: "Landing rate harder than 600 fpm",
+			When:        []Condition{{MetricLandingRate, "<", -600}},
+			Points:      10,
+			Repeat:      true,
+		},
+		{
+			ID:          "firm-landing",
+			Description: "Landing rate harder than 400 fpm",
+			When:        []Condition{{MetricLandingRate, "<", -400}, {MetricLandingRate, ">=", -600}},
+			Points:      3,
+			Repeat:      true,
+		},
+		{
+			ID:             "beacon-off-taxi",
+			Description:    "Beacon off while taxiing",
+			Phases:         []string{"TXI", "TOF"},
+			When:           []Condition{{MetricOnGround, "==", 1}, {MetricGS, ">", 3}, {MetricBeaconLights, "==", 0}},
+			SustainSeconds: 5,
+			Points:         2,
+		},
+		{
+			ID:             "landing-lights-10k",
+			Description:    "Landing lights off below 10,000 ft",
+			Phases:         airborne,
+			When:           []Condition{{MetricOnGround, "==", 0}, {MetricAltMSL, "<", 10000}, {MetricLandingLights, "==", 0}},
+			SustainSeconds: 10,
+			Points:         2,
+		},
+		{
+			ID:             "strobes-off-runway",
+			Description:    "Strobes off during takeoff",
+			Phases:         []string{"TOF"},
+			When:           []Condition{{MetricStrobeLights, "==", 0}, {MetricIAS, ">", 40}},
+			SustainSeconds: 3,
+			Points:         2,
+		},
+	}}
+}
@@ -0,0 +1,141 @@
+package rules
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 8, 21, 10, 0, 0, 0, time.UTC)
+
+func sample(seconds int, phase string, values map[Metric]float64) Sample {
+	return Sample{Time: start.Add(time.Duration(seconds) * time.Second), Phase: phase, Values: values}
+}
+
+func TestEngineSustainAndRepeat(t *testing.T) {
+	engine := NewEngine(&RuleSet{Rules: []Rule{{
+		ID:             "overspeed",
+		Description:    "Overspeed",
+		Phases:         []string{"ENR"},
+		When:           []Condition{{MetricAltMSL, "<", 10000}, {MetricIAS, ">", 250}},
+		SustainSeconds: 5,
+		Points:         5,
+		Repeat:         true,
+	}}})
+
+	fast := map[Metric]float64{MetricAltMSL: 8000, MetricIAS: 270}
+	slow := map[Metric]float64{MetricAltMSL: 8000, MetricIAS: 240}
+
+	if fired := engine.Evaluate(sample(0, "ENR", fast)); len(fired) != 0 {
+		t.Fatalf("Expected no violation before sustain period, got %v", fired)
+	}
+	if fired := engine.Evaluate(sample(6, "ENR", fast)); len(fired) != 1 {
+		t.Fatalf("Expected violation after sustain period, got %v", fired)
+	}
+	if fired := engine.Evaluate(sample(7, "ENR", fast)); len(fired) != 0 {
+		t.Errorf("Expected one violation per episode, got %v", fired)
+	}
+
+	engine.Evaluate(sample(8, "ENR", slow))
+	engine.Evaluate(sample(9, "ENR", fast))
+	engine.Evaluate(sample(15, "ENR", fast))
+	if fired := engine.Evaluate(sample(16, "TXI", fast)); len(fired) != 0 {
+		t.Errorf("Expected rule to be ignored outside its phases, got %v", fired)
+	}
+
+	if score := engine.Score(); score != 90 {
+		t.Errorf("Expected score 90 after two violations, got %d", score)
+	}
+}
+
+func TestEngineOnceAndMissingMetrics(t *testing.T) {
+	engine := NewEngine(Default())
+
+	// No lights in the payload, so the lights rules can't fire
+	taxiing := map[Metric]float64{MetricOnGround: 1, MetricGS: 15}
+	engine.Evaluate(sample(0, "TXI", taxiing))
+	if fired := engine.Evaluate(sample(10, "TXI", taxiing)); len(fired) != 0 {
+		t.Fatalf("Expected no violations without light data, got %v", fired)
+	}
+
+	taxiing[MetricBeaconLights] = 0
+	engine.Evaluate(sample(20, "TXI", taxiing))
+	if fired := engine.Evaluate(sample(26, "TXI", taxiing)); len(fired) != 1 || fired[0].RuleID != "beacon-off-taxi" {
+		t.Fatalf("Expected beacon violation, got %v", fired)
+	}
+
+	taxiing[MetricBeaconLights] = 1
+	engine.Evaluate(sample(27, "TXI", taxiing))
+	taxiing[MetricBeaconLights] = 0
+	engine.Evaluate(sample(28, "TXI", taxiing))
+	if fired := engine.Evaluate(sample(40, "TXI", taxiing)); len(fired) != 0 {
+		t.Errorf("Expected non-repeating rule to fire once, got %v", fired)
+	}
+}
+
+func TestEngineLandingRate(t *testing.T) {
+	engine := NewEngine(Default())
+
+	fired := engine.Evaluate(sample(0, "LDG", map[Metric]float64{MetricOnGround: 1, MetricLandingRate: -480}))
+	if len(fired) != 1 || fired[0].RuleID != "firm-landing" {
+		t.Fatalf("Expected firm landing, got %v", fired)
+	}
+	if !strings.Contains(fired[0].Detail, "landing_rate -480") {
+		t.Errorf("Expected landing rate in detail, got %q", fired[0].Detail)
+	}
+
+	engine.Reset()
+	fired = engine.Evaluate(sample(0, "LDG", map[Metric]float64{MetricOnGround: 1, MetricLandingRate: -150}))
+	if len(fired) != 0 || engine.Score() != MaxScore {
+		t.Errorf("Expected a clean landing, got %v (score %d)", fired, engine.Score())
+	}
+}
+
+func TestDefaultTakeoffRollIsClean(t *testing.T) {
+	engine := NewEngine(Default())
+
+	// The bridge reports TXI until 50 kt IAS
+	for second := 0; second <= 10; second++ {
+		roll := map[Metric]float64{MetricOnGround: 1, MetricGS: float64(20 + 3*second), MetricIAS: float64(20 + 3*second), MetricBeaconLights: 1, MetricStrobeLights: 1}
+		if fired := engine.Evaluate(sample(second, "TXI", roll)); len(fired) != 0 {
+			t.Fatalf("Expected no violations on the takeoff roll, got %v", fired)
+		}
+	}
+
+	fast := map[Metric]float64{MetricOnGround: 1, MetricGS: 40}
+	engine.Evaluate(sample(100, "LAN", fast))
+	if fired := engine.Evaluate(sample(104, "LAN", fast)); len(fired) != 1 || fired[0].RuleID != "taxi-speed" {
+		t.Errorf("Expected a taxi speed violation after landing, got %v", fired)
+	}
+}
+
+func TestParseValidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"unknown metric", `{"rules":[{"id":"a","when":[{"metric":"mach","op":">","value":1}],"points":1}]}`, "unknown metric"},
+		{"unknown operator", `{"rules":[{"id":"a","when":[{"metric":"ias","op":"=>","value":1}],"points":1}]}`, "unknown operator"},
+		{"duplicate id", `{"rules":[{"id":"a","when":[{"metric":"ias","op":">","value":1}]},{"id":"a","when":[{"metric":"ias","op":">","value":1}]}]}`, "duplicate"},
+		{"unknown field", `{"rules":[{"id":"a","penalty":3,"when":[{"metric":"ias","op":">","value":1}]}]}`, "unknown field"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := Default().Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if _, err := Parse(&buf); err != nil {
+		t.Errorf("Expected default rules to round-trip, got %v", err)
+	}
+}
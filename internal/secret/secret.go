@@ -0,0 +1,64 @@
+// Package secret holds values, such as the API key, that must never be
+// written out.
+package secret
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+const redacted = "[REDACTED]"
+
+// Value is a secret that prints, formats, marshals and logs as [REDACTED],
+// so it can't leak through a log line, a dump of the struct holding it or an
+// error message. Reveal is the only way to get it back. It's held behind a
+// pointer, so even where fmt can't call these methods, such as an unexported
+// field, only an address is printed.
+type Value struct {
+	value *string
+}
+
+func New(value string) Value {
+	if value == "" {
+		return Value{}
+	}
+	return Value{value: &value}
+}
+
+// Reveal returns the secret, for where it's actually used, such as a request
+// header.
+func (v Value) Reveal() string {
+	if v.value == nil {
+		return ""
+	}
+	return *v.value
+}
+
+func (v Value) IsSet() bool {
+	return v.value != nil
+}
+
+func (v Value) String() string {
+	return redacted
+}
+
+func (v Value) GoString() string {
+	return redacted
+}
+
+// Format covers every verb, including %x and %q, which don't use String.
+func (v Value) Format(f fmt.State, verb rune) {
+	fmt.Fprint(f, redacted)
+}
+
+func (v Value) MarshalText() ([]byte, error) {
+	return []byte(redacted), nil
+}
+
+func (v Value) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + redacted + `"`), nil
+}
+
+func (v Value) LogValue() slog.Value {
+	return slog.StringValue(redacted)
+}
@@ -0,0 +1,46 @@
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestValueNeverWrittenOut(t *testing.T) {
+	key := New("apikey123")
+	holder := struct {
+		Name   string
+		APIKey Value
+		Ptr    *Value
+		hidden Value
+	}{"config", key, &key, key}
+
+	var outputs []string
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%10s"} {
+		outputs = append(outputs, fmt.Sprintf(verb, key), fmt.Sprintf(verb, holder))
+	}
+	outputs = append(outputs, fmt.Sprint(key, holder), fmt.Sprintln(&key))
+
+	data, err := json.Marshal(holder)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	outputs = append(outputs, string(data))
+
+	var logs bytes.Buffer
+	slog.New(slog.NewTextHandler(&logs, nil)).Info("Loaded", "key", key, "config", holder)
+	slog.New(slog.NewJSONHandler(&logs, nil)).Info("Loaded", "key", key, "config", holder)
+	outputs = append(outputs, logs.String())
+
+	for _, output := range outputs {
+		if strings.Contains(output, "apikey123") || strings.Contains(output, fmt.Sprintf("%x", "apikey123")) {
+			t.Errorf("Expected the key to be redacted, got %s", output)
+		}
+	}
+	if key.Reveal() != "apikey123" || !key.IsSet() || New("").IsSet() {
+		t.Errorf("Expected Reveal() to return the key, got %s", key.Reveal())
+	}
+}
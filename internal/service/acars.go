@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/acars"
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+func (service *FlightService) detectMilestones(pirepID string, payload *udp.Payload, point track.Point, events []track.Event) {
+	sample := acars.Sample{
+		Time:     point.Time,
+		Lat:      point.Lat,
+		Lon:      point.Lon,
+		AltMSL:   point.AltMSL,
+		GS:       point.GS,
+		VS:       point.VS,
+		Heading:  point.Heading,
+		OnGround: point.OnGround,
+		Weight:   payload.Weight,
+	}
+	if payload.Engines != nil {
+		engines := udp.Int(payload.Engines)
+		sample.Engines = &engines
+	}
+
+	for _, event := range events {
+		switch event.Kind {
+		case track.EventTouchdown:
+			rate := event.Value
+			sample.LandingRate = &rate
+		case track.EventPhase:
+			service.queueACARS(acars.Entry{
+				PirepID: pirepID,
+				Kind:    acars.EntryEvent,
+				Text:    "Phase changed to " + event.Text,
+				Time:    event.Time,
+				Lat:     event.Lat,
+				Lon:     event.Lon,
+			})
+		}
+	}
+
+	for _, milestone := range service.Milestones.Update(sample) {
+		service.Logger.Info("Flight milestone", "milestone", milestone.Kind, "text", milestone.Text)
+		service.queueACARS(acars.Entry{
+			PirepID:   pirepID,
+			Kind:      acars.EntryLog,
+			Milestone: milestone.Kind,
+			Text:      milestone.Text,
+			Time:      milestone.Time,
+			Lat:       milestone.Lat,
+			Lon:       milestone.Lon,
+		})
+
+		// The recorder already marks takeoff and touchdown on the track
+		if milestone.Kind != acars.MilestoneTakeoff && milestone.Kind != acars.MilestoneTouchdown {
+			service.Recorder.AddEvent(track.Event{
+				Time: milestone.Time,
+				Kind: track.EventLog,
+				Text: milestone.Text,
+				Lat:  milestone.Lat,
+				Lon:  milestone.Lon,
+			})
+		}
+	}
+}
+
+// queueACARS hands an entry to the outbox, or posts it straight away if
+// there isn't one.
+func (service *FlightService) queueACARS(entry acars.Entry) {
+	if service.Outbox != nil {
+		service.Outbox.Enqueue(entry)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var err error
+	createdAt := entry.Time.UTC().Format(time.RFC3339)
+	if entry.Kind == acars.EntryEvent {
+		err = service.Client.PostACARSEvents(ctx, entry.PirepID, api.ACARSEvent{Event: entry.Text, Lat: entry.Lat, Lon: entry.Lon, CreatedAt: createdAt})
+	} else {
+		err = service.Client.PostACARSLogs(ctx, entry.PirepID, api.ACARSLog{Log: entry.Text, Lat: entry.Lat, Lon: entry.Lon, CreatedAt: createdAt})
+	}
+	if err != nil {
+		service.Logger.Warn("Failed to post ACARS entry", "pirep_id", entry.PirepID, "text", entry.Text, "error", err)
+	}
+}
+
+// resumeMilestones restarts milestone detection for a resumed PIREP without
+// repeating the ones already logged.
+func (service *FlightService) resumeMilestones(pirepID string) {
+	service.Milestones.Reset()
+	if service.Outbox == nil {
+		return
+	}
+
+	var kinds []acars.MilestoneKind
+	for _, entry := range service.Outbox.Entries(pirepID) {
+		if entry.Milestone != "" {
+			kinds = append(kinds, entry.Milestone)
+		}
+	}
+	service.Milestones.Seed(kinds)
+}
+
+// flushACARS tries to send anything still queued for the PIREP, so the flight
+// log is complete before it is filed.
+func (service *FlightService) flushACARS(ctx context.Context) {
+	if service.Outbox != nil {
+		service.Outbox.Flush(ctx)
+	}
+}
+
+// GetFlightLog returns the ACARS log entries for the active PIREP, or for the
+// last one if none is active.
+func (service *FlightService) GetFlightLog() []acars.Entry {
+	if service.Outbox == nil {
+		return nil
+	}
+
+	pirepID := service.lastPirepID.Load()
+	if id := service.ActivePirepID.Load(); id != nil {
+		pirepID = id
+	}
+	if pirepID == nil {
+		return nil
+	}
+	return service.Outbox.Entries(*pirepID)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// DefaultSourceName is the PIREP source name most phpVMS sites expect from an
+// ACARS client.
+const DefaultSourceName = "vmsacars"
+
+// DefaultSimulator is sent when the simulator isn't configured and the bridge
+// hasn't said which it is.
+const DefaultSimulator = "X-Plane 12"
+
+// SimulatorName is the configured simulator, or the one the bridge reports.
+func SimulatorName(configured string, detected *udp.Simulator) string {
+	switch {
+	case configured != "":
+		return configured
+	case detected != nil:
+		return detected.String()
+	default:
+		return DefaultSimulator
+	}
+}
+
+// DraftFiling proposes filing values for the active PIREP from the recorded
+// track and the score so far. The caller adds what only it knows, like fuel
+// used and the OFP weights, before the pilot reviews it.
+func (service *FlightService) DraftFiling() (api.FilePIREPRequest, error) {
+	if service.ActivePirepID.Load() == nil {
+		return api.FilePIREPRequest{}, fmt.Errorf("no active PIREP to file")
+	}
+
+	data := api.FilePIREPRequest{SourceName: service.SourceName}
+	if service.scored() {
+		score := service.Rules.Score()
+		data.Score = &score
+	}
+
+	flightTrack := service.Recorder.Track()
+	if rate, ok := flightTrack.LandingRate(); ok {
+		data.LandingRate = &rate
+	}
+	blockOff, blockOn := flightTrack.BlockTimes()
+	if !blockOff.IsZero() {
+		data.BlockOffTime = blockOff.UTC().Format(time.RFC3339)
+	}
+	if !blockOn.IsZero() {
+		data.BlockOnTime = blockOn.UTC().Format(time.RFC3339)
+	}
+	if !blockOff.IsZero() && !blockOn.IsZero() {
+		data.FlightTime = int(blockOn.Sub(blockOff).Minutes())
+	}
+	return data, nil
+}
+
+// GetFares returns the fares of the subfleet an aircraft belongs to.
+func (service *FlightService) GetFares(ctx context.Context, aircraftID int) ([]models.Fare, error) {
+	response, err := service.Client.GetUserFleet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subfleet := range response.Data {
+		for _, aircraft := range subfleet.Aircraft {
+			if aircraft.ID == aircraftID {
+				return subfleet.Fares, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("aircraft %d not in fleet", aircraftID)
+}
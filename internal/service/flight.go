@@ -2,21 +2,39 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/julietrb1/phpvms-xplane/internal/acars"
+	"github.com/julietrb1/phpvms-xplane/internal/airports"
 	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
 	"github.com/julietrb1/phpvms-xplane/internal/udp"
 	"github.com/julietrb1/phpvms-xplane/models"
 	"log/slog"
 	"math"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
+var ErrNoInProgressPIREP = errors.New("no in-progress PIREP")
+
 type FlightService struct {
 	Client        *api.Client
 	Logger        *slog.Logger
 	StateMachine  *StateMachine
+	Recorder      *track.Recorder
+	Logbook       *logbook.Store
+	Rules         *rules.Engine
+	Milestones    *acars.Detector
+	Outbox        *acars.Outbox
+	Airports      *airports.Cache
+	SourceName    string
 	ActivePirepID atomic.Pointer[string]
+	lastPirepID   atomic.Pointer[string]
+	fleet         atomic.Pointer[[]models.AircraftFleet]
 	InitialFuel   int
 	fuelMutex     sync.Mutex
 }
@@ -30,6 +48,11 @@ func NewFlightService(client *api.Client, logger *slog.Logger) *FlightService {
 		Client:       client,
 		Logger:       logger,
 		StateMachine: NewStateMachine(),
+		Recorder:     track.NewRecorder(nil, logger),
+		Rules:        rules.NewEngine(nil),
+		Milestones:   acars.NewDetector(),
+		Airports:     airports.NewCache("", client, logger),
+		SourceName:   DefaultSourceName,
 	}
 	s.ActivePirepID.Store(nil)
 	return s
@@ -50,10 +73,56 @@ func (service *FlightService) Prefile(ctx context.Context, flightData api.Prefil
 
 	service.SetActivePirepID(result.Data.ID)
 	service.StateMachine.SetState(PIREPStateInProgress)
+	meta := track.Meta{
+		PirepID:      result.Data.ID,
+		FlightNumber: flightData.FlightNumber,
+		Departure:    flightData.DepartureAirportID,
+		Arrival:      flightData.ArrivalAirportID,
+	}
+	if callsign, ok := flightData.Fields["Network Callsign Used"].(string); ok {
+		meta.Callsign = callsign
+	}
+	if aircraft, err := service.findAircraft(ctx, flightData.AircraftID); err == nil {
+		meta.Registration = aircraft.Registration
+		meta.AircraftType = aircraft.ICAO
+		meta.AircraftName = aircraft.Name
+	} else {
+		service.Logger.Warn("Failed to look up prefiled aircraft", "aircraft_id", flightData.AircraftID, "error", err)
+	}
+	service.Recorder.Start(meta)
+	service.Rules.Reset()
+	service.Milestones.Reset()
+	service.logPrefile(result.Data.ID, flightData, meta)
 
 	return &result.Data.ID, nil
 }
 
+func (service *FlightService) ResumePIREP(pirep models.ListedPIREP) {
+	service.SetActivePirepID(pirep.ID)
+	service.StateMachine.SetState(PIREPStateInProgress)
+	service.Recorder.Start(track.Meta{
+		PirepID:      pirep.ID,
+		FlightNumber: pirep.FlightNumber,
+		Departure:    pirep.DptAirportID,
+		Arrival:      pirep.ArrAirportID,
+		Registration: pirep.Aircraft.Registration,
+		AircraftType: pirep.Aircraft.Icao,
+		AircraftName: pirep.Aircraft.Name,
+	})
+	service.restoreViolations()
+	service.resumeMilestones(pirep.ID)
+	service.logResume(pirep)
+}
+
+// ActivatePIREP makes pirep the active PIREP for a one-shot command, such as
+// filing or cancelling from the command line. Unlike ResumePIREP, it doesn't
+// record a track, seed milestones or add a logbook entry for a flight this
+// process isn't watching.
+func (service *FlightService) ActivatePIREP(pirep models.ListedPIREP) {
+	service.SetActivePirepID(pirep.ID)
+	service.StateMachine.SetState(PIREPStateInProgress)
+}
+
 func (service *FlightService) UpdateFlight(ctx context.Context, status string, distance int, fuelRemainingKG int, flightTimeMin int) error {
 	pirepID := service.ActivePirepID.Load()
 	if pirepID == nil {
@@ -64,7 +133,7 @@ func (service *FlightService) UpdateFlight(ctx context.Context, status string, d
 	if !service.StateMachine.CanUpdate() {
 		service.Logger.Warn("PIREP is in read-only state, skipping update",
 			"pirep_id", pirepID,
-			"state", service.StateMachine.CurrentState.String())
+			"state", service.StateMachine.Get().String())
 		return nil
 	}
 
@@ -105,7 +174,7 @@ func (service *FlightService) SendPosition(ctx context.Context, pos udp.Position
 	if !service.StateMachine.CanUpdate() {
 		service.Logger.Warn("PIREP is in read-only state, skipping position update",
 			"pirep_id", pirepID,
-			"state", service.StateMachine.CurrentState.String())
+			"state", service.StateMachine.Get().String())
 		return nil
 	}
 
@@ -113,17 +182,22 @@ func (service *FlightService) SendPosition(ctx context.Context, pos udp.Position
 		return fmt.Errorf("invalid position coordinates: lat=%f, lon=%f", pos.Lat, pos.Lon)
 	}
 
+	simTime := time.Now().UTC()
+	if pos.SimTime != nil {
+		simTime = pos.SimTime.Time()
+	}
+
 	data := api.PositionUpdateRequest{
 		Lat:        pos.Lat,
 		Lon:        pos.Lon,
-		AltMSL:     pos.AltMSL,
-		AltAGL:     pos.AltAGL,
-		GS:         pos.GS,
-		SimTime:    pos.SimTime,
-		DistanceNM: pos.DistanceNM,
-		Heading:    pos.Heading,
-		IAS:        pos.IAS,
-		VSFPM:      pos.VSFPM,
+		AltMSL:     udp.Int(pos.AltMSL),
+		AltAGL:     udp.Int(pos.AltAGL),
+		GS:         udp.Int(pos.GS),
+		SimTime:    simTime.Format(time.RFC3339),
+		DistanceNM: udp.Int(pos.DistanceNM),
+		Heading:    udp.Int(pos.Heading),
+		IAS:        udp.Int(pos.IAS),
+		VSFPM:      udp.Int(pos.VSFPM),
 	}
 
 	if err := service.Client.PostACARSPosition(ctx, *pirepID, data); err != nil {
@@ -139,14 +213,18 @@ func (service *FlightService) FileFlight(ctx context.Context, data api.FilePIREP
 	}
 
 	if !service.StateMachine.CanFile() {
-		return fmt.Errorf("PIREP cannot be filed in current state: %s", service.StateMachine.CurrentState.String())
+		return fmt.Errorf("PIREP cannot be filed in current state: %s", service.StateMachine.Get().String())
 	}
 
+	service.applyScore(*pirepID, &data)
+	service.flushACARS(ctx)
+
 	if err := service.Client.FilePIREP(ctx, *pirepID, data); err != nil {
 		return fmt.Errorf("failed to file PIREP: %w", err)
 	}
 
 	service.StateMachine.SetState(PIREPStatePending)
+	service.logFiled(*pirepID, data, service.Recorder.Track())
 	service.ResetActivePirep()
 
 	return nil
@@ -159,7 +237,7 @@ func (service *FlightService) CancelFlight(ctx context.Context) error {
 	}
 
 	if !service.StateMachine.CanCancel() {
-		return fmt.Errorf("PIREP cannot be cancelled in current state: %s", service.StateMachine.CurrentState.String())
+		return fmt.Errorf("PIREP cannot be cancelled in current state: %s", service.StateMachine.Get().String())
 	}
 
 	if err := service.Client.CancelPIREP(ctx, *pirepID); err != nil {
@@ -167,17 +245,38 @@ func (service *FlightService) CancelFlight(ctx context.Context) error {
 	}
 
 	service.StateMachine.SetState(PIREPStateCancelled)
+	service.logState(*pirepID, PIREPStateCancelled)
 
 	service.ActivePirepID.Store(nil)
 	service.fuelMutex.Lock()
 	service.InitialFuel = 0
 	service.fuelMutex.Unlock()
+	service.Recorder.Stop()
+
+	return nil
+}
+
+// CancelPIREP cancels any of the pilot's PIREPs, e.g. one left in progress by
+// an earlier flight. The active PIREP is cancelled as with CancelFlight.
+func (service *FlightService) CancelPIREP(ctx context.Context, pirep models.ListedPIREP) error {
+	if active := service.ActivePirepID.Load(); active != nil && *active == pirep.ID {
+		return service.CancelFlight(ctx)
+	}
+
+	if !models.PirepState(pirep.State).CanCancel() {
+		return fmt.Errorf("PIREP cannot be cancelled in current state: %s", models.PirepState(pirep.State).String())
+	}
 
+	if err := service.Client.CancelPIREP(ctx, pirep.ID); err != nil {
+		return fmt.Errorf("failed to cancel PIREP: %w", err)
+	}
+	service.logState(pirep.ID, PIREPStateCancelled)
 	return nil
 }
 
 func (service *FlightService) SetActivePirepID(id string) {
 	service.ActivePirepID.Store(&id)
+	service.lastPirepID.Store(&id)
 }
 
 func (service *FlightService) ResetActivePirep() {
@@ -186,6 +285,15 @@ func (service *FlightService) ResetActivePirep() {
 	service.InitialFuel = 0
 	service.fuelMutex.Unlock()
 	service.StateMachine.SetState(PIREPStateInProgress)
+	service.Recorder.Stop()
+}
+
+// GetInitialFuel returns the fuel on board (kg) when the first update for the
+// active PIREP was sent, or 0 if there hasn't been one.
+func (service *FlightService) GetInitialFuel() int {
+	service.fuelMutex.Lock()
+	defer service.fuelMutex.Unlock()
+	return service.InitialFuel
 }
 
 func (service *FlightService) GetAPIClient() *api.Client {
@@ -203,12 +311,104 @@ func (service *FlightService) HandlePayload(ctx context.Context, payload *udp.Pa
 		return noPirepIDErr, noPirepIDErr
 	}
 
-	updateFlightsErr := service.UpdateFlight(ctx, payload.Status, payload.Position.DistanceNM, payload.Fuel, payload.FlightTime)
-	updatePositionErr := service.SendPosition(ctx, payload.Position)
+	service.recordPayload(*pirepID, payload)
+
+	var distance int
+	if payload.Position != nil {
+		distance = udp.Int(payload.Position.DistanceNM)
+	}
+	updateFlightsErr := service.UpdateFlight(ctx, payload.Status, distance, udp.Int(payload.Fuel), udp.Int(payload.FlightTime))
+
+	var updatePositionErr error
+	if payload.Position != nil {
+		updatePositionErr = service.SendPosition(ctx, *payload.Position)
+	} else {
+		updatePositionErr = fmt.Errorf("no position in payload")
+	}
 
 	return updateFlightsErr, updatePositionErr
 }
 
+func (service *FlightService) recordPayload(pirepID string, payload *udp.Payload) {
+	if payload.Position == nil {
+		return
+	}
+	pos := payload.Position
+	if !isValidLatitude(pos.Lat) || !isValidLongitude(pos.Lon) {
+		return
+	}
+
+	// Older bridge scripts don't send on_ground, so fall back to radio altitude
+	onGround := udp.Float(pos.AltAGL) < 2
+	if payload.OnGround != nil {
+		onGround = *payload.OnGround
+	}
+
+	now := time.Now().UTC()
+	point := track.Point{
+		Time:     now,
+		Lat:      pos.Lat,
+		Lon:      pos.Lon,
+		AltMSL:   udp.Float(pos.AltMSL),
+		AltAGL:   udp.Float(pos.AltAGL),
+		GS:       udp.Float(pos.GS),
+		IAS:      udp.Float(pos.IAS),
+		VS:       udp.Float(pos.VSFPM),
+		Heading:  udp.Float(pos.Heading),
+		Phase:    payload.Status,
+		OnGround: onGround,
+		Fuel:     udp.Float(payload.Fuel),
+		Distance: udp.Float(pos.DistanceNM),
+	}
+	events := service.Recorder.Record(point)
+	service.evaluateRules(pirepID, payload, point, events)
+	service.detectMilestones(pirepID, payload, point, events)
+
+	for _, event := range payload.Events {
+		eventTime := now
+		if event.SimTime != nil {
+			eventTime = event.SimTime.Time()
+		}
+		service.Recorder.AddEvent(track.Event{
+			Time: eventTime,
+			Kind: track.EventLog,
+			Text: event.Log,
+			Lat:  pos.Lat,
+			Lon:  pos.Lon,
+		})
+		service.queueACARS(acars.Entry{
+			PirepID: pirepID,
+			Kind:    acars.EntryLog,
+			Text:    event.Log,
+			Time:    eventTime,
+			Lat:     pos.Lat,
+			Lon:     pos.Lon,
+		})
+	}
+}
+
+func (service *FlightService) GetTrack() *track.Track {
+	return service.Recorder.Track()
+}
+
+// LastTrack returns the track being recorded or, failing that, the most
+// recently recorded one on disk.
+func (service *FlightService) LastTrack() (*track.Track, error) {
+	if current := service.Recorder.Track(); !current.Empty() {
+		return current, nil
+	}
+
+	if service.Recorder.Store == nil {
+		return nil, fmt.Errorf("no track recorded")
+	}
+
+	pirepID, err := service.Recorder.Store.Latest()
+	if err != nil {
+		return nil, err
+	}
+	return service.Recorder.Store.Load(pirepID)
+}
+
 func (service *FlightService) GetAirlines(ctx context.Context) ([]models.Airline, error) {
 	airlines, err := service.Client.GetAirlines(ctx)
 	if err != nil {
@@ -219,36 +419,95 @@ func (service *FlightService) GetAirlines(ctx context.Context) ([]models.Airline
 	return airlines.Data, nil
 }
 
-func (service *FlightService) ListPIREPs(ctx context.Context) ([]models.ListedPIREP, error) {
+// GetPIREPs returns the pilot's PIREPs in any state, newest first.
+func (service *FlightService) GetPIREPs(ctx context.Context) ([]models.ListedPIREP, error) {
 	response, err := service.Client.ListPIREPs(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(response.Data) == 0 {
-		return nil, fmt.Errorf("no active PIREPs found")
+	// TODO: Implement pagination
+	return response.Data, nil
+}
+
+// FindInProgressPIREP returns the in-progress or paused PIREP with the given
+// ID, or the latest one if id is empty.
+func (service *FlightService) FindInProgressPIREP(ctx context.Context, id string) (models.ListedPIREP, error) {
+	pireps, err := service.GetPIREPs(ctx)
+	if err != nil {
+		return models.ListedPIREP{}, err
 	}
 
-	if response.Data[0].State != int(models.PIREPStateInProgress) {
-		return nil, fmt.Errorf("latest PIREP not in progress")
+	for _, pirep := range pireps {
+		if !models.PirepState(pirep.State).CanResume() {
+			continue
+		}
+		if id == "" || pirep.ID == id {
+			return pirep, nil
+		}
 	}
+	if id != "" {
+		return models.ListedPIREP{}, fmt.Errorf("%w with ID %s", ErrNoInProgressPIREP, id)
+	}
+	return models.ListedPIREP{}, ErrNoInProgressPIREP
+}
 
-	// TODO: Implement pagination
+func (service *FlightService) GetFleet(ctx context.Context) ([]models.AircraftFleet, error) {
+	response, err := service.Client.GetUserFleet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	service.fleet.Store(&response.Data)
 	return response.Data, nil
 }
 
+// GetUserAircraftList returns the aircraft in every subfleet the pilot can
+// fly.
 func (service *FlightService) GetUserAircraftList(ctx context.Context) ([]models.Aircraft, error) {
-	aircraftResponse, err := service.Client.GetUserFleet(ctx)
+	fleet, err := service.GetFleet(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	if len(aircraftResponse.Data) == 0 {
+	var aircraftList []models.Aircraft
+	for _, subfleet := range fleet {
+		aircraftList = append(aircraftList, subfleet.Aircraft...)
+	}
+	if len(aircraftList) == 0 {
 		return nil, fmt.Errorf("no aircraft found")
 	}
+	return aircraftList, nil
+}
 
-	// TODO: Implement pagination
-	return aircraftResponse.Data[0].Aircraft, nil
+// findAircraft looks the aircraft up in the fleet last fetched by GetFleet,
+// which the pilot picked it from, and only fetches the fleet again if it
+// isn't there.
+func (service *FlightService) findAircraft(ctx context.Context, id int) (*models.Aircraft, error) {
+	if fleet := service.fleet.Load(); fleet != nil {
+		if aircraft := aircraftInFleet(*fleet, id); aircraft != nil {
+			return aircraft, nil
+		}
+	}
+
+	fleet, err := service.GetFleet(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if aircraft := aircraftInFleet(fleet, id); aircraft != nil {
+		return aircraft, nil
+	}
+	return nil, fmt.Errorf("aircraft %d not in fleet", id)
+}
+
+func aircraftInFleet(fleet []models.AircraftFleet, id int) *models.Aircraft {
+	for _, subfleet := range fleet {
+		for _, aircraft := range subfleet.Aircraft {
+			if aircraft.ID == id {
+				return &aircraft
+			}
+		}
+	}
+	return nil
 }
 
 func isValidLatitude(lat float64) bool {
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/julietrb1/phpvms-xplane/internal/acars"
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// fakePhpVMS serves the parts of the phpVMS API a flight uses, and keeps what
+// was filed and logged.
+type fakePhpVMS struct {
+	mutex sync.Mutex
+	filed *api.FilePIREPRequest
+	logs  []string
+}
+
+func (f *fakePhpVMS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch r.URL.Path {
+	case "/api/pireps/prefile":
+		w.Write([]byte(`{"data": {"id": "abc"}}`))
+	case "/api/user/fleet":
+		w.Write([]byte(`{"data": [{"id": 1, "type": "A320", "aircraft": [{"id": 7, "registration": "VH-VQA", "icao": "A320", "name": "Airbus A320"}]}]}`))
+	case "/api/pireps/abc/file":
+		var filed api.FilePIREPRequest
+		if err := json.NewDecoder(r.Body).Decode(&filed); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.filed = &filed
+		w.Write([]byte(`{}`))
+	case "/api/pireps/abc/acars/logs":
+		var body struct {
+			Logs []api.ACARSLog `json:"logs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, log := range body.Logs {
+			f.logs = append(f.logs, log.Log)
+		}
+		w.Write([]byte(`{}`))
+	case "/api/pireps/abc", "/api/pireps/abc/acars/position", "/api/pireps/abc/acars/events":
+		w.Write([]byte(`{}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// newTestService sets up a flight service keeping its track, logbook and
+// outbox in dir, as PXP does in its data directory.
+func newTestService(baseURL, dir string) *FlightService {
+	client := api.NewClient(baseURL, secret.New("key"), nil)
+	service := NewFlightService(client, nil)
+	service.Recorder = track.NewRecorder(track.NewStore(filepath.Join(dir, "tracks")), nil)
+	service.Logbook = logbook.NewStore(filepath.Join(dir, "logbook.db"))
+	service.Outbox = acars.NewOutbox(filepath.Join(dir, "acars-outbox.json"), client, nil)
+	service.Rules = rules.NewEngine(&rules.RuleSet{Rules: []rules.Rule{{
+		ID:          "overspeed",
+		Description: "IAS above 250 kt",
+		When:        []rules.Condition{{Metric: rules.MetricIAS, Op: ">", Value: 250}},
+		Points:      5,
+	}}})
+	return service
+}
+
+func TestPrefileResumeFile(t *testing.T) {
+	phpVMS := &fakePhpVMS{}
+	server := httptest.NewServer(phpVMS)
+	defer server.Close()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	first := newTestService(server.URL, dir)
+	pirepID, err := first.Prefile(ctx, api.PrefilePIREPRequest{
+		AirlineID:          1,
+		AircraftID:         7,
+		FlightNumber:       "QF1",
+		DepartureAirportID: "YSSY",
+		ArrivalAirportID:   "YMML",
+	})
+	if err != nil || *pirepID != "abc" {
+		t.Fatalf("Expected abc to be prefiled, got %v (%v)", pirepID, err)
+	}
+
+	onGround := false
+	first.HandlePayload(ctx, &udp.Payload{
+		Status:   "ENR",
+		OnGround: &onGround,
+		Position: &udp.Position{Lat: -34, Lon: 151, AltMSL: floatPtr(8000.0), AltAGL: floatPtr(7900.0), IAS: floatPtr(280.0)},
+	})
+	if score, _ := first.GetScore(); score != 95 {
+		t.Fatalf("Expected a score of 95 after the overspeed, got %d", score)
+	}
+
+	// PXP restarts mid-flight
+	first.Outbox.Flush(ctx)
+	first.Recorder.Stop()
+	second := newTestService(server.URL, dir)
+	second.ResumePIREP(models.ListedPIREP{ID: "abc", FlightNumber: "QF1", DptAirportID: "YSSY", ArrAirportID: "YMML"})
+	if score, violations := second.GetScore(); score != 95 || len(violations) != 1 {
+		t.Fatalf("Expected the violation to survive a restart, got %d, %+v", score, violations)
+	}
+
+	if err := second.FileFlight(ctx, api.FilePIREPRequest{FlightTime: 80, Distance: 383}); err != nil {
+		t.Fatalf("FileFlight() error = %v", err)
+	}
+
+	filed := phpVMS.filed
+	if filed == nil {
+		t.Fatal("Expected the PIREP to be filed")
+	}
+	if filed.Score == nil || *filed.Score != 95 {
+		t.Errorf("Expected a filed score of 95, got %v", filed.Score)
+	}
+	if filed.Fields["Score"] != "95/100" {
+		t.Errorf("Expected the Score field 95/100, got %v", filed.Fields["Score"])
+	}
+	if filed.Fields["Violations"] != "IAS above 250 kt (-5)" {
+		t.Errorf("Expected the overspeed in the Violations field, got %v", filed.Fields["Violations"])
+	}
+
+	entry, err := second.Logbook.Get("abc")
+	if err != nil {
+		t.Fatalf("Logbook.Get() error = %v", err)
+	}
+	if entry.State != PIREPStatePending.String() || entry.FiledAt == nil {
+		t.Errorf("Expected the logbook entry to be filed, got %s, %v", entry.State, entry.FiledAt)
+	}
+	if entry.Score == nil || *entry.Score != 95 {
+		t.Errorf("Expected a logbook score of 95, got %v", entry.Score)
+	}
+	if entry.Registration != "VH-VQA" || entry.FlightTime != 80 || entry.Distance != 383 {
+		t.Errorf("Expected the prefiled aircraft and filed times, got %+v", entry)
+	}
+
+	var texts []string
+	for _, queued := range second.Outbox.Entries("abc") {
+		if !queued.Sent() {
+			t.Errorf("Expected %q to be sent before filing", queued.Text)
+		}
+		texts = append(texts, queued.Text)
+	}
+	want := []string{"Violation: IAS above 250 kt (-5)", "Flight score 95/100. IAS above 250 kt (-5)"}
+	for _, text := range want {
+		if !contains(texts, text) {
+			t.Errorf("Expected %q in the outbox, got %v", text, texts)
+		}
+		if !contains(phpVMS.logs, text) {
+			t.Errorf("Expected %q to be posted, got %v", text, phpVMS.logs)
+		}
+	}
+	if second.GetActivePirepID() != nil {
+		t.Errorf("Expected no active PIREP after filing")
+	}
+}
+
+func floatPtr(f float64) *float64 { return &f }
+
+func contains(texts []string, text string) bool {
+	for _, t := range texts {
+		if strings.Contains(t, text) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+const lbsPerKg = 2.20462
+
+func (service *FlightService) logPrefile(pirepID string, flightData api.PrefilePIREPRequest, meta track.Meta) {
+	if service.Logbook == nil {
+		return
+	}
+
+	entry := logbook.Entry{
+		PirepID:      pirepID,
+		FlightNumber: flightData.FlightNumber,
+		Callsign:     meta.Callsign,
+		AirlineID:    flightData.AirlineID,
+		Departure:    flightData.DepartureAirportID,
+		Arrival:      flightData.ArrivalAirportID,
+		Alternate:    flightData.AlternateAirportID,
+		Route:        flightData.Route,
+		AircraftID:   flightData.AircraftID,
+		Registration: meta.Registration,
+		AircraftType: meta.AircraftType,
+		PrefiledAt:   time.Now().UTC(),
+		BlockFuel:    float64(flightData.BlockFuel) / lbsPerKg,
+		State:        PIREPStateInProgress.String(),
+		TrackPath:    service.trackPath(pirepID),
+	}
+	if err := service.Logbook.Put(entry); err != nil {
+		service.Logger.Warn("Failed to add logbook entry", "pirep_id", pirepID, "error", err)
+	}
+}
+
+// logResume adds a logbook entry for a PIREP prefiled elsewhere or before
+// the logbook existed. Existing entries are left alone.
+func (service *FlightService) logResume(pirep models.ListedPIREP) {
+	if service.Logbook == nil {
+		return
+	}
+
+	if _, err := service.Logbook.Get(pirep.ID); err == nil {
+		return
+	} else if !errors.Is(err, logbook.ErrNotFound) {
+		service.Logger.Warn("Failed to read logbook entry", "pirep_id", pirep.ID, "error", err)
+		return
+	}
+
+	entry := logbook.Entry{
+		PirepID:      pirep.ID,
+		FlightNumber: pirep.FlightNumber,
+		AirlineID:    pirep.AirlineID,
+		Departure:    pirep.DptAirportID,
+		Arrival:      pirep.ArrAirportID,
+		Route:        pirep.Route,
+		AircraftID:   pirep.AircraftID,
+		Registration: pirep.Aircraft.Registration,
+		AircraftType: pirep.Aircraft.Icao,
+		PrefiledAt:   pirep.CreatedAt,
+		BlockFuel:    pirep.BlockFuel.Kg,
+		State:        PIREPStateInProgress.String(),
+		TrackPath:    service.trackPath(pirep.ID),
+	}
+	if pirep.AltAirportID != nil {
+		entry.Alternate = *pirep.AltAirportID
+	}
+	if entry.PrefiledAt.IsZero() {
+		entry.PrefiledAt = time.Now().UTC()
+	}
+	if err := service.Logbook.Put(entry); err != nil {
+		service.Logger.Warn("Failed to add logbook entry", "pirep_id", pirep.ID, "error", err)
+	}
+}
+
+func (service *FlightService) logFiled(pirepID string, data api.FilePIREPRequest, flightTrack *track.Track) {
+	service.updateLogbook(pirepID, func(entry *logbook.Entry) {
+		now := time.Now().UTC()
+		entry.FiledAt = &now
+		entry.State = PIREPStatePending.String()
+		entry.FlightTime = data.FlightTime
+		entry.FuelUsed = float64(data.FuelUsedLbs) / lbsPerKg
+		entry.Distance = float64(data.Distance)
+		entry.Score = data.Score
+		entry.Notes = data.Notes
+
+		// Prefer what the pilot filed over what the track recorded
+		entry.LandingRate = data.LandingRate
+		if rate, ok := flightTrack.LandingRate(); ok && entry.LandingRate == nil {
+			entry.LandingRate = &rate
+		}
+		blockOff, blockOn := flightTrack.BlockTimes()
+		entry.BlockOff = timePtr(parseTime(data.BlockOffTime, blockOff))
+		entry.BlockOn = timePtr(parseTime(data.BlockOnTime, blockOn))
+		if takeoff := flightTrack.FirstEvent(track.EventTakeoff); takeoff != nil {
+			entry.Takeoff = timePtr(takeoff.Time)
+		}
+		if landing := flightTrack.LastEvent(track.EventTouchdown); landing != nil {
+			entry.Landing = timePtr(landing.Time)
+		}
+	})
+}
+
+func (service *FlightService) logState(pirepID string, state PirepState) {
+	service.updateLogbook(pirepID, func(entry *logbook.Entry) {
+		entry.State = state.String()
+	})
+}
+
+func (service *FlightService) updateLogbook(pirepID string, fn func(*logbook.Entry)) {
+	if service.Logbook == nil {
+		return
+	}
+	// A PIREP filed or cancelled from the command line may never have been
+	// added to the logbook
+	if err := service.Logbook.Update(pirepID, fn); err != nil && !errors.Is(err, logbook.ErrNotFound) {
+		service.Logger.Warn("Failed to update logbook entry", "pirep_id", pirepID, "error", err)
+	}
+}
+
+func (service *FlightService) trackPath(pirepID string) string {
+	if service.Recorder.Store == nil {
+		return ""
+	}
+	return service.Recorder.Store.Path(pirepID)
+}
+
+func (service *FlightService) GetLogbook() ([]logbook.Entry, error) {
+	if service.Logbook == nil {
+		return nil, fmt.Errorf("logbook is not enabled")
+	}
+	return service.Logbook.List()
+}
+
+// RefreshLogbook pulls the latest PIREP states from phpVMS, so entries pick
+// up whether filed flights were accepted or rejected.
+func (service *FlightService) RefreshLogbook(ctx context.Context) (int, error) {
+	if service.Logbook == nil {
+		return 0, fmt.Errorf("logbook is not enabled")
+	}
+
+	response, err := service.Client.ListPIREPs(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list PIREPs: %w", err)
+	}
+
+	var updated int
+	for _, pirep := range response.Data {
+		state := PirepState(pirep.State).String()
+		err := service.Logbook.Update(pirep.ID, func(entry *logbook.Entry) {
+			if entry.State != state {
+				updated++
+			}
+			entry.State = state
+			if pirep.LandingRate != nil && entry.LandingRate == nil {
+				rate := *pirep.LandingRate
+				entry.LandingRate = &rate
+			}
+		})
+		if err != nil && !errors.Is(err, logbook.ErrNotFound) {
+			return updated, err
+		}
+	}
+
+	service.Logger.Debug("Refreshed logbook", "pireps", len(response.Data), "updated", updated)
+	return updated, nil
+}
+
+// parseTime parses an RFC 3339 time, or returns fallback if value is empty
+// or invalid.
+func parseTime(value string, fallback time.Time) time.Time {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed
+	}
+	return fallback
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/acars"
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+func (service *FlightService) evaluateRules(pirepID string, payload *udp.Payload, point track.Point, events []track.Event) {
+	sample := rules.Sample{
+		Time:   point.Time,
+		Phase:  point.Phase,
+		Lat:    point.Lat,
+		Lon:    point.Lon,
+		Values: sampleValues(payload, point),
+	}
+	for _, event := range events {
+		if event.Kind == track.EventTouchdown {
+			sample.Values[rules.MetricLandingRate] = event.Value
+		}
+	}
+
+	violations := service.Rules.Evaluate(sample)
+	if len(violations) == 0 {
+		return
+	}
+
+	for _, violation := range violations {
+		service.Logger.Info("Rule violated", "rule", violation.RuleID, "points", violation.Points, "detail", violation.Detail)
+		service.Recorder.AddEvent(track.Event{
+			Time:  violation.Time,
+			Kind:  track.EventViolation,
+			Text:  violation.Description,
+			Lat:   violation.Lat,
+			Lon:   violation.Lon,
+			Value: float64(violation.Points),
+			Rule:  violation.RuleID,
+		})
+		service.queueACARS(acars.Entry{
+			PirepID: pirepID,
+			Kind:    acars.EntryLog,
+			Text:    "Violation: " + violation.String(),
+			Time:    violation.Time,
+			Lat:     violation.Lat,
+			Lon:     violation.Lon,
+		})
+	}
+}
+
+func sampleValues(payload *udp.Payload, point track.Point) map[rules.Metric]float64 {
+	values := map[rules.Metric]float64{
+		rules.MetricAltMSL:   point.AltMSL,
+		rules.MetricAltAGL:   point.AltAGL,
+		rules.MetricIAS:      point.IAS,
+		rules.MetricGS:       point.GS,
+		rules.MetricVS:       point.VS,
+		rules.MetricOnGround: boolMetric(point.OnGround),
+	}
+
+	if lights := payload.Lights; lights != nil {
+		setBoolMetric(values, rules.MetricBeaconLights, lights.Beacon)
+		setBoolMetric(values, rules.MetricNavLights, lights.Nav)
+		setBoolMetric(values, rules.MetricStrobeLights, lights.Strobe)
+		setBoolMetric(values, rules.MetricLandingLights, lights.Landing)
+		setBoolMetric(values, rules.MetricTaxiLights, lights.Taxi)
+	}
+	return values
+}
+
+func setBoolMetric(values map[rules.Metric]float64, metric rules.Metric, value *bool) {
+	if value != nil {
+		values[metric] = boolMetric(*value)
+	}
+}
+
+func boolMetric(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// restoreViolations reloads violations from a resumed track so a restart
+// doesn't wipe the score.
+func (service *FlightService) restoreViolations() {
+	service.Rules.Reset()
+
+	var violations []rules.Violation
+	for _, event := range service.Recorder.Events() {
+		if event.Kind != track.EventViolation {
+			continue
+		}
+		violations = append(violations, rules.Violation{
+			RuleID:      event.Rule,
+			Description: event.Text,
+			Time:        event.Time,
+			Points:      int(event.Value),
+			Lat:         event.Lat,
+			Lon:         event.Lon,
+		})
+	}
+	service.Rules.Restore(violations)
+}
+
+// scored reports whether the rules engine has evaluated this flight. A PIREP
+// PXP never watched, such as one filed headless or resumed without a track,
+// has no score rather than a perfect one.
+func (service *FlightService) scored() bool {
+	return !service.Recorder.Empty()
+}
+
+// applyScore attaches the score and violations to the PIREP being filed and
+// adds a summary to the ACARS log, if the flight was scored.
+func (service *FlightService) applyScore(pirepID string, data *api.FilePIREPRequest) {
+	if !service.scored() {
+		return
+	}
+
+	score := service.Rules.Score()
+	summary := service.Rules.Summary()
+
+	data.Score = &score
+	if data.Fields == nil {
+		data.Fields = map[string]interface{}{}
+	}
+	data.Fields["Score"] = fmt.Sprintf("%d/%d", score, rules.MaxScore)
+	data.Fields["Violations"] = summary
+
+	service.queueACARS(acars.Entry{
+		PirepID: pirepID,
+		Kind:    acars.EntryLog,
+		Text:    fmt.Sprintf("Flight score %d/%d. %s", score, rules.MaxScore, summary),
+		Time:    time.Now().UTC(),
+	})
+}
+
+func (service *FlightService) GetScore() (int, []rules.Violation) {
+	return service.Rules.Score(), service.Rules.Violations()
+}
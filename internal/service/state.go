@@ -1,6 +1,9 @@
 package service
 
-import "fmt"
+import (
+	"fmt"
+	"sync"
+)
 
 type PirepState int
 type PirepStatus string
@@ -70,34 +73,46 @@ func (s PirepState) CanFile() bool {
 	return s == PIREPStateInProgress || s == PIREPStateDraft || s == PIREPStatePaused
 }
 
+// StateMachine tracks the state of the active PIREP. It's read from the UDP,
+// control and web goroutines as well as the TUI, so the state is only
+// reached through Get and SetState.
 type StateMachine struct {
-	CurrentState PirepState
+	mutex sync.RWMutex
+	state PirepState
 }
 
 func NewStateMachine() *StateMachine {
 	return &StateMachine{
-		CurrentState: PIREPStateInProgress,
+		state: PIREPStateInProgress,
 	}
 }
 
+func (sm *StateMachine) Get() PirepState {
+	sm.mutex.RLock()
+	defer sm.mutex.RUnlock()
+	return sm.state
+}
+
 func (sm *StateMachine) SetState(state PirepState) {
-	sm.CurrentState = state
+	sm.mutex.Lock()
+	defer sm.mutex.Unlock()
+	sm.state = state
 }
 
 func (sm *StateMachine) CanUpdate() bool {
-	return sm.CurrentState.CanUpdate()
+	return sm.Get().CanUpdate()
 }
 
 func (sm *StateMachine) CanCancel() bool {
-	return sm.CurrentState.CanCancel()
+	return sm.Get().CanCancel()
 }
 
 func (sm *StateMachine) CanFile() bool {
-	return sm.CurrentState.CanFile()
+	return sm.Get().CanFile()
 }
 
 func (sm *StateMachine) IsReadOnly() bool {
-	return sm.CurrentState.IsReadOnly()
+	return sm.Get().IsReadOnly()
 }
 
 func ValidateStatus(status string) bool {
@@ -0,0 +1,147 @@
+package track
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The recorded aircraft is the only object in the file.
+const acmiObjectID = "1"
+
+type acmiEntry struct {
+	time  time.Time
+	point *Point
+	event *Event
+}
+
+// WriteACMI writes the track as a Tacview ACMI 2.1 text file. Tacview works
+// in metres and metres per second, so values are converted from the units
+// the bridge sends. ACMI has no ground speed property; Tacview derives it
+// from the recorded positions.
+func WriteACMI(w io.Writer, t *Track) error {
+	if t.Empty() {
+		return ErrEmpty
+	}
+	reference := t.Points[0].Time.UTC()
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprint(bw, "FileType=text/acmi/tacview\nFileVersion=2.1\n")
+	fmt.Fprintf(bw, "0,ReferenceTime=%s\n", reference.Format(time.RFC3339))
+	fmt.Fprintf(bw, "0,RecordingTime=%s\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(bw, "0,Title=%s\n", acmiEscape(t.title()))
+	fmt.Fprint(bw, "0,DataSource=X-Plane\n0,DataRecorder=PXP\n")
+	if t.Meta.Callsign != "" {
+		fmt.Fprintf(bw, "0,Author=%s\n", acmiEscape(t.Meta.Callsign))
+	}
+
+	lastOffset := -1.0
+	for i, entry := range acmiTimeline(t) {
+		if offset := entry.time.Sub(reference).Seconds(); offset != lastOffset {
+			fmt.Fprintf(bw, "#%.2f\n", offset)
+			lastOffset = offset
+		}
+
+		if entry.point != nil {
+			point := entry.point
+			fmt.Fprintf(bw, "%s,T=%.7f|%.7f|%.1f|||%.1f,IAS=%.1f,AGL=%.1f",
+				acmiObjectID,
+				point.Lon,
+				point.Lat,
+				feetToMetres(point.AltMSL),
+				point.Heading,
+				knotsToMetresPerSecond(point.IAS),
+				feetToMetres(point.AltAGL))
+			if i == 0 {
+				fmt.Fprint(bw, acmiObjectProperties(t.Meta))
+			}
+			fmt.Fprint(bw, "\n")
+			continue
+		}
+
+		event := entry.event
+		switch event.Kind {
+		case EventTakeoff:
+			fmt.Fprintf(bw, "0,Event=TakenOff|%s|%s\n", acmiObjectID, acmiEscape(event.Text))
+		case EventTouchdown:
+			fmt.Fprintf(bw, "0,Event=Landed|%s|%s\n", acmiObjectID, acmiEscape(event.Text))
+		case EventPhase:
+			fmt.Fprintf(bw, "0,Event=Bookmark|%s|%s\n", acmiObjectID, acmiEscape(eventLabel(*event)))
+		default:
+			fmt.Fprintf(bw, "0,Event=Message|%s|%s\n", acmiObjectID, acmiEscape(eventLabel(*event)))
+		}
+	}
+
+	return bw.Flush()
+}
+
+// WriteACMIZip writes the same content as WriteACMI wrapped in the zip
+// container Tacview opens as .zip.acmi.
+func WriteACMIZip(w io.Writer, t *Track) error {
+	if t.Empty() {
+		return ErrEmpty
+	}
+	archive := zip.NewWriter(w)
+	entry, err := archive.Create(t.Meta.PirepID + ".txt.acmi")
+	if err != nil {
+		return fmt.Errorf("failed to create ACMI archive entry: %w", err)
+	}
+	if err := WriteACMI(entry, t); err != nil {
+		return err
+	}
+	// Close writes the central directory, without which the archive is
+	// unreadable
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to write ACMI archive: %w", err)
+	}
+	return nil
+}
+
+func acmiObjectProperties(meta Meta) string {
+	properties := []string{"Type=Air+FixedWing"}
+	if meta.AircraftType != "" {
+		properties = append(properties, "Name="+acmiEscape(meta.AircraftType))
+	} else if meta.AircraftName != "" {
+		properties = append(properties, "Name="+acmiEscape(meta.AircraftName))
+	}
+	if meta.Callsign != "" {
+		properties = append(properties, "CallSign="+acmiEscape(meta.Callsign))
+	} else if meta.FlightNumber != "" {
+		properties = append(properties, "CallSign="+acmiEscape(meta.FlightNumber))
+	}
+	if meta.Registration != "" {
+		properties = append(properties, "Registration="+acmiEscape(meta.Registration))
+	}
+	return "," + strings.Join(properties, ",")
+}
+
+// acmiTimeline merges points and events in time order. Events recorded at
+// the same instant as a point are written after it, so the aircraft exists
+// before anything refers to it.
+func acmiTimeline(t *Track) []acmiEntry {
+	entries := make([]acmiEntry, 0, len(t.Points)+len(t.Events))
+	for i := range t.Points {
+		entries = append(entries, acmiEntry{time: t.Points[i].Time, point: &t.Points[i]})
+	}
+	for i := range t.Events {
+		if t.Events[i].Time.Before(t.Points[0].Time) {
+			continue
+		}
+		entries = append(entries, acmiEntry{time: t.Events[i].Time, event: &t.Events[i]})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].time.Before(entries[j].time)
+	})
+	return entries
+}
+
+func acmiEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	return strings.ReplaceAll(s, "\n", "\\\n")
+}
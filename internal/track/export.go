@@ -0,0 +1,90 @@
+package track
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/julietrb1/phpvms-xplane/internal/atomicfile"
+)
+
+type Format string
+
+const (
+	FormatGPX     Format = "gpx"
+	FormatKML     Format = "kml"
+	FormatGeoJSON Format = "geojson"
+	FormatACMI    Format = "acmi"
+	FormatACMIZip Format = "zip.acmi"
+)
+
+// Formats are the formats written when exporting "all".
+var Formats = []Format{FormatGPX, FormatKML, FormatGeoJSON, FormatACMI}
+
+var knownFormats = []Format{FormatGPX, FormatKML, FormatGeoJSON, FormatACMI, FormatACMIZip}
+
+func ParseFormat(s string) (Format, error) {
+	format := Format(strings.ToLower(s))
+	for _, known := range knownFormats {
+		if format == known {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown track format %q", s)
+}
+
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+func Write(w io.Writer, t *Track, format Format) error {
+	switch format {
+	case FormatGPX:
+		return WriteGPX(w, t)
+	case FormatKML:
+		return WriteKML(w, t)
+	case FormatGeoJSON:
+		return WriteGeoJSON(w, t)
+	case FormatACMI:
+		return WriteACMI(w, t)
+	case FormatACMIZip:
+		return WriteACMIZip(w, t)
+	default:
+		return fmt.Errorf("unknown track format %q", format)
+	}
+}
+
+// Export writes the track to dir as <PIREP ID>.<format> and returns the path.
+func Export(dir string, t *Track, format Format) (string, error) {
+	if t.Empty() {
+		return "", ErrEmpty
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	path := filepath.Join(dir, t.Meta.PirepID+format.Extension())
+	if err := atomicfile.Write(path, 0o644, func(w io.Writer) error {
+		return Write(w, t, format)
+	}); err != nil {
+		return "", fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	return path, nil
+}
+
+func (t *Track) title() string {
+	if t.Meta.Departure != "" && t.Meta.Arrival != "" {
+		if t.Meta.FlightNumber != "" {
+			return fmt.Sprintf("%s %s-%s", t.Meta.FlightNumber, t.Meta.Departure, t.Meta.Arrival)
+		}
+		return fmt.Sprintf("%s-%s", t.Meta.Departure, t.Meta.Arrival)
+	}
+	return fmt.Sprintf("PIREP %s", t.Meta.PirepID)
+}
@@ -0,0 +1,108 @@
+package track
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// WriteGeoJSON writes the track as a LineString feature. Per-point values go in
+// the "coordinateProperties" property, index-aligned with the coordinates,
+// which is the convention most GeoJSON tooling understands. Events become
+// Point features.
+func WriteGeoJSON(w io.Writer, t *Track) error {
+	if t.Empty() {
+		return ErrEmpty
+	}
+	count := len(t.Points)
+	coordinates := make([][]float64, count)
+	times := make([]string, count)
+	altitudeMSL := make([]float64, count)
+	altitudeAGL := make([]float64, count)
+	groundSpeed := make([]float64, count)
+	indicatedAirspeed := make([]float64, count)
+	verticalSpeed := make([]float64, count)
+	heading := make([]float64, count)
+	phase := make([]string, count)
+
+	for i, point := range t.Points {
+		coordinates[i] = []float64{point.Lon, point.Lat, feetToMetres(point.AltMSL)}
+		times[i] = point.Time.UTC().Format(time.RFC3339)
+		altitudeMSL[i] = point.AltMSL
+		altitudeAGL[i] = point.AltAGL
+		groundSpeed[i] = point.GS
+		indicatedAirspeed[i] = point.IAS
+		verticalSpeed[i] = point.VS
+		heading[i] = point.Heading
+		phase[i] = point.Phase
+	}
+
+	collection := geoJSONFeatureCollection{
+		Type: "FeatureCollection",
+		Features: []geoJSONFeature{
+			{
+				Type: "Feature",
+				Geometry: geoJSONGeometry{
+					Type:        "LineString",
+					Coordinates: coordinates,
+				},
+				Properties: map[string]interface{}{
+					"name":          t.title(),
+					"pirep_id":      t.Meta.PirepID,
+					"flight_number": t.Meta.FlightNumber,
+					"departure":     t.Meta.Departure,
+					"arrival":       t.Meta.Arrival,
+					"coordinateProperties": map[string]interface{}{
+						"time":         times,
+						"altitude_msl": altitudeMSL,
+						"altitude_agl": altitudeAGL,
+						"gs":           groundSpeed,
+						"ias":          indicatedAirspeed,
+						"vs":           verticalSpeed,
+						"heading":      heading,
+						"phase":        phase,
+					},
+				},
+			},
+		},
+	}
+
+	for _, event := range t.Events {
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: []float64{event.Lon, event.Lat},
+			},
+			Properties: map[string]interface{}{
+				"name": eventLabel(event),
+				"kind": event.Kind,
+				"time": event.Time.UTC().Format(time.RFC3339),
+			},
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(collection); err != nil {
+		return fmt.Errorf("failed to encode GeoJSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const gpxExtensionsNamespace = "https://github.com/julietrb1/phpvms-xplane/gpx/1"
+
+type gpxFile struct {
+	XMLName   xml.Name    `xml:"gpx"`
+	Version   string      `xml:"version,attr"`
+	Creator   string      `xml:"creator,attr"`
+	Namespace string      `xml:"xmlns,attr"`
+	PXP       string      `xml:"xmlns:pxp,attr"`
+	Metadata  gpxMetadata `xml:"metadata"`
+	Waypoints []gpxPoint  `xml:"wpt"`
+	Track     gpxTrack    `xml:"trk"`
+}
+
+type gpxMetadata struct {
+	Name string `xml:"name"`
+	Time string `xml:"time"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Type    string     `xml:"type"`
+	Segment gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Lat        float64        `xml:"lat,attr"`
+	Lon        float64        `xml:"lon,attr"`
+	Elevation  float64        `xml:"ele"`
+	Time       string         `xml:"time"`
+	Name       string         `xml:"name,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions,omitempty"`
+}
+
+type gpxExtensions struct {
+	AltAGL  float64 `xml:"pxp:agl"`
+	GS      float64 `xml:"pxp:gs"`
+	IAS     float64 `xml:"pxp:ias"`
+	VS      float64 `xml:"pxp:vs"`
+	Heading float64 `xml:"pxp:heading"`
+	Phase   string  `xml:"pxp:phase"`
+}
+
+// WriteGPX writes the track as GPX 1.1. Elevations are in metres as the
+// schema requires; speeds and phase go in pxp: extensions in their
+// original units.
+func WriteGPX(w io.Writer, t *Track) error {
+	if t.Empty() {
+		return ErrEmpty
+	}
+	file := gpxFile{
+		Version:   "1.1",
+		Creator:   "PXP",
+		Namespace: "http://www.topografix.com/GPX/1/1",
+		PXP:       gpxExtensionsNamespace,
+		Metadata: gpxMetadata{
+			Name: t.title(),
+			Time: t.Points[0].Time.UTC().Format(time.RFC3339),
+		},
+		Track: gpxTrack{
+			Name: t.title(),
+			Type: "flight",
+		},
+	}
+
+	for _, event := range t.Events {
+		file.Waypoints = append(file.Waypoints, gpxPoint{
+			Lat:  event.Lat,
+			Lon:  event.Lon,
+			Time: event.Time.UTC().Format(time.RFC3339),
+			Name: eventLabel(event),
+		})
+	}
+
+	for _, point := range t.Points {
+		file.Track.Segment.Points = append(file.Track.Segment.Points, gpxPoint{
+			Lat:       point.Lat,
+			Lon:       point.Lon,
+			Elevation: feetToMetres(point.AltMSL),
+			Time:      point.Time.UTC().Format(time.RFC3339),
+			Extensions: &gpxExtensions{
+				AltAGL:  point.AltAGL,
+				GS:      point.GS,
+				IAS:     point.IAS,
+				VS:      point.VS,
+				Heading: point.Heading,
+				Phase:   point.Phase,
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return fmt.Errorf("failed to encode GPX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func eventLabel(event Event) string {
+	switch event.Kind {
+	case EventPhase:
+		return "Phase: " + event.Text
+	case EventViolation:
+		return fmt.Sprintf("Violation: %s (-%.0f)", event.Text, event.Value)
+	default:
+		return event.Text
+	}
+}
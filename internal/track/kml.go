@@ -0,0 +1,120 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type kmlFile struct {
+	XMLName   xml.Name    `xml:"kml"`
+	Namespace string      `xml:"xmlns,attr"`
+	Document  kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Style      kmlStyle       `xml:"Style"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlStyle struct {
+	ID        string       `xml:"id,attr"`
+	LineStyle kmlLineStyle `xml:"LineStyle"`
+	PolyStyle kmlPolyStyle `xml:"PolyStyle"`
+}
+
+type kmlLineStyle struct {
+	Color string `xml:"color"`
+	Width int    `xml:"width"`
+}
+
+type kmlPolyStyle struct {
+	Color string `xml:"color"`
+}
+
+type kmlPlacemark struct {
+	Name        string         `xml:"name"`
+	Description string         `xml:"description,omitempty"`
+	TimeStamp   *kmlTimeStamp  `xml:"TimeStamp,omitempty"`
+	StyleURL    string         `xml:"styleUrl,omitempty"`
+	LineString  *kmlLineString `xml:"LineString,omitempty"`
+	Point       *kmlPoint      `xml:"Point,omitempty"`
+}
+
+type kmlTimeStamp struct {
+	When string `xml:"when"`
+}
+
+type kmlLineString struct {
+	Extrude      int    `xml:"extrude"`
+	Tessellate   int    `xml:"tessellate"`
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+type kmlPoint struct {
+	Coordinates string `xml:"coordinates"`
+}
+
+// WriteKML writes the track as an extruded, absolute-altitude line with a
+// placemark for each recorded event.
+func WriteKML(w io.Writer, t *Track) error {
+	if t.Empty() {
+		return ErrEmpty
+	}
+	var coordinates strings.Builder
+	for i, point := range t.Points {
+		if i > 0 {
+			coordinates.WriteByte(' ')
+		}
+		fmt.Fprintf(&coordinates, "%.6f,%.6f,%.1f", point.Lon, point.Lat, feetToMetres(point.AltMSL))
+	}
+
+	file := kmlFile{
+		Namespace: "http://www.opengis.net/kml/2.2",
+		Document: kmlDocument{
+			Name: t.title(),
+			Style: kmlStyle{
+				ID:        "flight",
+				LineStyle: kmlLineStyle{Color: "ff00a5ff", Width: 3},
+				PolyStyle: kmlPolyStyle{Color: "4000a5ff"},
+			},
+			Placemarks: []kmlPlacemark{
+				{
+					Name:     t.title(),
+					StyleURL: "#flight",
+					LineString: &kmlLineString{
+						Extrude:      1,
+						Tessellate:   1,
+						AltitudeMode: "absolute",
+						Coordinates:  coordinates.String(),
+					},
+				},
+			},
+		},
+	}
+
+	for _, event := range t.Events {
+		file.Document.Placemarks = append(file.Document.Placemarks, kmlPlacemark{
+			Name:      eventLabel(event),
+			TimeStamp: &kmlTimeStamp{When: event.Time.UTC().Format(time.RFC3339)},
+			Point: &kmlPoint{
+				Coordinates: fmt.Sprintf("%.6f,%.6f", event.Lon, event.Lat),
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(file); err != nil {
+		return fmt.Errorf("failed to encode KML: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
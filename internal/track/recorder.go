@@ -0,0 +1,264 @@
+package track
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+type Recorder struct {
+	Store  *Store
+	Logger *slog.Logger
+
+	mutex   sync.RWMutex
+	current *Track
+	file    *os.File
+}
+
+// NewRecorder creates a recorder that persists to store. A nil store keeps the
+// track in memory only.
+func NewRecorder(store *Store, logger *slog.Logger) *Recorder {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Recorder{
+		Store:  store,
+		Logger: logger,
+	}
+}
+
+// Start begins recording for a PIREP. If a track for the PIREP was already
+// recorded, it is loaded and recording continues where it left off.
+func (r *Recorder) Start(meta Meta) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.current != nil && r.current.Meta.PirepID == meta.PirepID {
+		r.mergeMeta(meta)
+		r.write(record{Meta: &r.current.Meta})
+		return
+	}
+
+	r.closeFile()
+
+	if meta.StartedAt.IsZero() {
+		meta.StartedAt = time.Now().UTC()
+	}
+	r.current = &Track{Meta: meta}
+
+	if r.Store == nil {
+		return
+	}
+
+	if existing, err := r.Store.Load(meta.PirepID); err == nil {
+		r.current.Points = existing.Points
+		r.current.Events = existing.Events
+		if !existing.Meta.StartedAt.IsZero() {
+			r.current.Meta = existing.Meta
+			r.mergeMeta(meta)
+		}
+	}
+
+	file, err := r.Store.openAppend(meta.PirepID)
+	if err != nil {
+		r.Logger.Warn("Failed to persist track, recording in memory only", "pirep_id", meta.PirepID, "error", err)
+		return
+	}
+	r.file = file
+	r.write(record{Meta: &r.current.Meta})
+}
+
+func (r *Recorder) Stop() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.closeFile()
+	r.current = nil
+}
+
+func (r *Recorder) Active() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.current != nil
+}
+
+// Meta returns the metadata of the track being recorded, and false if there
+// isn't one. Unlike Track, it doesn't copy the points.
+func (r *Recorder) Meta() (Meta, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.current == nil {
+		return Meta{}, false
+	}
+	return r.current.Meta, true
+}
+
+// Len returns how many points have been recorded.
+func (r *Recorder) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.current == nil {
+		return 0
+	}
+	return len(r.current.Points)
+}
+
+// Empty reports whether there's no track being recorded or it has no points.
+func (r *Recorder) Empty() bool {
+	return r.Len() == 0
+}
+
+// Events returns a copy of the recorded events, without the points.
+func (r *Recorder) Events() []Event {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.current == nil {
+		return nil
+	}
+	return append([]Event(nil), r.current.Events...)
+}
+
+// Record appends a point and returns any events it triggered, such as a phase
+// change or touchdown.
+func (r *Recorder) Record(point Point) []Event {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.current == nil {
+		return nil
+	}
+
+	eventCount := len(r.current.Events)
+
+	last := r.current.Last()
+	if last == nil || last.Phase != point.Phase {
+		r.addEvent(Event{
+			Time: point.Time,
+			Kind: EventPhase,
+			Text: point.Phase,
+			Lat:  point.Lat,
+			Lon:  point.Lon,
+		})
+	}
+
+	if last != nil && last.OnGround && !point.OnGround {
+		r.addEvent(Event{
+			Time: point.Time,
+			Kind: EventTakeoff,
+			Text: "Takeoff",
+			Lat:  point.Lat,
+			Lon:  point.Lon,
+		})
+	}
+
+	if last != nil && !last.OnGround && point.OnGround {
+		// The bridge samples about once a second, so the last airborne sample
+		// is a better estimate of the landing rate than the first on the ground.
+		rate := math.Min(last.VS, point.VS)
+		r.addEvent(Event{
+			Time:  point.Time,
+			Kind:  EventTouchdown,
+			Text:  fmt.Sprintf("Touchdown at %.0f fpm", rate),
+			Lat:   point.Lat,
+			Lon:   point.Lon,
+			Value: rate,
+		})
+	}
+
+	r.current.Points = append(r.current.Points, point)
+	r.write(record{Point: &point})
+
+	return append([]Event(nil), r.current.Events[eventCount:]...)
+}
+
+func (r *Recorder) AddEvent(event Event) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.current == nil {
+		return
+	}
+
+	r.addEvent(event)
+}
+
+func (r *Recorder) addEvent(event Event) {
+	r.current.Events = append(r.current.Events, event)
+	r.write(record{Event: &event})
+}
+
+// Track returns a copy of the track being recorded, or nil if none is. It
+// copies every point, so Meta, Len and Events are cheaper when that's all
+// that's needed.
+func (r *Recorder) Track() *Track {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if r.current == nil {
+		return nil
+	}
+
+	return &Track{
+		Meta:   r.current.Meta,
+		Points: append([]Point(nil), r.current.Points...),
+		Events: append([]Event(nil), r.current.Events...),
+	}
+}
+
+func (r *Recorder) mergeMeta(meta Meta) {
+	if meta.FlightNumber != "" {
+		r.current.Meta.FlightNumber = meta.FlightNumber
+	}
+	if meta.Departure != "" {
+		r.current.Meta.Departure = meta.Departure
+	}
+	if meta.Arrival != "" {
+		r.current.Meta.Arrival = meta.Arrival
+	}
+	if meta.Callsign != "" {
+		r.current.Meta.Callsign = meta.Callsign
+	}
+	if meta.Registration != "" {
+		r.current.Meta.Registration = meta.Registration
+	}
+	if meta.AircraftType != "" {
+		r.current.Meta.AircraftType = meta.AircraftType
+	}
+	if meta.AircraftName != "" {
+		r.current.Meta.AircraftName = meta.AircraftName
+	}
+}
+
+func (r *Recorder) write(rec record) {
+	if r.file == nil {
+		return
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		r.Logger.Warn("Failed to encode track record", "error", err)
+		return
+	}
+
+	if _, err := r.file.Write(append(data, '\n')); err != nil {
+		r.Logger.Warn("Failed to write track record", "error", err)
+	}
+}
+
+func (r *Recorder) closeFile() {
+	if r.file == nil {
+		return
+	}
+	if err := r.file.Close(); err != nil {
+		r.Logger.Warn("Failed to close track file", "error", err)
+	}
+	r.file = nil
+}
@@ -0,0 +1,166 @@
+package track
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const fileExtension = ".jsonl"
+
+// record is a single line of a recorded track file. Exactly one field is set;
+// a later meta line replaces an earlier one.
+type record struct {
+	Meta  *Meta  `json:"meta,omitempty"`
+	Point *Point `json:"point,omitempty"`
+	Event *Event `json:"event,omitempty"`
+}
+
+type Store struct {
+	Dir string
+}
+
+type Summary struct {
+	PirepID string
+	Path    string
+	Meta    Meta
+}
+
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+func (s *Store) Path(pirepID string) string {
+	return filepath.Join(s.Dir, pirepID+fileExtension)
+}
+
+func (s *Store) Load(pirepID string) (*Track, error) {
+	file, err := os.Open(s.Path(pirepID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open track: %w", err)
+	}
+	defer file.Close()
+
+	track := &Track{Meta: Meta{PirepID: pirepID}}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			// A crash mid-write can leave a truncated final line; keep what we have
+			continue
+		}
+
+		switch {
+		case rec.Meta != nil:
+			track.Meta = *rec.Meta
+		case rec.Point != nil:
+			track.Points = append(track.Points, *rec.Point)
+		case rec.Event != nil:
+			track.Events = append(track.Events, *rec.Event)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read track: %w", err)
+	}
+
+	return track, nil
+}
+
+// Latest returns the PIREP ID of the most recently modified track.
+func (s *Store) Latest() (string, error) {
+	summaries, err := s.List()
+	if err != nil {
+		return "", err
+	}
+	if len(summaries) == 0 {
+		return "", fmt.Errorf("no recorded tracks in %s", s.Dir)
+	}
+	return summaries[0].PirepID, nil
+}
+
+// List returns the recorded tracks, most recently modified first.
+func (s *Store) List() ([]Summary, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tracks: %w", err)
+	}
+
+	type entryInfo struct {
+		summary Summary
+		modTime int64
+	}
+
+	var infos []entryInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExtension) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		pirepID := strings.TrimSuffix(entry.Name(), fileExtension)
+		summary := Summary{
+			PirepID: pirepID,
+			Path:    filepath.Join(s.Dir, entry.Name()),
+			Meta:    s.readMeta(pirepID),
+		}
+		infos = append(infos, entryInfo{summary: summary, modTime: info.ModTime().UnixNano()})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].modTime > infos[j].modTime
+	})
+
+	summaries := make([]Summary, len(infos))
+	for i, info := range infos {
+		summaries[i] = info.summary
+	}
+	return summaries, nil
+}
+
+func (s *Store) readMeta(pirepID string) Meta {
+	meta := Meta{PirepID: pirepID}
+
+	file, err := os.Open(s.Path(pirepID))
+	if err != nil {
+		return meta
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err == nil && rec.Meta != nil {
+			meta = *rec.Meta
+		}
+	}
+	return meta
+}
+
+func (s *Store) openAppend(pirepID string) (*os.File, error) {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create track directory: %w", err)
+	}
+	file, err := os.OpenFile(s.Path(pirepID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open track for writing: %w", err)
+	}
+	return file, nil
+}
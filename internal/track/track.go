@@ -0,0 +1,164 @@
+package track
+
+import (
+	"errors"
+	"time"
+)
+
+type Meta struct {
+	PirepID      string    `json:"pirep_id"`
+	FlightNumber string    `json:"flight_number,omitempty"`
+	Callsign     string    `json:"callsign,omitempty"`
+	Departure    string    `json:"departure,omitempty"`
+	Arrival      string    `json:"arrival,omitempty"`
+	Registration string    `json:"registration,omitempty"`
+	AircraftType string    `json:"aircraft_type,omitempty"` // ICAO type designator
+	AircraftName string    `json:"aircraft_name,omitempty"`
+	StartedAt    time.Time `json:"started_at"`
+}
+
+type Point struct {
+	Time     time.Time `json:"time"`
+	Lat      float64   `json:"lat"`
+	Lon      float64   `json:"lon"`
+	AltMSL   float64   `json:"altitude_msl"` // ft
+	AltAGL   float64   `json:"altitude_agl"` // ft
+	GS       float64   `json:"gs"`           // kt
+	IAS      float64   `json:"ias"`          // kt
+	VS       float64   `json:"vs"`           // ft/min
+	Heading  float64   `json:"heading"`
+	Phase    string    `json:"phase"`
+	OnGround bool      `json:"on_ground"`
+	Fuel     float64   `json:"fuel,omitempty"`     // kg remaining
+	Distance float64   `json:"distance,omitempty"` // nm flown
+}
+
+type EventKind string
+
+const (
+	EventPhase     EventKind = "phase"
+	EventLog       EventKind = "log"
+	EventTakeoff   EventKind = "takeoff"
+	EventTouchdown EventKind = "touchdown"
+	EventViolation EventKind = "violation"
+)
+
+type Event struct {
+	Time  time.Time `json:"time"`
+	Kind  EventKind `json:"kind"`
+	Text  string    `json:"text"`
+	Lat   float64   `json:"lat"`
+	Lon   float64   `json:"lon"`
+	Value float64   `json:"value,omitempty"` // landing rate in ft/min for touchdowns, points for violations
+	Rule  string    `json:"rule,omitempty"`  // rule ID for violations
+}
+
+// ErrEmpty is returned when writing a track that has no recorded positions.
+var ErrEmpty = errors.New("track has no recorded positions")
+
+type Track struct {
+	Meta   Meta    `json:"meta"`
+	Points []Point `json:"points"`
+	Events []Event `json:"events"`
+}
+
+func (t *Track) Empty() bool {
+	return t == nil || len(t.Points) == 0
+}
+
+func (t *Track) Last() *Point {
+	if t.Empty() {
+		return nil
+	}
+	return &t.Points[len(t.Points)-1]
+}
+
+// LandingRate returns the vertical speed of the last touchdown, if any.
+func (t *Track) LandingRate() (float64, bool) {
+	if t == nil {
+		return 0, false
+	}
+	for i := len(t.Events) - 1; i >= 0; i-- {
+		if t.Events[i].Kind == EventTouchdown {
+			return t.Events[i].Value, true
+		}
+	}
+	return 0, false
+}
+
+// BlockTimes estimates when the aircraft first moved and when it came to rest
+// after the last touchdown. Either may be zero if the track doesn't cover that
+// part of the flight.
+func (t *Track) BlockTimes() (blockOff, blockOn time.Time) {
+	if t.Empty() {
+		return
+	}
+
+	for _, point := range t.Points {
+		if point.OnGround && point.GS >= 3 {
+			blockOff = point.Time
+			break
+		}
+	}
+
+	touchdown := t.LastEvent(EventTouchdown)
+	if touchdown == nil {
+		return
+	}
+	landedAt := touchdown.Time
+
+	// Block-on is the start of the final stop after landing, not of any
+	// pause while taxiing in.
+	for _, point := range t.Points {
+		if !point.Time.After(landedAt) {
+			continue
+		}
+		if point.OnGround && point.GS < 1 {
+			if blockOn.IsZero() {
+				blockOn = point.Time
+			}
+		} else {
+			blockOn = time.Time{}
+		}
+	}
+	return
+}
+
+func (t *Track) FirstEvent(kind EventKind) *Event {
+	if t == nil {
+		return nil
+	}
+	for i := range t.Events {
+		if t.Events[i].Kind == kind {
+			return &t.Events[i]
+		}
+	}
+	return nil
+}
+
+func (t *Track) LastEvent(kind EventKind) *Event {
+	if t == nil {
+		return nil
+	}
+	for i := len(t.Events) - 1; i >= 0; i-- {
+		if t.Events[i].Kind == kind {
+			return &t.Events[i]
+		}
+	}
+	return nil
+}
+
+func (t *Track) Duration() time.Duration {
+	if t.Empty() {
+		return 0
+	}
+	return t.Points[len(t.Points)-1].Time.Sub(t.Points[0].Time)
+}
+
+func feetToMetres(feet float64) float64 {
+	return feet * 0.3048
+}
+
+func knotsToMetresPerSecond(knots float64) float64 {
+	return knots * 0.514444
+}
@@ -0,0 +1,243 @@
+package track
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testPoints() []Point {
+	start := time.Date(2025, 8, 21, 10, 0, 0, 0, time.UTC)
+	return []Point{
+		{Time: start, Lat: -33.9461, Lon: 151.1772, AltMSL: 21, GS: 0, Phase: "TXI"},
+		{Time: start.Add(time.Minute), Lat: -33.9700, Lon: 151.1800, AltMSL: 1500, AltAGL: 1480, GS: 160, IAS: 155, VS: 2500, Heading: 160, Phase: "TOF"},
+		{Time: start.Add(2 * time.Minute), Lat: -34.0500, Lon: 151.1200, AltMSL: 8000, AltAGL: 7900, GS: 280, IAS: 250, VS: 2000, Heading: 200, Phase: "ENR"},
+	}
+}
+
+func TestRecorderPersistsAndResumes(t *testing.T) {
+	store := NewStore(t.TempDir())
+	recorder := NewRecorder(store, nil)
+
+	recorder.Start(Meta{PirepID: "abc123", Departure: "YSSY", Arrival: "YMML"})
+	points := testPoints()
+	for _, point := range points[:2] {
+		recorder.Record(point)
+	}
+	recorder.AddEvent(Event{Time: points[1].Time, Kind: EventLog, Text: "Takeoff"})
+	recorder.Stop()
+	if _, ok := recorder.Meta(); ok || !recorder.Empty() {
+		t.Errorf("Expected no track after Stop")
+	}
+
+	resumed := NewRecorder(store, nil)
+	resumed.Start(Meta{PirepID: "abc123"})
+	resumed.Record(points[2])
+
+	track := resumed.Track()
+	if len(track.Points) != 3 {
+		t.Fatalf("Expected 3 points, got %d", len(track.Points))
+	}
+	if track.Meta.Departure != "YSSY" {
+		t.Errorf("Expected departure YSSY to survive resume, got %q", track.Meta.Departure)
+	}
+
+	// TXI, TOF and ENR phase changes plus the log event
+	if len(track.Events) != 4 {
+		t.Errorf("Expected 4 events, got %d", len(track.Events))
+	}
+	if meta, ok := resumed.Meta(); !ok || meta.Departure != "YSSY" || resumed.Len() != 3 || len(resumed.Events()) != 4 {
+		t.Errorf("Expected the accessors to agree with Track, got %+v, %d points and %d events", meta, resumed.Len(), len(resumed.Events()))
+	}
+
+	loaded, err := store.Load("abc123")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(loaded.Points) != 3 {
+		t.Errorf("Expected 3 persisted points, got %d", len(loaded.Points))
+	}
+
+	latest, err := store.Latest()
+	if err != nil || latest != "abc123" {
+		t.Errorf("Expected latest track abc123, got %q (err %v)", latest, err)
+	}
+}
+
+func TestRecorderDetectsTakeoffAndTouchdown(t *testing.T) {
+	recorder := NewRecorder(nil, nil)
+	recorder.Start(Meta{PirepID: "abc123"})
+
+	start := time.Date(2025, 8, 21, 10, 0, 0, 0, time.UTC)
+	samples := []Point{
+		{Time: start, OnGround: true, Phase: "TOF"},
+		{Time: start.Add(time.Second), VS: 1800, Phase: "TOF"},
+		{Time: start.Add(2 * time.Second), VS: -160, AltAGL: 3, Phase: "LDG"},
+		{Time: start.Add(3 * time.Second), VS: -40, OnGround: true, Phase: "LDG"},
+	}
+	for _, sample := range samples {
+		recorder.Record(sample)
+	}
+
+	var kinds []EventKind
+	for _, event := range recorder.Track().Events {
+		if event.Kind != EventPhase {
+			kinds = append(kinds, event.Kind)
+		}
+	}
+	if len(kinds) != 2 || kinds[0] != EventTakeoff || kinds[1] != EventTouchdown {
+		t.Fatalf("Expected takeoff then touchdown, got %v", kinds)
+	}
+
+	rate, ok := recorder.Track().LandingRate()
+	if !ok || rate != -160 {
+		t.Errorf("Expected landing rate -160, got %f (ok %v)", rate, ok)
+	}
+}
+
+func TestWriteFormats(t *testing.T) {
+	points := testPoints()
+	track := &Track{
+		Meta:   Meta{PirepID: "abc123", FlightNumber: "QFA1", Departure: "YSSY", Arrival: "YMML"},
+		Points: points,
+		Events: []Event{{Time: points[1].Time, Kind: EventPhase, Text: "TOF", Lat: -33.97, Lon: 151.18}},
+	}
+
+	t.Run("gpx", func(t *testing.T) {
+		var sb strings.Builder
+		if err := Write(&sb, track, FormatGPX); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		var decoded struct {
+			Points []struct {
+				Lat float64 `xml:"lat,attr"`
+				Ele float64 `xml:"ele"`
+			} `xml:"trk>trkseg>trkpt"`
+		}
+		if err := xml.Unmarshal([]byte(sb.String()), &decoded); err != nil {
+			t.Fatalf("Invalid GPX: %v", err)
+		}
+		if len(decoded.Points) != 3 {
+			t.Fatalf("Expected 3 track points, got %d", len(decoded.Points))
+		}
+		if decoded.Points[2].Ele != 2438.4 {
+			t.Errorf("Expected elevation 2438.4 m, got %f", decoded.Points[2].Ele)
+		}
+		if !strings.Contains(sb.String(), "<pxp:gs>280</pxp:gs>") {
+			t.Errorf("Expected ground speed extension in GPX output")
+		}
+	})
+
+	t.Run("kml", func(t *testing.T) {
+		var sb strings.Builder
+		if err := Write(&sb, track, FormatKML); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if !strings.Contains(sb.String(), "<extrude>1</extrude>") {
+			t.Errorf("Expected extruded line string in KML output")
+		}
+		if !strings.Contains(sb.String(), "151.120000,-34.050000,2438.4") {
+			t.Errorf("Expected lon,lat,alt coordinates in KML output")
+		}
+	})
+
+	t.Run("geojson", func(t *testing.T) {
+		var sb strings.Builder
+		if err := Write(&sb, track, FormatGeoJSON); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		var decoded struct {
+			Features []struct {
+				Geometry struct {
+					Type        string          `json:"type"`
+					Coordinates json.RawMessage `json:"coordinates"`
+				} `json:"geometry"`
+				Properties struct {
+					CoordinateProperties struct {
+						GS []float64 `json:"gs"`
+					} `json:"coordinateProperties"`
+				} `json:"properties"`
+			} `json:"features"`
+		}
+		if err := json.Unmarshal([]byte(sb.String()), &decoded); err != nil {
+			t.Fatalf("Invalid GeoJSON: %v", err)
+		}
+		if len(decoded.Features) != 2 {
+			t.Fatalf("Expected line and event features, got %d", len(decoded.Features))
+		}
+		if decoded.Features[0].Geometry.Type != "LineString" {
+			t.Errorf("Expected LineString, got %s", decoded.Features[0].Geometry.Type)
+		}
+		if gs := decoded.Features[0].Properties.CoordinateProperties.GS; len(gs) != 3 || gs[2] != 280 {
+			t.Errorf("Expected per-point ground speeds, got %v", gs)
+		}
+	})
+
+	t.Run("acmi", func(t *testing.T) {
+		var sb strings.Builder
+		if err := Write(&sb, track, FormatACMI); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		output := sb.String()
+		if !strings.HasPrefix(output, "FileType=text/acmi/tacview\nFileVersion=2.1\n") {
+			t.Errorf("Expected ACMI header, got %q", output[:min(60, len(output))])
+		}
+		if !strings.Contains(output, "1,T=151.1200000|-34.0500000|2438.4|||200.0,IAS=128.6") {
+			t.Errorf("Expected converted transform for last point in ACMI output:\n%s", output)
+		}
+		if !strings.Contains(output, "0,Event=Bookmark|1|Phase: TOF") {
+			t.Errorf("Expected phase bookmark in ACMI output")
+		}
+	})
+
+	if err := Write(&strings.Builder{}, &Track{}, FormatGPX); err == nil {
+		t.Errorf("Expected error writing an empty track")
+	}
+}
+
+func TestExport(t *testing.T) {
+	track := &Track{Meta: Meta{PirepID: "abc123"}, Points: testPoints()}
+	dir := t.TempDir()
+
+	path, err := Export(dir, track, FormatACMIZip)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Expected a complete archive at %s, got %v", path, err)
+	}
+	archive.Close()
+
+	// An export that can't be written leaves nothing behind
+	if _, err := Export(dir, track, Format("bogus")); err == nil {
+		t.Fatal("Expected error for unknown format")
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 || entries[0].Name() != "abc123.zip.acmi" {
+		t.Errorf("Expected only abc123.zip.acmi in %s, got %v", dir, entries)
+	}
+}
+
+func TestWritersRejectEmptyTrack(t *testing.T) {
+	writers := map[string]func(io.Writer, *Track) error{
+		"GPX":     WriteGPX,
+		"KML":     WriteKML,
+		"GeoJSON": WriteGeoJSON,
+		"ACMI":    WriteACMI,
+		"ACMIZip": WriteACMIZip,
+	}
+	for name, write := range writers {
+		for _, empty := range []*Track{nil, {Meta: Meta{PirepID: "abc123"}}} {
+			if err := write(&strings.Builder{}, empty); !errors.Is(err, ErrEmpty) {
+				t.Errorf("Expected ErrEmpty from %s, got %v", name, err)
+			}
+		}
+	}
+}
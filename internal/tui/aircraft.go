@@ -2,23 +2,43 @@ package tui
 
 import (
 	"fmt"
-	"github.com/julietrb1/phpvms-xplane/models"
 	"io"
 	"sort"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julietrb1/phpvms-xplane/models"
 )
 
+var aircraftKeys = struct {
+	AtDeparture key.Binding
+}{
+	AtDeparture: key.NewBinding(
+		key.WithKeys("@"),
+		key.WithHelp("@", "at departure"),
+	),
+}
+
 type AircraftItem struct {
 	Aircraft models.Aircraft
+	// Subfleet is the subfleet the aircraft is in, without its aircraft
+	Subfleet models.AircraftFleet
+	// Airline is the subfleet's airline's ICAO code, once airlines have loaded
+	Airline string
+	// WeightUnit is what fuel is shown in
+	WeightUnit string
 }
 
-func NewAircraftItem(aircraft models.Aircraft) AircraftItem {
+func NewAircraftItem(aircraft models.Aircraft, subfleet models.AircraftFleet, airline string) AircraftItem {
+	subfleet.Aircraft = nil
 	return AircraftItem{
 		Aircraft: aircraft,
+		Subfleet: subfleet,
+		Airline:  airline,
 	}
 }
 
@@ -27,21 +47,55 @@ func (i AircraftItem) Title() string {
 }
 
 func (i AircraftItem) Description() string {
-	return fmt.Sprintf("%s - %s", i.Aircraft.ICAO, i.Aircraft.Name)
+	parts := []string{i.Aircraft.ICAO}
+	if i.Aircraft.AirportID != "" {
+		parts = append(parts, "at "+i.Aircraft.AirportID)
+	}
+	parts = append(parts, i.Aircraft.StatusText())
+	if i.Aircraft.FuelOnboard.Kg > 0 {
+		parts = append(parts, formatWeight(i.Aircraft.FuelOnboard.Kg, i.WeightUnit)+" fuel")
+	}
+	if hub := i.hub(); hub != "" {
+		parts = append(parts, "hub "+hub)
+	}
+	return strings.Join(parts, " · ")
 }
 
+// FilterValue lets the list be filtered by airline, subfleet and location as
+// well as the aircraft itself.
 func (i AircraftItem) FilterValue() string {
-	return i.Aircraft.FilterValue()
+	return strings.Join([]string{i.Aircraft.FilterValue(), i.group(), i.Aircraft.AirportID, i.hub()}, " ")
+}
+
+// group is the heading the aircraft is listed under.
+func (i AircraftItem) group() string {
+	group := strings.TrimSpace(i.Subfleet.Type + " " + i.Subfleet.Name)
+	if i.Airline != "" {
+		group += " (" + i.Airline + ")"
+	}
+	return group
+}
+
+func (i AircraftItem) hub() string {
+	if i.Aircraft.HubID != "" {
+		return i.Aircraft.HubID
+	}
+	if i.Subfleet.HubId != nil {
+		return *i.Subfleet.HubId
+	}
+	return ""
 }
 
 type AircraftDelegate struct{}
 
+// Height includes a line for the subfleet heading, which is blank unless the
+// aircraft is the first of its subfleet.
 func (d AircraftDelegate) Height() int {
-	return 2
+	return 3
 }
 
 func (d AircraftDelegate) Spacing() int {
-	return 1
+	return 0
 }
 
 func (d AircraftDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
@@ -65,6 +119,15 @@ func (d AircraftDelegate) Render(w io.Writer, m list.Model, index int, listItem
 		maxWidth = 0
 	}
 
+	var heading string
+	visible := m.VisibleItems()
+	if index == 0 || index > len(visible) || visible[index-1].(AircraftItem).group() != i.group() {
+		heading = i.group()
+	}
+	if len(heading) > maxWidth {
+		heading = heading[:maxWidth-3] + "..."
+	}
+
 	title = i.Title()
 	if len(title) > maxWidth {
 		title = title[:maxWidth-3] + "..."
@@ -75,6 +138,10 @@ func (d AircraftDelegate) Render(w io.Writer, m list.Model, index int, listItem
 		desc = desc[:maxWidth-3] + "..."
 	}
 
+	headingStyle := lipgloss.NewStyle().
+		Foreground(colourSubtle).
+		Underline(true)
+
 	selectedStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("170")).
 		Bold(true)
@@ -85,45 +152,109 @@ func (d AircraftDelegate) Render(w io.Writer, m list.Model, index int, listItem
 	descStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("240"))
 
-	if index == m.Index() {
+	unavailableStyle := descStyle.Copy().
+		Strikethrough(true)
+
+	switch {
+	case index == m.Index():
 		title = selectedStyle.Render(title)
 		desc = selectedStyle.Render(desc)
-	} else {
+	case !i.Aircraft.Available():
+		title = unavailableStyle.Render(title)
+		desc = descStyle.Render(desc)
+	default:
 		title = normalStyle.Render(title)
 		desc = descStyle.Render(desc)
 	}
 
-	fmt.Fprintf(w, "%s\n%s", title, desc)
+	fmt.Fprintf(w, "%s\n%s\n%s", headingStyle.Render(heading), title, desc)
 }
 
-func ConvertToAircraftItems(data []models.Aircraft) []list.Item {
-	items := make([]list.Item, len(data))
-	if len(data) == 0 {
-		return items
-	}
-	for i, ac := range data {
-		items[i] = NewAircraftItem(ac)
+// ConvertToAircraftItems lists every subfleet's aircraft, grouped by type and
+// subfleet. atAirport, if set, only includes aircraft at that airport.
+func ConvertToAircraftItems(fleet []models.AircraftFleet, airlines map[int]string, atAirport, weightUnit string) []list.Item {
+	var items []list.Item
+	for _, subfleet := range fleet {
+		for _, aircraft := range subfleet.Aircraft {
+			if atAirport != "" && aircraft.AirportID != atAirport {
+				continue
+			}
+			item := NewAircraftItem(aircraft, subfleet, airlines[subfleet.AirlineId])
+			item.WeightUnit = weightUnit
+			items = append(items, item)
+		}
 	}
 	sortAircraftItems(items)
 	return items
 }
 
 func sortAircraftItems(items []list.Item) {
-	sort.Slice(items, func(i, j int) bool {
-		regI := items[i].(AircraftItem).Aircraft.Registration
-		regJ := items[j].(AircraftItem).Aircraft.Registration
-
-		return strings.Compare(regI, regJ) < 0
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i].(AircraftItem), items[j].(AircraftItem)
+		if a.group() != b.group() {
+			return a.group() < b.group()
+		}
+		return strings.Compare(a.Aircraft.Registration, b.Aircraft.Registration) < 0
 	})
 }
 
-func GetSelectedAircraftID(model list.Model) int {
+// matchAllTerms is a list filter that keeps the items containing every word
+// of the term, in their order, so aircraft stay grouped by subfleet.
+func matchAllTerms(term string, targets []string) []list.Rank {
+	terms := strings.Fields(strings.ToLower(term))
+	var ranks []list.Rank
+	for i, target := range targets {
+		target = strings.ToLower(target)
+		matched := true
+		for _, t := range terms {
+			if !strings.Contains(target, t) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			ranks = append(ranks, list.Rank{Index: i})
+		}
+	}
+	return ranks
+}
+
+func GetSelectedAircraft(model list.Model) (models.Aircraft, bool) {
 	if model.SelectedItem() == nil {
-		return 0
+		return models.Aircraft{}, false
 	}
 	item, ok := model.SelectedItem().(AircraftItem)
 	if !ok {
-		return 0
+		return models.Aircraft{}, false
+	}
+	return item.Aircraft, true
+}
+
+// refreshAircraftList lists the fleet with the airlines' codes, which may
+// have loaded after it.
+func (model *Model) refreshAircraftList() {
+	airlines := map[int]string{}
+	for _, airline := range model.airlines() {
+		airlines[airline.ID] = NewAirlineItem(airline).Title()
+	}
+
+	var atAirport string
+	model.aircraftList.Title = "Select Aircraft"
+	if model.aircraftAtDeparture {
+		atAirport = model.flightInputs[1].Value()
+		model.aircraftList.Title = "Select Aircraft at " + atAirport
+	}
+	model.aircraftList.SetItems(ConvertToAircraftItems(model.fleet, airlines, atAirport, model.weightUnit()))
+}
+
+// selectedAircraft is the selected aircraft, once the fleet has loaded.
+func (model *Model) selectedAircraft() (models.Aircraft, bool) {
+	for _, subfleet := range model.fleet {
+		for _, aircraft := range subfleet.Aircraft {
+			if aircraft.ID == model.selectedAircraftID {
+				return aircraft, true
+			}
+		}
 	}
-	return item.Aircraft.ID
+	return models.Aircraft{}, false
 }
@@ -0,0 +1,265 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julietrb1/phpvms-xplane/internal/airports"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// airportInputs are the flight inputs that take an airport, by what they're
+// for.
+var airportInputs = map[int]string{1: "departure", 2: "arrival", 3: "alternate"}
+
+// airportSearchDelay is how long typing has to pause before searching, so
+// phpVMS isn't asked about every letter.
+const airportSearchDelay = 300 * time.Millisecond
+
+type airportKeyMap struct {
+	Search key.Binding
+}
+
+var airportKeys = airportKeyMap{
+	Search: key.NewBinding(
+		key.WithKeys("ctrl+f"),
+		key.WithHelp("ctrl+f", "search airports"),
+	),
+}
+
+// airportCheck is what phpVMS knows about an airport code typed into the form.
+type airportCheck struct {
+	pending     bool
+	airport     models.Airport
+	err         error
+	suggestions []models.Airport
+}
+
+type airportSearch struct {
+	show    bool
+	input   int
+	query   textinput.Model
+	results []models.Airport
+	cursor  int
+	// seq is bumped on every change to the query, so only the latest search
+	// is shown
+	seq int
+}
+
+func newAirportSearch() airportSearch {
+	query := textinput.New()
+	query.Placeholder = "ICAO, IATA, name or city"
+	query.CharLimit = 40
+	return airportSearch{query: query}
+}
+
+// checkAirports looks up any airport codes in the form that haven't been
+// looked up yet.
+func (model *Model) checkAirports() tea.Cmd {
+	var cmds []tea.Cmd
+	for i := range airportInputs {
+		icao := model.flightInputs[i].Value()
+		if len(icao) != 4 {
+			continue
+		}
+		if _, ok := model.airportChecks[icao]; ok {
+			continue
+		}
+		model.airportChecks[icao] = airportCheck{pending: true}
+		cmds = append(cmds, model.lookupAirport(icao))
+	}
+	return tea.Batch(cmds...)
+}
+
+func (model *Model) lookupAirport(icao string) tea.Cmd {
+	return func() tea.Msg {
+		airport, err := model.flightService.Airports.Get(model.ctx, icao)
+		msg := airportCheckedMsg{icao: icao, airport: airport, error: err}
+		if errors.Is(err, airports.ErrNotFound) {
+			msg.suggestions = model.flightService.Airports.Search(model.ctx, icao, 3)
+		}
+		return msg
+	}
+}
+
+// airportProblem is why the form's airports can't be prefiled, if phpVMS
+// doesn't know one of them.
+func (model *Model) airportProblem() string {
+	for i := 1; i <= 3; i++ {
+		icao := model.flightInputs[i].Value()
+		if check, ok := model.airportChecks[icao]; ok && errors.Is(check.err, airports.ErrNotFound) {
+			return fmt.Sprintf("Unknown %s airport %s", airportInputs[i], icao)
+		}
+	}
+	return ""
+}
+
+func (model *Model) openAirportSearch(input int) tea.Cmd {
+	search := &model.airportSearch
+	search.show = true
+	search.input = input
+	search.results = nil
+	search.cursor = 0
+	search.query.SetValue(model.flightInputs[input].Value())
+	search.query.CursorEnd()
+	model.flightInputs[input].Blur()
+	return tea.Batch(search.query.Focus(), model.searchAirports())
+}
+
+// searchAirports searches for the query once typing pauses.
+func (model *Model) searchAirports() tea.Cmd {
+	search := &model.airportSearch
+	search.seq++
+	seq := search.seq
+	return tea.Tick(airportSearchDelay, func(time.Time) tea.Msg {
+		return airportSearchDueMsg{seq: seq}
+	})
+}
+
+func (model *Model) runAirportSearch(seq int) tea.Cmd {
+	query := model.airportSearch.query.Value()
+	if seq != model.airportSearch.seq || strings.TrimSpace(query) == "" {
+		return nil
+	}
+	return func() tea.Msg {
+		return airportSearchResultsMsg{seq: seq, airports: model.flightService.Airports.Search(model.ctx, query, 10)}
+	}
+}
+
+func (model *Model) handleKeyAirportSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	search := &model.airportSearch
+
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		model.cancel()
+		return model, tea.Quit
+	case key.Matches(msg, model.keys.Back):
+		search.show = false
+		return model, model.flightInputs[search.input].Focus()
+	case key.Matches(msg, model.keys.Up):
+		search.cursor = max(0, search.cursor-1)
+		return model, nil
+	case key.Matches(msg, model.keys.Down):
+		search.cursor = max(0, min(len(search.results)-1, search.cursor+1))
+		return model, nil
+	case key.Matches(msg, model.keys.Enter):
+		if search.cursor >= len(search.results) {
+			return model, nil
+		}
+		airport := search.results[search.cursor]
+		search.show = false
+		model.flightInputs[search.input].SetValue(airport.ICAO)
+		model.airportChecks[airport.ICAO] = airportCheck{airport: airport}
+		model.statusMessage = fmt.Sprintf("%s airport set to %s (%s)",
+			strings.ToUpper(airportInputs[search.input][:1])+airportInputs[search.input][1:], airport.ICAO, airport.Name)
+		return model, nil
+	}
+
+	before := search.query.Value()
+	var cmd tea.Cmd
+	search.query, cmd = search.query.Update(msg)
+	if search.query.Value() == before {
+		return model, cmd
+	}
+	search.cursor = 0
+	return model, tea.Batch(cmd, model.searchAirports())
+}
+
+func (model *Model) renderAirportSearch() string {
+	search := &model.airportSearch
+	width := model.contentWidth()
+
+	s := styleTitle.Copy().Width(width).Render("Search airports for the "+airportInputs[search.input]) + "\n\n"
+	s += search.query.View() + "\n\n"
+
+	query := strings.TrimSpace(search.query.Value())
+	switch {
+	case query == "":
+		s += styleSecondary.Render("Type an ICAO or IATA code, or part of a name or city") + "\n"
+	case len(search.results) == 0:
+		s += styleSecondary.Render("No airports found") + "\n"
+	}
+	for i, airport := range search.results {
+		line := fmt.Sprintf("%-4s %-3s %s", airport.ICAO, airport.IATA, airportDescription(airport))
+		line = lipgloss.NewStyle().MaxWidth(width - 2).Render(line)
+		if i == search.cursor {
+			s += styleSecondary.Render("> "+line) + "\n"
+		} else {
+			s += "  " + line + "\n"
+		}
+	}
+
+	return s + "\n" + styleSecondary.Render("↑/↓: select • enter: use airport • esc: back") + "\n"
+}
+
+// renderAirportCheck describes the airport in flight input i, or what's wrong
+// with it, under the input.
+func (model *Model) renderAirportCheck(i int) string {
+	icao := model.flightInputs[i].Value()
+	check, ok := model.airportChecks[icao]
+	if len(icao) != 4 || !ok {
+		return ""
+	}
+
+	var notes []string
+	switch {
+	case check.pending:
+		notes = append(notes, styleSecondary.Render("Looking up..."))
+	case errors.Is(check.err, airports.ErrNotFound):
+		text := "Unknown airport"
+		if len(check.suggestions) > 0 {
+			var codes []string
+			for _, airport := range check.suggestions {
+				codes = append(codes, fmt.Sprintf("%s (%s)", airport.ICAO, airport.Name))
+			}
+			text += ", did you mean " + strings.Join(codes, ", ") + "?"
+		}
+		notes = append(notes, styleAttention.Render(text+" Press ctrl+f to search"))
+	case check.err != nil:
+		notes = append(notes, styleAttention.Render(fmt.Sprintf("Couldn't look up airport: %v", check.err)))
+	default:
+		notes = append(notes, styleSecondary.Render(airportDescription(check.airport)))
+	}
+
+	// phpVMS can require flights to start where the aircraft is
+	if i == 1 && !check.pending {
+		if aircraft, ok := model.selectedAircraft(); ok && aircraft.AirportID != "" && aircraft.AirportID != icao {
+			notes = append(notes, styleAttention.Render(fmt.Sprintf("%s is at %s", aircraft.Registration, aircraft.AirportID)))
+		}
+	}
+
+	width := max(20, model.columnWidth()-24)
+	var s string
+	for _, note := range notes {
+		s += lipgloss.JoinHorizontal(lipgloss.Top, stylePairKey.Render("")+" ",
+			lipgloss.NewStyle().Width(width).Render(note)) + "\n"
+	}
+	return s
+}
+
+// airportDescription is the airport's name, where it is, its timezone and
+// whether it's a hub.
+func airportDescription(airport models.Airport) string {
+	description := airport.Name
+	if airport.Location != "" && !strings.Contains(airport.Name, airport.Location) {
+		description += ", " + airport.Location
+	}
+	var details []string
+	if airport.Timezone != "" {
+		details = append(details, airport.Timezone)
+	}
+	if airport.Hub {
+		details = append(details, "hub")
+	}
+	if len(details) > 0 {
+		description += " (" + strings.Join(details, ", ") + ")"
+	}
+	return description
+}
@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/fleet"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// fleetHints are what the OFP and X-Plane say about the aircraft being flown.
+func (model *Model) fleetHints() fleet.Hints {
+	var hints fleet.Hints
+	if model.ofp != nil {
+		hints.Registration = strings.TrimSpace(model.ofp.Aircraft.Reg)
+		hints.AircraftType = strings.TrimSpace(model.ofp.Aircraft.ICAOCode)
+		if model.ofp.General.ICAOAirline != nil {
+			hints.Airline = strings.TrimSpace(*model.ofp.General.ICAOAirline)
+		}
+	}
+	if simulator := model.metrics.LastSimulator.Load(); simulator != nil {
+		hints.TailNumber = simulator.TailNumber
+		hints.SimAircraftType = simulator.AircraftICAO
+	}
+	return hints
+}
+
+// checkSimAircraft notices X-Plane loading a different aircraft, so it can be
+// selected.
+func (model *Model) checkSimAircraft() {
+	simulator := model.metrics.LastSimulator.Load()
+	if simulator == nil {
+		return
+	}
+	if aircraft := simulator.TailNumber + " " + simulator.AircraftICAO; aircraft != model.simAircraft {
+		model.simAircraft = aircraft
+		model.autoSelectFrom = "X-Plane"
+		model.autoSelect()
+	}
+}
+
+// autoSelect selects the aircraft and airline the OFP and X-Plane point to,
+// once the fleet and airlines have loaded. autoSelectFrom is what changed, and
+// is cleared once the selection is made. Nothing changes while a PIREP is
+// active, as it's already being flown in the selected aircraft.
+func (model *Model) autoSelect() {
+	airlines := model.airlines()
+	if model.autoSelectFrom == "" || len(model.fleet) == 0 || len(airlines) == 0 {
+		return
+	}
+	source := model.autoSelectFrom
+	model.autoSelectFrom = ""
+	if model.flightService.GetActivePirepID() != nil {
+		return
+	}
+
+	aircraft, airline := fleet.Match(model.fleetHints(), model.fleet, airlines)
+	var selected []string
+	if aircraft != nil && aircraft.ID != model.selectedAircraftID {
+		model.selectedAircraftID = aircraft.ID
+		selected = append(selected, aircraft.Registration)
+	}
+	if airline != nil && airline.ID != model.selectedAirlineID {
+		model.selectedAirlineID = airline.ID
+		selected = append(selected, airline.ICAO)
+	}
+	if len(selected) == 0 {
+		return
+	}
+
+	model.statusMessage = fmt.Sprintf("Selected %s from %s", strings.Join(selected, " and "), source)
+	if model.config != nil {
+		cfg := model.config.Update(func(cfg *config.Config) {
+			cfg.SelectedAircraftID = model.selectedAircraftID
+			cfg.SelectedAirlineID = model.selectedAirlineID
+		})
+		if err := cfg.SavePreferences(""); err != nil {
+			model.logger.Error("Failed to save preferences", "error", err)
+		}
+	}
+}
+
+// fleetMismatches describes where the OFP and X-Plane disagree with the
+// selected aircraft and airline.
+func (model *Model) fleetMismatches() []string {
+	airlines := model.airlines()
+	if len(model.fleet) == 0 || len(airlines) == 0 {
+		return nil
+	}
+
+	var aircraft *models.Aircraft
+	if selected, ok := model.selectedAircraft(); ok {
+		aircraft = &selected
+	}
+	var airline *models.Airline
+	for _, candidate := range airlines {
+		if candidate.ID == model.selectedAirlineID {
+			airline = &candidate
+			break
+		}
+	}
+	return fleet.Mismatches(model.fleetHints(), model.fleet, aircraft, airline)
+}
+
+func (model *Model) airlines() []models.Airline {
+	var airlines []models.Airline
+	for _, item := range model.airlineList.Items() {
+		if airlineItem, ok := item.(AirlineItem); ok {
+			airlines = append(airlines, airlineItem.Airline)
+		}
+	}
+	return airlines
+}
@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// brailleCanvas draws lines in braille characters, which have 2x4 dots per
+// terminal cell, with text drawn over them. Everything is drawn with an ink,
+// an index into palette.
+type brailleCanvas struct {
+	cols, rows int
+	palette    []lipgloss.Style
+	dots       []rune
+	dotInks    []int
+	text       []rune
+	textInks   []int
+}
+
+const (
+	inkSubtle = iota
+	inkPrimary
+	inkStrong
+	inkAttention
+)
+
+var canvasPalette = []lipgloss.Style{
+	inkSubtle:    lipgloss.NewStyle().Foreground(colourSubtle),
+	inkPrimary:   lipgloss.NewStyle().Foreground(colourPrimary),
+	inkStrong:    lipgloss.NewStyle().Foreground(colourText).Bold(true),
+	inkAttention: lipgloss.NewStyle().Foreground(colourAttention).Bold(true),
+}
+
+// brailleDots are the bits for each dot of a cell, by row then column.
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+func newBrailleCanvas(cols, rows int, palette []lipgloss.Style) *brailleCanvas {
+	return &brailleCanvas{
+		cols:     cols,
+		rows:     rows,
+		palette:  palette,
+		dots:     make([]rune, cols*rows),
+		dotInks:  make([]int, cols*rows),
+		text:     make([]rune, cols*rows),
+		textInks: make([]int, cols*rows),
+	}
+}
+
+// width and height are the size in dots.
+func (c *brailleCanvas) width() int  { return 2 * c.cols }
+func (c *brailleCanvas) height() int { return 4 * c.rows }
+
+func (c *brailleCanvas) set(x, y, ink int) {
+	if x < 0 || y < 0 || x >= c.width() || y >= c.height() {
+		return
+	}
+	cell := y/4*c.cols + x/2
+	c.dots[cell] |= brailleDots[y%4][x%2]
+	c.dotInks[cell] = ink
+}
+
+func (c *brailleCanvas) line(x0, y0, x1, y1, ink int) {
+	// Clip to the canvas first, so a leg between fixes far off the canvas
+	// still crosses it without being walked dot by dot
+	x0, y0, x1, y1, ok := c.clip(x0, y0, x1, y1)
+	if !ok {
+		return
+	}
+
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		c.set(x0, y0, ink)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// clip cuts the line from x0, y0 to x1, y1 down to the part on the canvas
+// using Liang-Barsky, and reports false if none of it is.
+func (c *brailleCanvas) clip(x0, y0, x1, y1 int) (int, int, int, int, bool) {
+	dx, dy := float64(x1-x0), float64(y1-y0)
+	maxX, maxY := float64(c.width()-1), float64(c.height()-1)
+	t0, t1 := 0.0, 1.0
+	edges := [4][2]float64{
+		{-dx, float64(x0)},
+		{dx, maxX - float64(x0)},
+		{-dy, float64(y0)},
+		{dy, maxY - float64(y0)},
+	}
+	for _, edge := range edges {
+		p, q := edge[0], edge[1]
+		if p == 0 {
+			// Parallel to this edge, so either all outside or not limited by it
+			if q < 0 {
+				return 0, 0, 0, 0, false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = max(t0, t)
+		} else {
+			t1 = min(t1, t)
+		}
+		if t0 > t1 {
+			return 0, 0, 0, 0, false
+		}
+	}
+
+	return x0 + int(math.Round(t0*dx)), y0 + int(math.Round(t0*dy)),
+		x0 + int(math.Round(t1*dx)), y0 + int(math.Round(t1*dy)), true
+}
+
+// label writes text from the cell holding dot x, y.
+func (c *brailleCanvas) label(x, y int, text string, ink int) {
+	if x < 0 || y < 0 || y >= c.height() {
+		return
+	}
+	row := y / 4
+	for i, r := range []rune(text) {
+		if col := x/2 + i; col < c.cols {
+			c.text[row*c.cols+col] = r
+			c.textInks[row*c.cols+col] = ink
+		}
+	}
+}
+
+func (c *brailleCanvas) String() string {
+	var sb strings.Builder
+	for row := 0; row < c.rows; row++ {
+		// Consecutive cells in the same ink are rendered together
+		var run strings.Builder
+		runInk := -1
+		flush := func() {
+			if runInk < 0 {
+				sb.WriteString(run.String())
+			} else {
+				sb.WriteString(c.palette[runInk].Render(run.String()))
+			}
+			run.Reset()
+		}
+
+		for col := 0; col < c.cols; col++ {
+			cell := row*c.cols + col
+			r, ink := ' ', -1
+			switch {
+			case c.text[cell] != 0:
+				r, ink = c.text[cell], c.textInks[cell]
+			case c.dots[cell] != 0:
+				r, ink = 0x2800+c.dots[cell], c.dotInks[cell]
+			}
+
+			if ink != runInk {
+				flush()
+				runInk = ink
+			}
+			run.WriteRune(r)
+		}
+		flush()
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,206 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+)
+
+// chartWindows are how much of the flight the charts show. Zero is the whole
+// flight.
+var chartWindows = []time.Duration{10 * time.Minute, 30 * time.Minute, 0}
+
+// approachPhases are highlighted, so the descent can be reviewed at a glance.
+var approachPhases = map[string]bool{"TEN": true, "FIN": true}
+
+type chartsKeyMap struct {
+	Window key.Binding
+}
+
+var chartsKeys = chartsKeyMap{
+	Window: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "change chart window"),
+	),
+}
+
+type chartSeries struct {
+	xs, ys []float64
+	ink    int
+	// highlight marks points drawn in inkAttention instead of ink
+	highlight []bool
+}
+
+func (model *Model) handleKeyLive(msg tea.KeyMsg) (tea.Cmd, bool) {
+	if !key.Matches(msg, chartsKeys.Window) {
+		return nil, false
+	}
+	model.chartWindow = (model.chartWindow + 1) % len(chartWindows)
+	model.statusMessage = "Charts show " + chartWindowName(chartWindows[model.chartWindow])
+	return nil, true
+}
+
+func chartWindowName(window time.Duration) string {
+	if window == 0 {
+		return "the whole flight"
+	}
+	return fmt.Sprintf("the last %.0f minutes", window.Minutes())
+}
+
+func (model *Model) renderCharts(s string) string {
+	points := model.flown()
+	if len(points) < 2 {
+		return s + styleHeading.Render("Charts") + "\n" + styleSecondary.Render("(none)") + "\n"
+	}
+
+	window := chartWindows[model.chartWindow]
+	windowed := points
+	if window > 0 {
+		since := points[len(points)-1].Time.Add(-window)
+		for i, point := range points {
+			if !point.Time.Before(since) {
+				windowed = points[i:]
+				break
+			}
+		}
+	}
+
+	width := model.contentWidth()
+	rows := max(3, min(8, (model.height-32)/3))
+	start := windowed[0].Time
+	seconds := func(point track.Point) float64 { return point.Time.Sub(start).Seconds() }
+	approach := func(point track.Point) bool { return approachPhases[point.Phase] }
+
+	// Against the plan by distance when there is one, otherwise over time like
+	// the other charts
+	if len(model.route) > 1 && points[len(points)-1].Distance > 0 {
+		planned := chartSeries{ink: inkSubtle}
+		distance := 0.0
+		for i, fix := range model.route {
+			if i > 0 {
+				previous := model.route[i-1]
+				distance += flightplan.DistanceNM(previous.Lat, previous.Lon, fix.Lat, fix.Lon)
+			}
+			planned.xs = append(planned.xs, distance)
+			planned.ys = append(planned.ys, fix.Altitude)
+		}
+		flown := pointSeries(points, inkPrimary, func(point track.Point) float64 { return point.Distance },
+			func(point track.Point) float64 { return point.AltMSL }, approach)
+
+		low, high := chartRange(0, planned.ys, flown.ys)
+		s += styleHeading.Render("Altitude profile (ft by nm, planned and flown)") + "\n"
+		s += renderChart(width, rows, low, high, planned, flown)
+	} else {
+		altitude := pointSeries(windowed, inkPrimary, seconds, func(point track.Point) float64 { return point.AltMSL }, approach)
+		low, high := chartRange(0, altitude.ys)
+		s += styleHeading.Render("Altitude (ft)") + "\n"
+		s += renderChart(width, rows, low, high, altitude)
+	}
+
+	ias := pointSeries(windowed, inkPrimary, seconds, func(point track.Point) float64 { return point.IAS }, approach)
+	gs := pointSeries(windowed, inkSubtle, seconds, func(point track.Point) float64 { return point.GS }, nil)
+	low, high := chartRange(ias.ys[0], ias.ys, gs.ys)
+	s += styleHeading.Render("Speed (kt, IAS and GS)") + "\n"
+	s += renderChart(width, rows, low, high, gs, ias)
+
+	vs := pointSeries(windowed, inkPrimary, seconds, func(point track.Point) float64 { return point.VS }, approach)
+	low, high = chartRange(0, vs.ys)
+	limit := math.Max(-low, high)
+	zero := chartSeries{xs: []float64{0, seconds(windowed[len(windowed)-1])}, ys: []float64{0, 0}, ink: inkSubtle}
+	s += styleHeading.Render("Vertical speed (fpm)") + "\n"
+	s += renderChart(width, rows, -limit, limit, zero, vs)
+
+	legend := fmt.Sprintf("%s planned or GS, %s flown, %s approach",
+		canvasPalette[inkSubtle].Render("━"), canvasPalette[inkPrimary].Render("━"), canvasPalette[inkAttention].Render("━"))
+	s += legend + "\n"
+	return s + styleSecondary.Render(fmt.Sprintf("Showing %s. Press w to change", chartWindowName(window))) + "\n"
+}
+
+func pointSeries(points []track.Point, ink int, x, y func(track.Point) float64, highlight func(track.Point) bool) chartSeries {
+	series := chartSeries{ink: ink, xs: make([]float64, len(points)), ys: make([]float64, len(points))}
+	if highlight != nil {
+		series.highlight = make([]bool, len(points))
+	}
+	for i, point := range points {
+		series.xs[i], series.ys[i] = x(point), y(point)
+		if highlight != nil {
+			series.highlight[i] = highlight(point)
+		}
+	}
+	return series
+}
+
+// chartRange is the lowest and highest of values and include, with some room
+// above and below.
+func chartRange(include float64, values ...[]float64) (float64, float64) {
+	low, high := include, include
+	for _, series := range values {
+		for _, value := range series {
+			low, high = math.Min(low, value), math.Max(high, value)
+		}
+	}
+	margin := math.Max((high-low)*0.05, 1)
+	if low < include {
+		low -= margin
+	}
+	return low, high + margin
+}
+
+// renderChart draws series with their shared y axis from low to high labelled
+// on the left.
+func renderChart(width, rows int, low, high float64, series ...chartSeries) string {
+	const labelWidth = 8
+	canvas := newBrailleCanvas(max(10, width-labelWidth), rows, canvasPalette)
+
+	xMin, xMax := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for _, x := range s.xs {
+			xMin, xMax = math.Min(xMin, x), math.Max(xMax, x)
+		}
+	}
+	scaleX := func(x float64) int {
+		if xMax <= xMin {
+			return 0
+		}
+		return int(math.Round((x - xMin) / (xMax - xMin) * float64(canvas.width()-1)))
+	}
+	scaleY := func(y float64) int {
+		return int(math.Round((high - y) / (high - low) * float64(canvas.height()-1)))
+	}
+
+	for _, s := range series {
+		for i := range s.xs {
+			ink := s.ink
+			if s.highlight != nil && s.highlight[i] {
+				ink = inkAttention
+			}
+			x1, y1 := scaleX(s.xs[i]), scaleY(s.ys[i])
+			if i == 0 {
+				canvas.set(x1, y1, ink)
+				continue
+			}
+			canvas.line(scaleX(s.xs[i-1]), scaleY(s.ys[i-1]), x1, y1, ink)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(canvas.String(), "\n"), "\n")
+	var sb strings.Builder
+	for i, line := range lines {
+		label := ""
+		switch i {
+		case 0:
+			label = fmt.Sprintf("%.0f", high)
+		case len(lines) - 1:
+			label = fmt.Sprintf("%.0f", low)
+		}
+		sb.WriteString(styleSecondary.Render(fmt.Sprintf("%*s ", labelWidth-1, label)) + line + "\n")
+	}
+	return sb.String()
+}
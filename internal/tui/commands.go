@@ -2,10 +2,11 @@ package tui
 
 import (
 	"fmt"
-	"strconv"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
 )
 
 func (model *Model) fetchAirlineList() tea.Cmd {
@@ -33,98 +34,72 @@ func (model *Model) fetchAirlineList() tea.Cmd {
 	}
 }
 
-func (model *Model) fetchInProgressPIREP() tea.Cmd {
-	return func() tea.Msg {
-		pireps, err := model.flightService.ListPIREPs(model.ctx)
-		if err != nil {
-			return fetchInProgressPIREPMsg{
-				error: err,
-			}
-		}
-		return fetchInProgressPIREPMsg{
-			pirep: pireps[0],
-		}
-	}
-}
-
 func (model *Model) fetchSimbriefData() tea.Cmd {
 	return func() tea.Msg {
 		apiClient := model.flightService.GetAPIClient()
-		ofpData, err := apiClient.GetSimbriefOFP(model.ctx, model.config.SimbriefUserID)
+		ofpData, err := apiClient.GetSimbriefOFP(model.ctx, model.config.Get().SimbriefUserID)
 		if err != nil {
 			return fetchSimbriefOFPErrorMsg{
 				err: fmt.Errorf("failed to fetch SimBrief OFP: %w", err),
 			}
 		}
 
-		routeDistance, err := strconv.Atoi(ofpData.General.RouteDistance)
+		plan, err := flightplan.FromOFP(ofpData)
 		if err != nil {
-			return fetchSimbriefOFPErrorMsg{
-				err: fmt.Errorf("failed to parse route distance: %w", err),
-			}
+			return fetchSimbriefOFPErrorMsg{err: err}
 		}
 
-		initialAltitude, err := strconv.Atoi(ofpData.General.InitialAltitude)
-		if err != nil {
-			return fetchSimbriefOFPErrorMsg{
-				err: fmt.Errorf("failed to parse initial altitude: %w", err),
-			}
+		return fetchSimbriefOFPMsg{
+			origin:          plan.Origin,
+			destination:     plan.Destination,
+			alternate:       plan.Alternate,
+			flightNumber:    plan.FlightNumber,
+			planDist:        plan.Distance,
+			initialAltitude: plan.Altitude,
+			blockFuel:       plan.BlockFuel,
+			flightTime:      plan.FlightTime,
+			route:           plan.Route,
+			ofp:             ofpData,
 		}
+	}
+}
 
-		blockFuel, err := strconv.Atoi(ofpData.Fuel.PlanRamp)
-		if err != nil {
-			return fetchSimbriefOFPErrorMsg{
-				err: fmt.Errorf("failed to parse block fuel: %w", err),
-			}
+func (model *Model) exportFMSPlan() tea.Cmd {
+	ofp := model.ofp
+	return func() tea.Msg {
+		if ofp == nil {
+			return fmsExportedMsg{error: fmt.Errorf("no SimBrief OFP loaded")}
 		}
+		path, err := flightplan.ExportFMS(model.config.Get().FMSOutputDir, ofp)
+		return fmsExportedMsg{path: path, error: err}
+	}
+}
 
-		flightTime, err := strconv.Atoi(ofpData.Times.EstTimeEnroute)
+func (model *Model) exportTrack() tea.Cmd {
+	return func() tea.Msg {
+		flightTrack, err := model.flightService.LastTrack()
 		if err != nil {
-			return fetchSimbriefOFPErrorMsg{
-				err: fmt.Errorf("failed to parse flight time: %w", err),
-			}
+			return trackExportedMsg{error: err}
 		}
 
-		if ofpData.Origin.ICAOCode == nil {
-			return fetchSimbriefOFPErrorMsg{
-				err: fmt.Errorf("failed to parse origin ICAO code"),
+		dir := model.config.Get().ExportsDir()
+		for _, format := range track.Formats {
+			if _, err := track.Export(dir, flightTrack, format); err != nil {
+				return trackExportedMsg{error: err}
 			}
 		}
-
-		if ofpData.Destination.ICAOCode == nil {
-			return fetchSimbriefOFPErrorMsg{
-				err: fmt.Errorf("failed to parse destination ICAO code"),
-			}
-		}
-
-		alternateICAOCode := ""
-		if ofpData.Alternate.ICAOCode != nil {
-			alternateICAOCode = *ofpData.Alternate.ICAOCode
-		}
-
-		return fetchSimbriefOFPMsg{
-			origin:          *ofpData.Origin.ICAOCode,
-			destination:     *ofpData.Destination.ICAOCode,
-			alternate:       alternateICAOCode,
-			flightNumber:    ofpData.General.FlightNumber,
-			planDist:        routeDistance,
-			initialAltitude: initialAltitude,
-			blockFuel:       blockFuel,
-			flightTime:      flightTime,
-			route:           ofpData.General.Route,
-		}
+		return trackExportedMsg{dir: dir}
 	}
 }
 
 func (model *Model) fetchAircraftList() tea.Cmd {
 	return func() tea.Msg {
-		fleet, err := model.flightService.GetUserAircraftList(model.ctx)
+		fleet, err := model.flightService.GetFleet(model.ctx)
 		if err != nil {
 			model.logger.Error("Failed to fetch aircraft list", "error", err)
 			return nil
 		}
-		items := ConvertToAircraftItems(fleet)
-		return aircraftListUpdatedMsg{items: items}
+		return aircraftListUpdatedMsg{fleet: fleet}
 	}
 }
 
@@ -0,0 +1,371 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+const (
+	reviewFlightTime = iota
+	reviewFuelUsed
+	reviewDistance
+	reviewLandingRate
+	reviewBlockOff
+	reviewBlockOn
+	reviewZFW
+	reviewFares
+	reviewFields
+	reviewNotes
+	reviewInputCount
+)
+
+// reviewTimeLayout is how block times are shown and edited, always in UTC
+const reviewTimeLayout = "2006-01-02 15:04"
+
+var reviewLabels = [reviewInputCount]string{
+	reviewFlightTime:  "Flight time (min)",
+	reviewFuelUsed:    "Fuel used",
+	reviewDistance:    "Distance (nm)",
+	reviewLandingRate: "Landing rate (fpm)",
+	reviewBlockOff:    "Block off (UTC)",
+	reviewBlockOn:     "Block on (UTC)",
+	reviewZFW:         "ZFW",
+	reviewFares:       "Fares",
+	reviewFields:      "Custom fields",
+	reviewNotes:       "Notes",
+}
+
+// filingReview is the dialog shown before filing, so the pilot can check and
+// correct the values taken from telemetry and the OFP.
+type filingReview struct {
+	show   bool
+	inputs []textinput.Model
+	focus  int
+	fares  []models.Fare
+	draft  api.FilePIREPRequest
+	err    error
+	// weightUnit is what fuel and ZFW are entered in
+	weightUnit string
+}
+
+func newFilingReview() filingReview {
+	inputs := make([]textinput.Model, reviewInputCount)
+	for i := range inputs {
+		t := textinput.New()
+		t.CharLimit = 16
+		t.PlaceholderStyle = styleAttention
+
+		switch i {
+		case reviewLandingRate:
+			t.Placeholder = "e.g. -180"
+		case reviewBlockOff, reviewBlockOn:
+			t.Placeholder = reviewTimeLayout
+		case reviewFares:
+			t.Placeholder = "e.g. Y=150 J=12"
+			t.CharLimit = 64
+		case reviewFields:
+			t.Placeholder = "e.g. Gate=12; Stand=B4"
+			t.CharLimit = 200
+		case reviewNotes:
+			t.Placeholder = "Remarks for the reviewer"
+			t.CharLimit = 500
+		}
+		inputs[i] = t
+	}
+	return filingReview{inputs: inputs}
+}
+
+func (review *filingReview) open(msg filingDraftMsg, weightUnit string) tea.Cmd {
+	review.show = true
+	review.weightUnit = weightUnit
+	review.draft = msg.data
+	review.fares = msg.fares
+	review.err = nil
+
+	values := [reviewInputCount]string{
+		reviewFlightTime: strconv.Itoa(msg.data.FlightTime),
+		reviewFuelUsed:   weightValue(msg.fuelUsedKg, weightUnit),
+		reviewDistance:   strconv.Itoa(msg.data.Distance),
+		reviewBlockOff:   reviewTime(msg.data.BlockOffTime),
+		reviewBlockOn:    reviewTime(msg.data.BlockOnTime),
+		reviewFares:      defaultFares(msg.fares, msg.passengers),
+		reviewNotes:      msg.data.Notes,
+	}
+	if msg.data.LandingRate != nil {
+		values[reviewLandingRate] = fmt.Sprintf("%.0f", *msg.data.LandingRate)
+	}
+	if msg.zfwKg > 0 {
+		values[reviewZFW] = weightValue(msg.zfwKg, weightUnit)
+	}
+
+	for i := range review.inputs {
+		review.inputs[i].SetValue(values[i])
+		review.inputs[i].Blur()
+	}
+	review.focus = 0
+	return review.inputs[0].Focus()
+}
+
+func (review *filingReview) moveFocus(delta int) tea.Cmd {
+	review.inputs[review.focus].Blur()
+	review.focus = (review.focus + delta + reviewInputCount) % reviewInputCount
+	return review.inputs[review.focus].Focus()
+}
+
+// request builds the filing request from the edited values.
+func (review *filingReview) request() (api.FilePIREPRequest, error) {
+	data := review.draft
+	value := func(i int) string {
+		return strings.TrimSpace(review.inputs[i].Value())
+	}
+
+	var err error
+	if data.FlightTime, err = strconv.Atoi(value(reviewFlightTime)); err != nil || data.FlightTime <= 0 {
+		return data, fmt.Errorf("invalid flight time")
+	}
+	fuelUsed, err := strconv.Atoi(value(reviewFuelUsed))
+	if err != nil || fuelUsed <= 0 {
+		return data, fmt.Errorf("invalid fuel used")
+	}
+	data.FuelUsedLbs = weightLbs(fuelUsed, review.weightUnit)
+	if data.Distance, err = strconv.Atoi(value(reviewDistance)); err != nil || data.Distance < 0 {
+		return data, fmt.Errorf("invalid distance")
+	}
+
+	data.LandingRate = nil
+	if rate := value(reviewLandingRate); rate != "" {
+		parsed, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return data, fmt.Errorf("invalid landing rate")
+		}
+		data.LandingRate = &parsed
+	}
+
+	if data.BlockOffTime, err = parseReviewTime(value(reviewBlockOff)); err != nil {
+		return data, fmt.Errorf("invalid block off time")
+	}
+	if data.BlockOnTime, err = parseReviewTime(value(reviewBlockOn)); err != nil {
+		return data, fmt.Errorf("invalid block on time")
+	}
+
+	data.ZFWLbs = 0
+	if zfw := value(reviewZFW); zfw != "" {
+		zfwValue, err := strconv.Atoi(zfw)
+		if err != nil || zfwValue < 0 {
+			return data, fmt.Errorf("invalid ZFW")
+		}
+		data.ZFWLbs = weightLbs(zfwValue, review.weightUnit)
+	}
+
+	if data.Fares, err = parseFares(value(reviewFares), review.fares); err != nil {
+		return data, err
+	}
+	if data.Fields, err = parseFields(value(reviewFields)); err != nil {
+		return data, err
+	}
+	data.Notes = value(reviewNotes)
+	return data, nil
+}
+
+func (model *Model) handleKeyReview(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	review := &model.review
+
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		model.cancel()
+		return model, tea.Quit
+	case key.Matches(msg, model.keys.Back):
+		review.show = false
+		model.statusMessage = "Filing cancelled"
+		return model, nil
+	case key.Matches(msg, model.keys.Tab), key.Matches(msg, model.keys.Down):
+		return model, review.moveFocus(1)
+	case key.Matches(msg, model.keys.ShiftTab), key.Matches(msg, model.keys.Up):
+		return model, review.moveFocus(-1)
+	case key.Matches(msg, model.keys.Enter):
+		data, err := review.request()
+		if err != nil {
+			review.err = err
+			return model, nil
+		}
+		review.show = false
+		model.statusMessage = "Filing PIREP..."
+		return model, model.filePIREP(data)
+	}
+
+	var cmd tea.Cmd
+	review.inputs[review.focus], cmd = review.inputs[review.focus].Update(msg)
+	return model, cmd
+}
+
+func (model *Model) renderReview() string {
+	review := &model.review
+
+	s := styleTitle.Render("Review PIREP") + "\n"
+	for i, input := range review.inputs {
+		label := reviewLabels[i]
+		if i == reviewFuelUsed || i == reviewZFW {
+			label += " (" + review.weightUnit + ")"
+		}
+		s += fmt.Sprintf("%s %s\n", stylePairKey.Render(label), input.View())
+	}
+
+	if review.draft.Score != nil {
+		s += fmt.Sprintf("%s %d/%d\n", stylePairKey.Render("Score"), *review.draft.Score, rules.MaxScore)
+	}
+	if len(review.fares) > 0 {
+		codes := make([]string, len(review.fares))
+		for i, fare := range review.fares {
+			codes[i] = fmt.Sprintf("%s (%s)", fare.Code, fare.Name)
+		}
+		s += fmt.Sprintf("%s %s\n", stylePairKey.Render("Available fares"), strings.Join(codes, ", "))
+	}
+
+	if review.err != nil {
+		s += "\n" + styleAttention.Render(review.err.Error()) + "\n"
+	}
+	s += "\n" + styleSecondary.Render("enter: file • tab: next field • esc: back") + "\n"
+	return s
+}
+
+// draftPIREP gathers the values to review before filing.
+func (model *Model) draftPIREP() tea.Cmd {
+	aircraftID := model.selectedAircraftID
+	ofp := model.ofp
+	startingFuel := model.flightInputs[7].Value()
+	weightUnit := model.weightUnit()
+
+	return func() tea.Msg {
+		data, err := model.flightService.DraftFiling()
+		if err != nil {
+			return filingDraftMsg{error: err}
+		}
+
+		msg := filingDraftMsg{data: data}
+		snapshot := model.metrics.Snapshot()
+		if snapshot.LastFlightTime != nil && *snapshot.LastFlightTime > 0 {
+			msg.data.FlightTime = *snapshot.LastFlightTime
+		}
+		if snapshot.LastDistance != nil {
+			msg.data.Distance = *snapshot.LastDistance
+		}
+		if blockFuel, err := strconv.Atoi(startingFuel); err == nil && snapshot.LastFuel != nil && *snapshot.LastFuel > 0 {
+			msg.fuelUsedKg = int(math.Max(0, float64(weightKg(blockFuel, weightUnit)-*snapshot.LastFuel)))
+		}
+
+		if ofp != nil {
+			msg.zfwKg, _ = strconv.Atoi(ofp.Weights.EstZfw)
+			msg.passengers, _ = strconv.Atoi(ofp.Weights.PaxCount)
+		}
+
+		if aircraftID > 0 {
+			fares, err := model.flightService.GetFares(model.ctx, aircraftID)
+			if err != nil {
+				model.logger.Debug("Failed to get fares", "aircraft_id", aircraftID, "error", err)
+			}
+			msg.fares = fares
+		}
+		return msg
+	}
+}
+
+// defaultFares puts all the OFP's passengers in the only passenger fare, if
+// there is just one.
+func defaultFares(fares []models.Fare, passengers int) string {
+	if passengers <= 0 {
+		return ""
+	}
+
+	var code string
+	for _, fare := range fares {
+		if fare.Type != models.FareTypePassenger {
+			continue
+		}
+		if code != "" {
+			return ""
+		}
+		code = fare.Code
+	}
+	if code == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s=%d", code, passengers)
+}
+
+// parseFares reads counts written as CODE=count, separated by spaces or
+// commas, e.g. "Y=150 J=12".
+func parseFares(input string, fares []models.Fare) ([]api.PIREPFare, error) {
+	var result []api.PIREPFare
+	for _, pair := range strings.FieldsFunc(input, func(r rune) bool { return r == ',' || r == ' ' }) {
+		code, count, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid fare %q, expected CODE=count", pair)
+		}
+
+		var fareID int
+		for _, fare := range fares {
+			if strings.EqualFold(fare.Code, code) {
+				fareID = fare.ID
+				break
+			}
+		}
+		if fareID == 0 {
+			return nil, fmt.Errorf("unknown fare %q", code)
+		}
+
+		n, err := strconv.Atoi(count)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid count for fare %q", code)
+		}
+		result = append(result, api.PIREPFare{ID: fareID, Count: n})
+	}
+	return result, nil
+}
+
+// parseFields reads custom PIREP fields written as Name=value, separated by
+// semicolons.
+func parseFields(input string) (map[string]interface{}, error) {
+	fields := map[string]interface{}{}
+	for _, pair := range strings.Split(input, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid field %q, expected Name=value", strings.TrimSpace(pair))
+		}
+		fields[name] = strings.TrimSpace(value)
+	}
+	return fields, nil
+}
+
+func reviewTime(value string) string {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return ""
+	}
+	return parsed.UTC().Format(reviewTimeLayout)
+}
+
+func parseReviewTime(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	parsed, err := time.ParseInLocation(reviewTimeLayout, value, time.UTC)
+	if err != nil {
+		return "", err
+	}
+	return parsed.Format(time.RFC3339), nil
+}
@@ -30,6 +30,13 @@ func conditionalAttentionTime(input *time.Time) string {
 	return input.Format(time.RFC3339)
 }
 
+func conditionalNumber(input *float64) string {
+	if input == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f", *input)
+}
+
 func (model *Model) findSelectedAirline() string {
 	var airlineInfo string
 	for _, item := range model.airlineList.Items() {
@@ -0,0 +1,59 @@
+package tui
+
+import (
+	"github.com/julietrb1/phpvms-xplane/internal/track"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+// maxHistory is how many samples are kept when no track is being recorded.
+const maxHistory = 10000
+
+// recordHistory keeps what X-Plane reports, so the map and charts have
+// something to show before a PIREP is started.
+func (model *Model) recordHistory(snapshot udp.MetricsSnapshot) {
+	position := snapshot.LastPosition
+	if position == nil || snapshot.LastPacketTime == nil {
+		return
+	}
+	if n := len(model.history); n > 0 && !snapshot.LastPacketTime.After(model.history[n-1].Time) {
+		return
+	}
+
+	point := track.Point{
+		Time:     *snapshot.LastPacketTime,
+		Lat:      position.Lat,
+		Lon:      position.Lon,
+		AltMSL:   udp.Float(position.AltMSL),
+		AltAGL:   udp.Float(position.AltAGL),
+		GS:       udp.Float(position.GS),
+		IAS:      udp.Float(position.IAS),
+		VS:       udp.Float(position.VSFPM),
+		Heading:  udp.Float(position.Heading),
+		Distance: udp.Float(position.DistanceNM),
+	}
+	if snapshot.LastStatus != nil {
+		point.Phase = *snapshot.LastStatus
+	}
+
+	if len(model.history) >= maxHistory {
+		model.history = append(model.history[:0], model.history[maxHistory/2:]...)
+	}
+	model.history = append(model.history, point)
+}
+
+// flown is the track being recorded, or what X-Plane has reported if there
+// isn't one. The track is only copied again once it has grown, not on every
+// render.
+func (model *Model) flown() []track.Point {
+	recorder := model.flightService.Recorder
+	meta, ok := recorder.Meta()
+	if count := recorder.Len(); ok && count > 0 {
+		if model.flownTrack == nil || model.flownTrack.Meta.PirepID != meta.PirepID || len(model.flownTrack.Points) != count {
+			model.flownTrack = model.flightService.GetTrack()
+		}
+		if !model.flownTrack.Empty() {
+			return model.flownTrack.Points
+		}
+	}
+	return model.history
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	tabFlight = iota
+	tabLive
+	tabMap
+	tabPlan
+	tabLogbook
+	tabLogs
+	tabSettings
+	tabCount
+)
+
+var tabNames = [tabCount]string{"Flight", "Live", "Map", "Plan", "Logbook", "Logs", "Settings"}
+
+const (
+	// defaultWidth is used until the terminal size is known
+	defaultWidth = 80
+	// minColumnWidth is the narrowest a column may be before sections are
+	// stacked instead
+	minColumnWidth = 40
+)
+
+func renderTabs(activeTab int) string {
+	tabs := make([]string, len(tabNames))
+	for i, name := range tabNames {
+		if i == activeTab {
+			tabs[i] = styleSecondary.Copy().Bold(true).Render("[" + name + "]")
+		} else {
+			tabs[i] = lipgloss.NewStyle().Foreground(colourSubtle).Render(" " + name + " ")
+		}
+	}
+	return strings.Join(tabs, " ") + "\n"
+}
+
+func (model *Model) contentWidth() int {
+	if model.width <= 0 {
+		return defaultWidth
+	}
+	return model.width
+}
+
+// columns puts sections side by side when the terminal is wide enough, and
+// one after the other when it isn't.
+func (model *Model) columns(left, right string) string {
+	width := model.contentWidth()
+	left = strings.TrimSuffix(left, "\n")
+	right = strings.TrimSuffix(right, "\n")
+
+	leftWidth := max(width/2, lipgloss.Width(left)+2)
+	if width-leftWidth < minColumnWidth {
+		return left + "\n" + right + "\n"
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top,
+		lipgloss.NewStyle().Width(leftWidth).PaddingRight(2).Render(left),
+		lipgloss.NewStyle().Width(width-leftWidth).Render(right),
+	) + "\n"
+}
+
+// columnWidth is how wide a section drawn by columns can be.
+func (model *Model) columnWidth() int {
+	width := model.contentWidth()
+	if width < 2*minColumnWidth {
+		return width
+	}
+	return width / 2
+}
@@ -0,0 +1,262 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
+)
+
+type logbookKeyMap struct {
+	Sort       key.Binding
+	Reverse    key.Binding
+	Filter     key.Binding
+	ExportCSV  key.Binding
+	ExportJSON key.Binding
+	Refresh    key.Binding
+}
+
+var logbookKeys = logbookKeyMap{
+	Sort: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "change sort"),
+	),
+	Reverse: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reverse sort"),
+	),
+	Filter: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "filter"),
+	),
+	ExportCSV: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "export CSV"),
+	),
+	ExportJSON: key.NewBinding(
+		key.WithKeys("j"),
+		key.WithHelp("j", "export JSON"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "refresh states from phpVMS"),
+	),
+}
+
+type logbookView struct {
+	entries    []logbook.Entry
+	visible    []logbook.Entry
+	table      table.Model
+	filter     textinput.Model
+	sortField  logbook.SortField
+	descending bool
+}
+
+func newLogbookView() logbookView {
+	columns := []table.Column{
+		{Title: "Date", Width: 10},
+		{Title: "Flight", Width: 8},
+		{Title: "Route", Width: 9},
+		{Title: "Aircraft", Width: 8},
+		{Title: "Time", Width: 5},
+		{Title: "Dist", Width: 5},
+		{Title: "Landing", Width: 7},
+		{Title: "State", Width: 11},
+	}
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithFocused(true),
+		table.WithHeight(12),
+	)
+	tableStyles := table.DefaultStyles()
+	tableStyles.Header = tableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(colourBorder).
+		BorderBottom(true).
+		Bold(false)
+	tableStyles.Selected = tableStyles.Selected.
+		Foreground(colourText).
+		Background(colourBackground).
+		Bold(false)
+	t.SetStyles(tableStyles)
+
+	filter := textinput.New()
+	filter.Placeholder = "flight, airport, aircraft or state"
+	filter.Prompt = "/ "
+	filter.CharLimit = 32
+
+	return logbookView{
+		table:      t,
+		filter:     filter,
+		sortField:  logbook.SortByDate,
+		descending: true,
+	}
+}
+
+func (view *logbookView) setEntries(entries []logbook.Entry) {
+	view.entries = entries
+	view.refresh()
+}
+
+func (view *logbookView) refresh() {
+	view.visible = logbook.Filter(append([]logbook.Entry(nil), view.entries...), view.filter.Value())
+	logbook.Sort(view.visible, view.sortField, view.descending)
+
+	rows := make([]table.Row, 0, len(view.visible))
+	for _, entry := range view.visible {
+		landing := "-"
+		if entry.LandingRate != nil {
+			landing = fmt.Sprintf("%.0f", *entry.LandingRate)
+		}
+		rows = append(rows, table.Row{
+			entry.PrefiledAt.Local().Format(time.DateOnly),
+			entry.FlightNumber,
+			entry.Departure + "-" + entry.Arrival,
+			entry.Registration,
+			fmt.Sprintf("%d:%02d", entry.FlightTime/60, entry.FlightTime%60),
+			fmt.Sprintf("%.0f", entry.Distance),
+			landing,
+			entry.State,
+		})
+	}
+	view.table.SetRows(rows)
+	if view.table.Cursor() >= len(rows) {
+		view.table.SetCursor(max(0, len(rows)-1))
+	}
+}
+
+func (model *Model) handleKeyLogbook(msg tea.KeyMsg) (tea.Cmd, bool) {
+	view := &model.logbook
+
+	if view.filter.Focused() {
+		switch {
+		case msg.Type == tea.KeyCtrlC:
+			return nil, false
+		case key.Matches(msg, model.keys.Back), key.Matches(msg, model.keys.Enter):
+			view.filter.Blur()
+		default:
+			var cmd tea.Cmd
+			view.filter, cmd = view.filter.Update(msg)
+			view.refresh()
+			return cmd, true
+		}
+		return nil, true
+	}
+
+	switch {
+	case key.Matches(msg, logbookKeys.Sort):
+		view.sortField = view.sortField.Next()
+		view.refresh()
+		model.statusMessage = fmt.Sprintf("Logbook sorted by %s", view.sortField)
+	case key.Matches(msg, logbookKeys.Reverse):
+		view.descending = !view.descending
+		view.refresh()
+	case key.Matches(msg, logbookKeys.Filter):
+		return view.filter.Focus(), true
+	case key.Matches(msg, model.keys.Back):
+		view.filter.SetValue("")
+		view.refresh()
+	case key.Matches(msg, logbookKeys.ExportCSV):
+		return model.exportLogbook("csv"), true
+	case key.Matches(msg, logbookKeys.ExportJSON):
+		return model.exportLogbook("json"), true
+	case key.Matches(msg, logbookKeys.Refresh):
+		model.statusMessage = "Refreshing logbook..."
+		return model.refreshLogbook(), true
+	case key.Matches(msg, model.keys.Up), key.Matches(msg, model.keys.Down):
+		var cmd tea.Cmd
+		view.table, cmd = view.table.Update(msg)
+		return cmd, true
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+func (model *Model) loadLogbook() tea.Cmd {
+	return func() tea.Msg {
+		entries, err := model.flightService.GetLogbook()
+		return logbookLoadedMsg{entries: entries, error: err}
+	}
+}
+
+func (model *Model) refreshLogbook() tea.Cmd {
+	return func() tea.Msg {
+		updated, err := model.flightService.RefreshLogbook(model.ctx)
+		if err != nil {
+			return logbookLoadedMsg{error: err}
+		}
+		entries, err := model.flightService.GetLogbook()
+		return logbookLoadedMsg{entries: entries, updated: updated, refreshed: true, error: err}
+	}
+}
+
+func (model *Model) exportLogbook(format string) tea.Cmd {
+	entries := append([]logbook.Entry(nil), model.logbook.visible...)
+	return func() tea.Msg {
+		dir := model.config.Get().ExportsDir()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return logbookExportedMsg{error: fmt.Errorf("failed to create export directory: %w", err)}
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("logbook-%s.%s", time.Now().Format("20060102-150405"), format))
+		file, err := os.Create(path)
+		if err != nil {
+			return logbookExportedMsg{error: fmt.Errorf("failed to create export file: %w", err)}
+		}
+		defer file.Close()
+
+		if format == "json" {
+			err = logbook.WriteJSON(file, entries)
+		} else {
+			err = logbook.WriteCSV(file, entries)
+		}
+		return logbookExportedMsg{path: path, error: err}
+	}
+}
+
+func (model *Model) renderLogbook(s string) string {
+	view := &model.logbook
+
+	s += styleHeading.Render("Logbook") + "\n"
+	if len(view.entries) == 0 {
+		return s + styleSecondary.Render("No flights logged yet") + "\n"
+	}
+
+	direction := "ascending"
+	if view.descending {
+		direction = "descending"
+	}
+	s += fmt.Sprintf("%d of %d flights, sorted by %s (%s)\n",
+		len(view.visible), len(view.entries), view.sortField, direction)
+	if view.filter.Focused() || view.filter.Value() != "" {
+		s += view.filter.View() + "\n"
+	}
+	s += view.table.View() + "\n"
+
+	s += renderTotals("Top aircraft", logbook.TotalsByAircraft(view.visible))
+	s += renderTotals("Top airports", logbook.TotalsByAirport(view.visible))
+	return s
+}
+
+func renderTotals(heading string, totals []logbook.Total) string {
+	s := styleHeading.Render(heading) + "\n"
+	if len(totals) == 0 {
+		return s + styleSecondary.Render("(none)") + "\n"
+	}
+
+	for _, total := range totals[:min(5, len(totals))] {
+		s += stylePairKey.Render(total.Key)
+		s += fmt.Sprintf("%d flights, %d:%02d, %.0f nm\n",
+			total.Flights, total.FlightTime/60, total.FlightTime%60, total.Distance)
+	}
+	return s
+}
@@ -0,0 +1,273 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
+)
+
+const (
+	logsPaneRecords = iota
+	logsPaneCalls
+)
+
+type logsKeyMap struct {
+	Pane     key.Binding
+	Level    key.Binding
+	Search   key.Binding
+	PageUp   key.Binding
+	PageDown key.Binding
+	Top      key.Binding
+	Bottom   key.Binding
+}
+
+var logsKeys = logsKeyMap{
+	Pane: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "switch between logs and API calls"),
+	),
+	Level: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "change level"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	PageUp: key.NewBinding(
+		key.WithKeys("pgup"),
+	),
+	PageDown: key.NewBinding(
+		key.WithKeys("pgdown"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("home", "g"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("end", "G"),
+	),
+}
+
+// logsView shows recent log records and API calls, which would otherwise be
+// hidden in the log file behind the TUI.
+type logsView struct {
+	buffer   *logging.Buffer
+	calls    *api.CallLog
+	pane     int
+	level    slog.Level
+	search   textinput.Model
+	viewport viewport.Model
+	shown    int
+	total    int
+}
+
+func newLogsView(buffer *logging.Buffer, calls *api.CallLog) logsView {
+	search := textinput.New()
+	search.Placeholder = "message, path or error"
+	search.Prompt = "/ "
+	search.CharLimit = 64
+
+	return logsView{
+		buffer:   buffer,
+		calls:    calls,
+		level:    slog.LevelDebug,
+		search:   search,
+		viewport: viewport.New(defaultWidth, 12),
+	}
+}
+
+func (view *logsView) setSize(width, height int) {
+	view.viewport.Width = width
+	view.viewport.Height = max(5, height)
+	view.refresh()
+}
+
+// refresh reloads the lines shown, following new ones if already scrolled to
+// the bottom.
+func (view *logsView) refresh() {
+	following := view.viewport.AtBottom()
+
+	var lines []string
+	if view.pane == logsPaneCalls {
+		lines = view.callLines()
+	} else {
+		lines = view.recordLines()
+	}
+	view.viewport.SetContent(strings.Join(lines, "\n"))
+
+	if following {
+		view.viewport.GotoBottom()
+	}
+}
+
+func (view *logsView) matches(text ...string) bool {
+	search := strings.ToLower(strings.TrimSpace(view.search.Value()))
+	if search == "" {
+		return true
+	}
+	return strings.Contains(strings.ToLower(strings.Join(text, " ")), search)
+}
+
+func (view *logsView) recordLines() []string {
+	var entries []logging.Entry
+	if view.buffer != nil {
+		entries = view.buffer.Entries()
+	}
+
+	view.total = len(entries)
+	view.shown = 0
+	lines := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Level < view.level || !view.matches(entry.Message, entry.Attrs) {
+			continue
+		}
+		view.shown++
+
+		level := fmt.Sprintf("%-5s", entry.Level)
+		switch {
+		case entry.Level >= slog.LevelWarn:
+			level = styleAttention.Render(level)
+		case entry.Level < slog.LevelInfo:
+			level = lipgloss.NewStyle().Foreground(colourSubtle).Render(level)
+		}
+		line := fmt.Sprintf("%s %s %s %s", entry.Time.Local().Format(time.TimeOnly), level, entry.Message,
+			lipgloss.NewStyle().Foreground(colourSubtle).Render(entry.Attrs))
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(view.viewport.Width).Render(line))
+	}
+	return lines
+}
+
+func (view *logsView) callLines() []string {
+	var calls []api.Call
+	if view.calls != nil {
+		calls = view.calls.Calls()
+	}
+
+	view.total = len(calls)
+	view.shown = 0
+	lines := make([]string, 0, len(calls))
+	for _, call := range calls {
+		if !view.matches(call.Method, call.Path, call.Error) {
+			continue
+		}
+		view.shown++
+
+		status := fmt.Sprintf("%3d", call.Status)
+		if call.Status == 0 {
+			status = "---"
+		}
+		if call.Error != "" {
+			status = styleAttention.Render(status)
+		}
+		line := fmt.Sprintf("%s %-6s %s %6dms %s", call.Time.Local().Format(time.TimeOnly), call.Method, status,
+			call.Duration.Milliseconds(), call.Path)
+		lines = append(lines, lipgloss.NewStyle().MaxWidth(view.viewport.Width).Render(line))
+
+		// Error bodies are shown in full, as they are usually why the call is
+		// being looked at
+		if call.Error != "" {
+			lines = append(lines, styleAttention.Copy().
+				Width(max(10, view.viewport.Width-2)).
+				MarginLeft(2).
+				Render(strings.Join(strings.Fields(call.Error), " ")))
+		}
+	}
+	return lines
+}
+
+func (model *Model) handleKeyLogs(msg tea.KeyMsg) (tea.Cmd, bool) {
+	view := &model.logs
+
+	if view.search.Focused() {
+		switch {
+		case msg.Type == tea.KeyCtrlC:
+			return nil, false
+		case key.Matches(msg, model.keys.Back), key.Matches(msg, model.keys.Enter):
+			view.search.Blur()
+		default:
+			var cmd tea.Cmd
+			view.search, cmd = view.search.Update(msg)
+			view.refresh()
+			return cmd, true
+		}
+		return nil, true
+	}
+
+	switch {
+	case key.Matches(msg, logsKeys.Pane):
+		view.pane = (view.pane + 1) % 2
+		view.refresh()
+		view.viewport.GotoBottom()
+	case key.Matches(msg, logsKeys.Level):
+		view.level = nextLevel(view.level)
+		view.refresh()
+		model.statusMessage = fmt.Sprintf("Showing %s logs and above", strings.ToLower(view.level.String()))
+	case key.Matches(msg, logsKeys.Search):
+		return view.search.Focus(), true
+	case key.Matches(msg, model.keys.Back):
+		view.search.SetValue("")
+		view.refresh()
+	case key.Matches(msg, model.keys.Up):
+		view.viewport.LineUp(1)
+	case key.Matches(msg, model.keys.Down):
+		view.viewport.LineDown(1)
+	case key.Matches(msg, logsKeys.PageUp):
+		view.viewport.ViewUp()
+	case key.Matches(msg, logsKeys.PageDown):
+		view.viewport.ViewDown()
+	case key.Matches(msg, logsKeys.Top):
+		view.viewport.GotoTop()
+	case key.Matches(msg, logsKeys.Bottom):
+		view.viewport.GotoBottom()
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+func nextLevel(level slog.Level) slog.Level {
+	switch {
+	case level < slog.LevelInfo:
+		return slog.LevelInfo
+	case level < slog.LevelWarn:
+		return slog.LevelWarn
+	case level < slog.LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelDebug
+}
+
+func (model *Model) renderLogs(s string) string {
+	view := &model.logs
+
+	if view.pane == logsPaneCalls {
+		s += styleHeading.Render("API calls") + "\n"
+		s += fmt.Sprintf("%d of %d recent calls\n", view.shown, view.total)
+	} else {
+		s += styleHeading.Render("Logs") + "\n"
+		s += fmt.Sprintf("%d of %d recent records, %s and above\n",
+			view.shown, view.total, strings.ToLower(view.level.String()))
+	}
+	if view.search.Focused() || view.search.Value() != "" {
+		s += view.search.View() + "\n"
+	}
+
+	if view.total == 0 {
+		return s + styleSecondary.Render("(none)") + "\n"
+	}
+	s += view.viewport.View() + "\n"
+	if !view.viewport.AtBottom() {
+		s += styleSecondary.Render(fmt.Sprintf("%3.0f%%, press G to follow", view.viewport.ScrollPercent()*100)) + "\n"
+	}
+	return s
+}
@@ -0,0 +1,178 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+// mapRanges are the zoom levels, in nm from the centre to the nearest edge of
+// the map. Zero fits everything in.
+var mapRanges = []float64{0, 1000, 500, 250, 100, 50, 25, 10, 5}
+
+type mapKeyMap struct {
+	ZoomIn  key.Binding
+	ZoomOut key.Binding
+	Centre  key.Binding
+}
+
+var mapKeys = mapKeyMap{
+	ZoomIn: key.NewBinding(
+		key.WithKeys("+", "="),
+		key.WithHelp("+", "zoom in"),
+	),
+	ZoomOut: key.NewBinding(
+		key.WithKeys("-"),
+		key.WithHelp("-", "zoom out"),
+	),
+	Centre: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "centre on aircraft"),
+	),
+}
+
+type mapPoint struct {
+	lat, lon float64
+}
+
+type mapView struct {
+	zoom   int
+	centre bool
+}
+
+func newMapView() mapView {
+	return mapView{centre: true}
+}
+
+func (model *Model) handleKeyMap(msg tea.KeyMsg) (tea.Cmd, bool) {
+	view := &model.mapView
+
+	switch {
+	case key.Matches(msg, mapKeys.ZoomIn):
+		view.zoom = min(view.zoom+1, len(mapRanges)-1)
+	case key.Matches(msg, mapKeys.ZoomOut):
+		view.zoom = max(view.zoom-1, 0)
+	case key.Matches(msg, mapKeys.Centre):
+		view.centre = !view.centre
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+func (model *Model) renderMap(s string, snapshot udp.MetricsSnapshot) string {
+	view := &model.mapView
+	s += styleHeading.Render("Map") + "\n"
+
+	points := model.flown()
+	flown := make([]mapPoint, len(points))
+	for i, point := range points {
+		flown[i] = mapPoint{lat: point.Lat, lon: point.Lon}
+	}
+	airports := flightplan.Airports(model.ofp)
+	aircraft := snapshot.LastPosition
+
+	// Everything shown is fitted in when zoomed out
+	var all []mapPoint
+	for _, fix := range model.route {
+		all = append(all, mapPoint{lat: fix.Lat, lon: fix.Lon})
+	}
+	for _, airport := range airports {
+		all = append(all, mapPoint{lat: airport.Lat, lon: airport.Lon})
+	}
+	all = append(all, flown...)
+	if aircraft != nil {
+		all = append(all, mapPoint{lat: aircraft.Lat, lon: aircraft.Lon})
+	}
+	if len(all) == 0 {
+		return s + styleSecondary.Render("Waiting for a position from X-Plane or a SimBrief OFP") + "\n"
+	}
+
+	canvas := newBrailleCanvas(model.contentWidth(), max(8, model.height-14), canvasPalette)
+	centre, nmPerDot := fitMap(all, canvas)
+	if mapRange := mapRanges[view.zoom]; mapRange > 0 {
+		nmPerDot = 2 * mapRange / float64(min(canvas.width(), canvas.height()))
+		if view.centre && aircraft != nil {
+			centre = mapPoint{lat: aircraft.Lat, lon: aircraft.Lon}
+		}
+	}
+
+	// Equirectangular, which is close enough over the distance of a flight
+	cosLat := math.Cos(centre.lat * math.Pi / 180)
+	project := func(point mapPoint) (int, int) {
+		x := float64(canvas.width())/2 + (point.lon-centre.lon)*60*cosLat/nmPerDot
+		y := float64(canvas.height())/2 - (point.lat-centre.lat)*60/nmPerDot
+		return int(math.Round(x)), int(math.Round(y))
+	}
+
+	for i := 1; i < len(model.route); i++ {
+		x0, y0 := project(mapPoint{lat: model.route[i-1].Lat, lon: model.route[i-1].Lon})
+		x1, y1 := project(mapPoint{lat: model.route[i].Lat, lon: model.route[i].Lon})
+		canvas.line(x0, y0, x1, y1, inkSubtle)
+	}
+	for i := range flown {
+		x1, y1 := project(flown[i])
+		if i == 0 {
+			canvas.set(x1, y1, inkPrimary)
+			continue
+		}
+		x0, y0 := project(flown[i-1])
+		canvas.line(x0, y0, x1, y1, inkPrimary)
+	}
+	for _, airport := range airports {
+		x, y := project(mapPoint{lat: airport.Lat, lon: airport.Lon})
+		canvas.label(x, y, "◆"+airport.Ident, inkStrong)
+	}
+	if aircraft != nil {
+		x, y := project(mapPoint{lat: aircraft.Lat, lon: aircraft.Lon})
+		canvas.label(x, y, headingArrow(aircraft.Heading), inkAttention)
+	}
+	s += canvas.String()
+
+	zoom := "fit"
+	if mapRange := mapRanges[view.zoom]; mapRange > 0 {
+		zoom = fmt.Sprintf("%.0f nm", mapRange)
+	}
+	centring := "off"
+	if view.centre {
+		centring = "on"
+	}
+	s += styleSecondary.Render(fmt.Sprintf("Range %s, %.1f nm per column, centring %s. Press +/- to zoom, c to centre",
+		zoom, 2*nmPerDot, centring)) + "\n"
+	return s
+}
+
+// fitMap finds the centre and scale that fit points on canvas, leaving a
+// margin for labels.
+func fitMap(points []mapPoint, canvas *brailleCanvas) (mapPoint, float64) {
+	minimum, maximum := points[0], points[0]
+	for _, point := range points[1:] {
+		minimum = mapPoint{lat: math.Min(minimum.lat, point.lat), lon: math.Min(minimum.lon, point.lon)}
+		maximum = mapPoint{lat: math.Max(maximum.lat, point.lat), lon: math.Max(maximum.lon, point.lon)}
+	}
+	centre := mapPoint{lat: (minimum.lat + maximum.lat) / 2, lon: (minimum.lon + maximum.lon) / 2}
+
+	cosLat := math.Cos(centre.lat * math.Pi / 180)
+	spanX := (maximum.lon - minimum.lon) * 60 * cosLat
+	spanY := (maximum.lat - minimum.lat) * 60
+	nmPerDot := math.Max(spanX/float64(max(1, canvas.width()-16)), spanY/float64(max(1, canvas.height()-8)))
+	// A single point still needs a scale
+	return centre, math.Max(nmPerDot, 0.05)
+}
+
+func headingArrow(heading *float64) string {
+	if heading == nil {
+		return "✈"
+	}
+	arrows := []string{"↑", "↗", "→", "↘", "↓", "↙", "←", "↖"}
+	index := int(math.Round(*heading/45)) % 8
+	if index < 0 {
+		index += 8
+	}
+	return arrows[index]
+}
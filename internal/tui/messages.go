@@ -2,6 +2,9 @@ package tui
 
 import (
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/logbook"
 	"github.com/julietrb1/phpvms-xplane/models"
 	"time"
 )
@@ -9,7 +12,7 @@ import (
 type tickMsg time.Time
 
 type aircraftListUpdatedMsg struct {
-	items []list.Item
+	fleet []models.AircraftFleet
 }
 
 type airlineListUpdatedMsg struct {
@@ -34,12 +37,23 @@ type fetchSimbriefOFPMsg struct {
 	blockFuel       int
 	flightTime      int
 	route           string
+	ofp             *models.SimBriefOFP
 }
 
 type fetchSimbriefOFPErrorMsg struct {
 	err error
 }
 
+type fmsExportedMsg struct {
+	path  string
+	error error
+}
+
+type trackExportedMsg struct {
+	dir   string
+	error error
+}
+
 type prefileDataMsg struct {
 	pirepID *string
 	error   error
@@ -49,11 +63,61 @@ type pirepCancelledMsg struct {
 	error error
 }
 
+type filingDraftMsg struct {
+	data       api.FilePIREPRequest
+	fuelUsedKg int
+	zfwKg      int
+	passengers int
+	fares      []models.Fare
+	error      error
+}
+
 type pirepFiledMsg struct {
 	error error
 }
 
-type fetchInProgressPIREPMsg struct {
-	error error
+type pirepsLoadedMsg struct {
+	pireps []models.ListedPIREP
+	error  error
+}
+
+type pirepResumedMsg struct {
 	pirep models.ListedPIREP
+	error error
+}
+
+type logbookLoadedMsg struct {
+	entries   []logbook.Entry
+	updated   int
+	refreshed bool
+	error     error
+}
+
+type logbookExportedMsg struct {
+	path  string
+	error error
+}
+
+type airportCheckedMsg struct {
+	icao        string
+	airport     models.Airport
+	suggestions []models.Airport
+	error       error
+}
+
+type airportSearchDueMsg struct {
+	seq int
+}
+
+type airportSearchResultsMsg struct {
+	seq      int
+	airports []models.Airport
+}
+
+type reloadRequestedMsg struct{}
+
+type configLoadedMsg struct {
+	next    *config.Config
+	changes config.Changes
+	error   error
 }
@@ -2,7 +2,6 @@ package tui
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -19,16 +18,18 @@ import (
 	"github.com/charmbracelet/lipgloss"
 
 	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/control"
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
 	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
 	"github.com/julietrb1/phpvms-xplane/internal/udp"
+	"github.com/julietrb1/phpvms-xplane/models"
 )
 
-const nominalWidth = 60
-
 var (
 	styleTitle = lipgloss.NewStyle().
 			Bold(true).
-			Width(nominalWidth).
 			Background(lipgloss.Color("62")).
 			Align(lipgloss.Center)
 	colourPrimary  = lipgloss.Color("205")
@@ -38,7 +39,6 @@ var (
 			Foreground(lipgloss.Color("15")).
 			Background(lipgloss.Color("61")).
 			Padding(0, 1).
-			Width(nominalWidth).
 			Align(lipgloss.Center)
 	colourBorder     = lipgloss.Color("240")
 	colourSubtle     = lipgloss.Color("245")
@@ -56,44 +56,49 @@ var (
 	styleInactivePirepBar = lipgloss.NewStyle().
 				Background(lipgloss.Color("242")).
 				Foreground(lipgloss.Color("15")).
-				Width(nominalWidth).
 				Align(lipgloss.Center).
 				MarginBottom(1)
 	styleActivePirepBar = lipgloss.NewStyle().
 				Background(lipgloss.Color("34")).
 				Foreground(lipgloss.Color("15")).
-				Width(nominalWidth).
 				Align(lipgloss.Center).
 				MarginBottom(1)
 )
 
 type keyMap struct {
-	Help             key.Binding
-	Quit             key.Binding
-	Start            key.Binding
-	File             key.Binding
-	Cancel           key.Binding
-	Reset            key.Binding
-	Tab              key.Binding
-	ShiftTab         key.Binding
-	Enter            key.Binding
-	Back             key.Binding
-	SelectAircraft   key.Binding
-	SelectAirline    key.Binding
-	FetchSimbrief    key.Binding
-	FetchActivePIREP key.Binding
+	Help           key.Binding
+	Quit           key.Binding
+	Start          key.Binding
+	File           key.Binding
+	Cancel         key.Binding
+	Reset          key.Binding
+	Tab            key.Binding
+	ShiftTab       key.Binding
+	Enter          key.Binding
+	Back           key.Binding
+	SelectAircraft key.Binding
+	SelectAirline  key.Binding
+	FetchSimbrief  key.Binding
+	PIREPs         key.Binding
+	ExportFMS      key.Binding
+	ExportTrack    key.Binding
+	NextTab        key.Binding
+	PrevTab        key.Binding
+	JumpTab        key.Binding
+	Up             key.Binding
+	Down           key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Help, k.Quit, k.Start, k.File, k.Cancel, k.Reset, k.SelectAircraft, k.SelectAirline, k.FetchSimbrief, k.FetchActivePIREP}
+	return []key.Binding{k.Help, k.Quit, k.NextTab, k.Start, k.File, k.Cancel, k.Reset, k.SelectAircraft, k.SelectAirline, k.FetchSimbrief, k.PIREPs, k.ExportFMS, k.ExportTrack}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Help, k.Quit},
+		{k.Help, k.Quit, k.NextTab, k.PrevTab, k.JumpTab},
 		{k.Start, k.File, k.Cancel, k.Reset},
 		{k.Enter, k.Back},
-		{k.SelectAircraft, k.SelectAirline, k.FetchSimbrief, k.FetchActivePIREP},
+		{k.SelectAircraft, k.SelectAirline, k.FetchSimbrief, k.PIREPs, k.ExportFMS, k.ExportTrack},
 	}
 }
 
@@ -146,45 +151,96 @@ var keys = keyMap{
 		key.WithKeys("o"),
 		key.WithHelp("o", "fetch SimBrief OFP"),
 	),
-	FetchActivePIREP: key.NewBinding(
+	PIREPs: key.NewBinding(
 		key.WithKeys("e"),
-		key.WithHelp("e", "fetch pending PIREP"),
+		key.WithHelp("e", "PIREPs"),
+	),
+	ExportFMS: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "export FMS plan"),
+	),
+	ExportTrack: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "export flight track"),
+	),
+	NextTab: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next tab"),
+	),
+	PrevTab: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous tab"),
+	),
+	JumpTab: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7"),
+		key.WithHelp("1-7", "go to tab"),
+	),
+	Up: key.NewBinding(
+		key.WithKeys("up"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down"),
 	),
 }
 
 type Model struct {
-	ctx                context.Context
-	cancel             context.CancelFunc
-	metrics            *udp.Metrics
-	flightService      *service.FlightService
-	logger             *slog.Logger
-	help               help.Model
-	spinner            spinner.Model
-	keys               keyMap
-	width              int
-	height             int
-	ready              bool
-	showHelp           bool
-	lastUpdate         time.Time
-	statusMessage      string
-	activeTab          int
-	flightInputs       []textinput.Model
-	flightTable        table.Model
-	aircraftList       list.Model
-	showAircraftList   bool
+	ctx                 context.Context
+	cancel              context.CancelFunc
+	metrics             *udp.Metrics
+	flightService       *service.FlightService
+	control             *control.Local
+	logger              *slog.Logger
+	help                help.Model
+	spinner             spinner.Model
+	keys                keyMap
+	width               int
+	height              int
+	ready               bool
+	showHelp            bool
+	lastUpdate          time.Time
+	statusMessage       string
+	activeTab           int
+	flightInputs        []textinput.Model
+	flightTable         table.Model
+	aircraftList        list.Model
+	showAircraftList    bool
+	fleet               []models.AircraftFleet
+	aircraftAtDeparture bool
+	// simAircraft is the tail number and type X-Plane last reported
+	simAircraft string
+	// autoSelectFrom is what to say the aircraft and airline were selected
+	// from, while they're waiting to be
+	autoSelectFrom     string
 	selectedAircraftID int
 	airlineList        list.Model
 	showAirlineList    bool
 	selectedAirlineID  int
-	config             *config.Config
+	ofp                *models.SimBriefOFP
+	route              []flightplan.Fix
+	history            []track.Point
+	flownTrack         *track.Track
+	chartWindow        int
+	logbook            logbookView
+	logs               logsView
+	mapView            mapView
+	review             filingReview
+	prefile            prefileOptions
+	pirepPicker        pirepPicker
+	airportChecks      map[string]airportCheck
+	airportSearch      airportSearch
+	config             *config.Live
+	reloader           Reloader
+	// pendingReload is a reloaded configuration waiting for r to be pressed
+	// again, as it would leave the active PIREP behind
+	pendingReload *configLoadedMsg
 }
 
-func NewModel(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metrics, flightService *service.FlightService, cfg *config.Config, logger *slog.Logger) Model {
+func NewModel(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metrics, flightService *service.FlightService, cfg *config.Live, reloader Reloader, logBuffer *logging.Buffer, logger *slog.Logger) Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = lipgloss.NewStyle().Foreground(colourPrimary)
 
-	flightInputs := make([]textinput.Model, 10)
+	flightInputs := make([]textinput.Model, 11)
 	for i := range flightInputs {
 		t := textinput.New()
 		t.CharLimit = 32
@@ -227,6 +283,9 @@ func NewModel(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metri
 		case 9:
 			t.Placeholder = "e.g. DCT"
 			t.CharLimit = 200
+		case customFieldsInput:
+			t.Placeholder = "e.g. Gate=12; Stand=B4"
+			t.CharLimit = 200
 		}
 
 		flightInputs[i] = t
@@ -265,7 +324,11 @@ func NewModel(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metri
 	aircraftList.Title = "Select Aircraft"
 	aircraftList.SetShowStatusBar(false)
 	aircraftList.SetFilteringEnabled(true)
+	aircraftList.Filter = matchAllTerms
 	aircraftList.Styles.Title = styleTitle
+	aircraftList.AdditionalShortHelpKeys = func() []key.Binding {
+		return []key.Binding{aircraftKeys.AtDeparture}
+	}
 
 	airlineDelegate := AirlineDelegate{}
 	airlineList := list.New([]list.Item{}, airlineDelegate, 0, 0)
@@ -278,8 +341,8 @@ func NewModel(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metri
 	selectedAirlineID := 0
 
 	if cfg != nil {
-		selectedAircraftID = cfg.SelectedAircraftID
-		selectedAirlineID = cfg.SelectedAirlineID
+		selectedAircraftID = cfg.Get().SelectedAircraftID
+		selectedAirlineID = cfg.Get().SelectedAirlineID
 	}
 
 	return Model{
@@ -287,6 +350,7 @@ func NewModel(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metri
 		cancel:             cancel,
 		metrics:            metrics,
 		flightService:      flightService,
+		control:            control.NewLocal(flightService, metrics),
 		logger:             logger,
 		help:               help.New(),
 		spinner:            s,
@@ -300,7 +364,16 @@ func NewModel(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metri
 		airlineList:        airlineList,
 		showAirlineList:    false,
 		selectedAirlineID:  selectedAirlineID,
+		logbook:            newLogbookView(),
+		pirepPicker:        newPIREPPicker(),
+		airportChecks:      map[string]airportCheck{},
+		airportSearch:      newAirportSearch(),
+		logs:               newLogsView(logBuffer, flightService.Client.Calls),
+		mapView:            newMapView(),
+		review:             newFilingReview(),
+		prefile:            newPrefileOptions(cfg.Get(), logger),
 		config:             cfg,
+		reloader:           reloader,
 		statusMessage:      "Hi!",
 	}
 }
@@ -311,27 +384,45 @@ func (model *Model) Init() tea.Cmd {
 		tickCmd(),
 		model.fetchAircraftList(),
 		model.fetchAirlineList(),
+		model.loadLogbook(),
+		model.waitForReload(),
 	)
 }
 
 func (model *Model) handleKeyAircraftList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	// Typing a filter takes every key but ctrl+c
+	filtering := model.aircraftList.FilterState() == list.Filtering
+
 	switch {
-	case key.Matches(msg, model.keys.Quit):
+	case msg.Type == tea.KeyCtrlC, key.Matches(msg, model.keys.Quit) && !filtering:
 		model.cancel()
 		return model, tea.Quit
-	case key.Matches(msg, model.keys.Back):
+	case key.Matches(msg, model.keys.Back) && model.aircraftList.FilterState() == list.Unfiltered:
 		model.showAircraftList = false
 		return model, nil
-	case key.Matches(msg, model.keys.Enter):
-		id := GetSelectedAircraftID(model.aircraftList)
-		if id > 0 {
+	case key.Matches(msg, aircraftKeys.AtDeparture) && !filtering:
+		if len(model.flightInputs[1].Value()) != 4 {
+			return model, model.aircraftList.NewStatusMessage("Enter a departure first")
+		}
+		model.aircraftAtDeparture = !model.aircraftAtDeparture
+		model.refreshAircraftList()
+		return model, nil
+	case key.Matches(msg, model.keys.Enter) && !filtering:
+		aircraft, ok := GetSelectedAircraft(model.aircraftList)
+		if ok && !aircraft.Available() {
+			return model, model.aircraftList.NewStatusMessage(
+				styleAttention.Render(fmt.Sprintf("%s is %s", aircraft.Registration, aircraft.StatusText())))
+		}
+		if id := aircraft.ID; ok && id > 0 {
 			model.selectedAircraftID = id
 			model.showAircraftList = false
 			model.statusMessage = fmt.Sprintf("Selected aircraft ID: %d", id)
 
 			if model.config != nil {
-				model.config.SelectedAircraftID = id
-				if err := model.config.SavePreferences(""); err != nil {
+				cfg := model.config.Update(func(cfg *config.Config) {
+					cfg.SelectedAircraftID = id
+				})
+				if err := cfg.SavePreferences(""); err != nil {
 					model.logger.Error("Failed to save preferences", "error", err)
 				}
 			}
@@ -360,8 +451,10 @@ func (model *Model) handleKeyAirlineList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			model.statusMessage = fmt.Sprintf("Selected airline ID: %d", id)
 
 			if model.config != nil {
-				model.config.SelectedAirlineID = id
-				if err := model.config.SavePreferences(""); err != nil {
+				cfg := model.config.Update(func(cfg *config.Config) {
+					cfg.SelectedAirlineID = id
+				})
+				if err := cfg.SavePreferences(""); err != nil {
 					model.logger.Error("Failed to save preferences", "error", err)
 				}
 			}
@@ -387,8 +480,20 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return model.handleKeyAirlineList(msg)
 		}
 
+		if model.review.show {
+			return model.handleKeyReview(msg)
+		}
+
+		if model.pirepPicker.show {
+			return model.handleKeyPIREPPicker(msg)
+		}
+
+		if model.airportSearch.show {
+			return model.handleKeyAirportSearch(msg)
+		}
+
 		var focusedFlightInput *int
-		if model.activeTab == 0 {
+		if model.activeTab == tabFlight {
 			for i := range model.flightInputs {
 				if model.flightInputs[i].Focused() {
 					focusedFlightInput = &i
@@ -396,6 +501,12 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+			if focusedFlightInput != nil && key.Matches(msg, airportKeys.Search) {
+				if _, ok := airportInputs[*focusedFlightInput]; ok {
+					return model, model.openAirportSearch(*focusedFlightInput)
+				}
+			}
+
 			if focusedFlightInput != nil && key.Matches(msg, model.keys.Back) {
 				model.flightInputs[*focusedFlightInput].Blur()
 				break
@@ -404,12 +515,54 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if model.activeTab == tabFlight {
+			if cmd, handled := model.handleKeyPrefile(msg); handled {
+				return model, cmd
+			}
+		}
+
+		if model.activeTab == tabLive {
+			if cmd, handled := model.handleKeyLive(msg); handled {
+				return model, cmd
+			}
+		}
+
+		if model.activeTab == tabLogbook {
+			if cmd, handled := model.handleKeyLogbook(msg); handled {
+				return model, cmd
+			}
+		}
+
+		if model.activeTab == tabLogs {
+			if cmd, handled := model.handleKeyLogs(msg); handled {
+				return model, cmd
+			}
+		}
+
+		if model.activeTab == tabMap {
+			if cmd, handled := model.handleKeyMap(msg); handled {
+				return model, cmd
+			}
+		}
+
+		if model.activeTab == tabSettings {
+			if cmd, handled := model.handleKeySettings(msg); handled {
+				return model, cmd
+			}
+		}
+
 		switch {
 		case key.Matches(msg, model.keys.Quit):
 			model.cancel()
 			return model, tea.Quit
 		case key.Matches(msg, model.keys.Help):
 			model.showHelp = !model.showHelp
+		case key.Matches(msg, model.keys.NextTab):
+			cmds = append(cmds, model.setTab((model.activeTab+1)%tabCount))
+		case key.Matches(msg, model.keys.PrevTab):
+			cmds = append(cmds, model.setTab((model.activeTab+tabCount-1)%tabCount))
+		case key.Matches(msg, model.keys.JumpTab):
+			cmds = append(cmds, model.setTab(int(msg.String()[0]-'1')))
 		case key.Matches(msg, model.keys.SelectAircraft):
 			model.showAircraftList = true
 			if len(model.aircraftList.Items()) == 0 {
@@ -421,17 +574,27 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return model, model.fetchAirlineList()
 			}
 		case key.Matches(msg, model.keys.FetchSimbrief):
-			if model.config.SimbriefUserID != "" {
+			if model.config.Get().SimbriefUserID != "" {
 				model.statusMessage = "Fetching SimBrief OFP..."
 				return model, model.fetchSimbriefData()
 			} else {
 				model.statusMessage = "Set SIMBRIEF_USER_ID"
 			}
-		case key.Matches(msg, model.keys.FetchActivePIREP):
-			model.statusMessage = "Fetching active PIREP..."
-			return model, model.fetchInProgressPIREP()
+		case key.Matches(msg, model.keys.ExportFMS):
+			if model.ofp == nil {
+				model.statusMessage = "Fetch a SimBrief OFP first"
+			} else {
+				model.statusMessage = "Exporting FMS plan..."
+				return model, model.exportFMSPlan()
+			}
+		case key.Matches(msg, model.keys.ExportTrack):
+			model.statusMessage = "Exporting flight track..."
+			return model, model.exportTrack()
+		case key.Matches(msg, model.keys.PIREPs):
+			model.statusMessage = ""
+			return model, model.openPIREPPicker()
 		case key.Matches(msg, model.keys.Start):
-			if model.activeTab == 0 {
+			if model.activeTab == tabFlight {
 				model.statusMessage = "Prefiling PIREP..."
 				cmd := model.startPIREP()
 				if cmd != nil {
@@ -439,15 +602,12 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case key.Matches(msg, model.keys.File):
-			if model.activeTab == 0 {
-				model.statusMessage = "Filing PIREP..."
-				cmd := model.filePIREP()
-				if cmd != nil {
-					cmds = append(cmds, cmd)
-				}
+			if model.activeTab == tabFlight {
+				model.statusMessage = "Preparing PIREP for review..."
+				cmds = append(cmds, model.draftPIREP())
 			}
 		case key.Matches(msg, model.keys.Cancel):
-			if model.activeTab == 0 {
+			if model.activeTab == tabFlight {
 				model.statusMessage = "Cancelling PIREP..."
 				cmd := model.cancelPIREP()
 				if cmd != nil {
@@ -455,12 +615,15 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case key.Matches(msg, model.keys.Reset):
-			if model.activeTab == 0 {
-				model.flightService.ResetActivePirep()
-				model.statusMessage = "Active PIREP reset"
+			if model.activeTab == tabFlight {
+				if _, err := model.control.Reset(model.ctx); err != nil {
+					model.statusMessage = fmt.Sprintf("Failed to reset PIREP: %v", err)
+				} else {
+					model.statusMessage = "Active PIREP reset"
+				}
 			}
 		case key.Matches(msg, model.keys.Tab):
-			if model.activeTab == 0 {
+			if model.activeTab == tabFlight {
 				if focusedFlightInput != nil {
 					model.flightInputs[*focusedFlightInput].Blur()
 					if *focusedFlightInput < len(model.flightInputs)-1 {
@@ -471,7 +634,7 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		case key.Matches(msg, model.keys.ShiftTab):
-			if model.activeTab == 0 {
+			if model.activeTab == tabFlight {
 				if focusedFlightInput != nil {
 					model.flightInputs[*focusedFlightInput].Blur()
 					if *focusedFlightInput > 0 {
@@ -492,9 +655,17 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		top, right, bottom, left := 2, 2, 2, 2
 		model.aircraftList.SetSize(msg.Width-left-right, msg.Height-top-bottom)
 		model.airlineList.SetSize(msg.Width-left-right, msg.Height-top-bottom)
+		model.logs.setSize(msg.Width, msg.Height-12)
+		model.logbook.table.SetHeight(max(5, min(30, msg.Height-26)))
+		model.pirepPicker.table.SetHeight(max(5, min(30, msg.Height-10)))
 
 	case tickMsg:
 		model.lastUpdate = time.Time(msg)
+		model.recordHistory(model.metrics.Snapshot())
+		model.checkSimAircraft()
+		if model.activeTab == tabLogs {
+			model.logs.refresh()
+		}
 		cmds = append(cmds, tickCmd())
 
 	case spinner.TickMsg:
@@ -503,25 +674,33 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 
 	case aircraftListUpdatedMsg:
-		model.aircraftList.SetItems(msg.items)
-		if len(msg.items) > 0 {
-			model.statusMessage = fmt.Sprintf("Loaded %d aircraft", len(msg.items))
+		model.fleet = msg.fleet
+		model.refreshAircraftList()
+		if count := len(model.aircraftList.Items()); count > 0 {
+			model.statusMessage = fmt.Sprintf("Loaded %d aircraft in %d subfleets", count, len(msg.fleet))
 		} else {
 			model.statusMessage = "No aircraft found"
 		}
+		model.autoSelect()
 
 	case airlineListUpdatedMsg:
 		model.airlineList.SetItems(msg.items)
+		model.refreshAircraftList()
 		if len(msg.items) > 0 {
 			model.statusMessage = fmt.Sprintf("Loaded %d airlines", len(msg.items))
 		} else {
 			model.statusMessage = "No airlines found"
 		}
+		model.autoSelect()
 
 	case fetchSimbriefOFPMsg:
 		if msg.origin != "" && msg.destination != "" {
+			model.ofp = msg.ofp
+			model.route = flightplan.Route(msg.ofp)
 			model.populateFieldsFromSimbriefOFP(msg)
 			model.statusMessage = fmt.Sprintf("SimBrief OFP loaded: %s to %s", msg.origin, msg.destination)
+			model.autoSelectFrom = "the OFP"
+			model.autoSelect()
 		} else {
 			model.statusMessage = "Failed to extract origin, destination, alternate from SimBrief OFP"
 		}
@@ -531,38 +710,40 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			model.statusMessage = msg.err.Error()
 		}
 
-	case fetchInProgressPIREPMsg:
+	case fmsExportedMsg:
 		if msg.error != nil {
-			model.statusMessage = fmt.Sprintf("Failed to fetch active PIREP: %v", msg.error)
+			model.statusMessage = fmt.Sprintf("Failed to export FMS plan: %v", msg.error)
 		} else {
-			model.flightService.SetActivePirepID(msg.pirep.ID)
-			model.flightService.StateMachine.SetState(service.PIREPStateInProgress)
-			model.flightInputs[1].SetValue(msg.pirep.DptAirportID)
-			model.flightInputs[2].SetValue(msg.pirep.ArrAirportID)
-			if msg.pirep.AltAirportID != nil {
-				model.flightInputs[3].SetValue(*msg.pirep.AltAirportID)
-			} else {
-				model.flightInputs[3].SetValue("")
-			}
+			model.statusMessage = fmt.Sprintf("FMS plan written to %s", msg.path)
+		}
 
-			// TODO: Fix this
-			var fields map[string]string
-			if err := json.Unmarshal(msg.pirep.Fields, &fields); err != nil {
-				model.statusMessage = fmt.Sprintf("Failed to parse PIREP fields: %v", err)
-				break
-			}
-			if networkCallsign, exists := fields["Network Callsign Used"]; exists {
-				model.flightInputs[4].SetValue(networkCallsign)
-			} else {
-				model.flightInputs[4].SetValue("")
-			}
+	case reloadRequestedMsg:
+		model.statusMessage = "Reloading configuration..."
+		cmds = append(cmds, model.loadConfig(), model.waitForReload())
 
-			model.flightInputs[5].SetValue(strconv.Itoa(int(msg.pirep.Distance.Nmi)))
-			model.flightInputs[6].SetValue(strconv.Itoa(*msg.pirep.Level))
-			model.flightInputs[8].SetValue(strconv.Itoa(msg.pirep.FlightTime))
-			model.flightInputs[9].SetValue(msg.pirep.Route)
+	case configLoadedMsg:
+		cmds = append(cmds, model.configLoaded(msg))
 
-			model.statusMessage = "Active PIREP fetched"
+	case trackExportedMsg:
+		if msg.error != nil {
+			model.statusMessage = fmt.Sprintf("Failed to export track: %v", msg.error)
+		} else {
+			model.statusMessage = fmt.Sprintf("Track exported to %s", msg.dir)
+		}
+
+	case pirepsLoadedMsg:
+		model.pirepPicker.loading = false
+		model.pirepPicker.err = msg.error
+		if msg.error == nil {
+			model.pirepPicker.setPIREPs(msg.pireps)
+		}
+
+	case pirepResumedMsg:
+		if msg.error != nil {
+			model.statusMessage = fmt.Sprintf("Failed to resume PIREP: %v", msg.error)
+		} else {
+			model.populateFieldsFromPIREP(msg.pirep)
+			model.statusMessage = fmt.Sprintf("PIREP %s resumed", msg.pirep.ID)
 		}
 
 	case pirepCancelledMsg:
@@ -570,6 +751,18 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			model.statusMessage = fmt.Sprintf("Failed to cancel PIREP: %v", msg.error)
 		} else {
 			model.statusMessage = "PIREP cancelled"
+			cmds = append(cmds, model.loadLogbook())
+			if model.pirepPicker.show {
+				cmds = append(cmds, model.loadPIREPs())
+			}
+		}
+
+	case filingDraftMsg:
+		if msg.error != nil {
+			model.statusMessage = fmt.Sprintf("Failed to prepare PIREP: %v", msg.error)
+		} else {
+			model.statusMessage = "Review the PIREP, then press enter to file"
+			cmds = append(cmds, model.review.open(msg, model.weightUnit()))
 		}
 
 	case pirepFiledMsg:
@@ -577,6 +770,36 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			model.statusMessage = fmt.Sprintf("Failed to file PIREP: %v", msg.error)
 		} else {
 			model.statusMessage = "PIREP filed"
+			cmds = append(cmds, model.loadLogbook())
+		}
+
+	case logbookLoadedMsg:
+		if msg.error != nil {
+			model.statusMessage = fmt.Sprintf("Failed to load logbook: %v", msg.error)
+		} else {
+			model.logbook.setEntries(msg.entries)
+			if msg.refreshed {
+				model.statusMessage = fmt.Sprintf("Logbook refreshed, %d entries updated", msg.updated)
+			}
+		}
+
+	case logbookExportedMsg:
+		if msg.error != nil {
+			model.statusMessage = fmt.Sprintf("Failed to export logbook: %v", msg.error)
+		} else {
+			model.statusMessage = fmt.Sprintf("Logbook written to %s", msg.path)
+		}
+
+	case airportCheckedMsg:
+		model.airportChecks[msg.icao] = airportCheck{airport: msg.airport, suggestions: msg.suggestions, err: msg.error}
+
+	case airportSearchDueMsg:
+		cmds = append(cmds, model.runAirportSearch(msg.seq))
+
+	case airportSearchResultsMsg:
+		if msg.seq == model.airportSearch.seq {
+			model.airportSearch.results = msg.airports
+			model.airportSearch.cursor = 0
 		}
 
 	case prefileDataMsg:
@@ -584,10 +807,11 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			model.statusMessage = fmt.Sprintf("Failed to prefile PIREP: %v", msg.error)
 		} else {
 			model.statusMessage = "PIREP prefiled"
+			cmds = append(cmds, model.loadLogbook())
 		}
 	}
 
-	if model.activeTab == 0 && !model.showAircraftList && !model.showAirlineList {
+	if model.activeTab == tabFlight && !model.showAircraftList && !model.showAirlineList && !model.review.show && !model.pirepPicker.show && !model.airportSearch.show {
 		for i := range model.flightInputs {
 			var cmd tea.Cmd
 			model.flightInputs[i], cmd = model.flightInputs[i].Update(msg)
@@ -600,9 +824,29 @@ func (model *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
+	cmds = append(cmds, model.checkAirports())
+
+	if model.review.show {
+		var cmd tea.Cmd
+		model.review.inputs[model.review.focus], cmd = model.review.inputs[model.review.focus].Update(msg)
+		cmds = append(cmds, cmd)
+	}
+
 	return model, tea.Batch(cmds...)
 }
 
+// setTab switches to tab, loading anything it shows that may be out of date.
+func (model *Model) setTab(tab int) tea.Cmd {
+	model.activeTab = tab
+	switch tab {
+	case tabLogbook:
+		return model.loadLogbook()
+	case tabLogs:
+		model.logs.refresh()
+	}
+	return nil
+}
+
 func (model *Model) populateFieldsFromSimbriefOFP(msg fetchSimbriefOFPMsg) {
 	model.flightInputs[1].SetValue(msg.origin)
 	model.flightInputs[2].SetValue(msg.destination)
@@ -610,11 +854,30 @@ func (model *Model) populateFieldsFromSimbriefOFP(msg fetchSimbriefOFPMsg) {
 	model.flightInputs[4].SetValue(msg.flightNumber)
 	model.flightInputs[5].SetValue(strconv.Itoa(msg.planDist))
 	model.flightInputs[6].SetValue(strconv.Itoa(msg.initialAltitude))
-	model.flightInputs[7].SetValue(strconv.Itoa(msg.blockFuel))
+	model.flightInputs[7].SetValue(weightValue(msg.blockFuel, model.weightUnit()))
 	model.flightInputs[8].SetValue(strconv.Itoa(msg.flightTime))
 	model.flightInputs[9].SetValue(msg.route)
 }
 
+func (model *Model) populateFieldsFromPIREP(pirep models.ListedPIREP) {
+	model.flightInputs[1].SetValue(pirep.DptAirportID)
+	model.flightInputs[2].SetValue(pirep.ArrAirportID)
+	if pirep.AltAirportID != nil {
+		model.flightInputs[3].SetValue(*pirep.AltAirportID)
+	} else {
+		model.flightInputs[3].SetValue("")
+	}
+	model.flightInputs[4].SetValue(pirep.FieldValues()["Network Callsign Used"])
+	model.flightInputs[5].SetValue(strconv.Itoa(int(pirep.Distance.Nmi)))
+	if pirep.Level != nil {
+		model.flightInputs[6].SetValue(strconv.Itoa(*pirep.Level))
+	} else {
+		model.flightInputs[6].SetValue("")
+	}
+	model.flightInputs[8].SetValue(strconv.Itoa(pirep.FlightTime))
+	model.flightInputs[9].SetValue(pirep.Route)
+}
+
 func (model *Model) View() string {
 	if !model.ready {
 		return "Spinning prop..."
@@ -626,15 +889,44 @@ func (model *Model) View() string {
 	if model.showAirlineList {
 		return model.airlineList.View()
 	}
+	if model.review.show {
+		return model.renderReview()
+	}
+	if model.pirepPicker.show {
+		return model.renderPIREPPicker()
+	}
+	if model.airportSearch.show {
+		return model.renderAirportSearch()
+	}
 
 	snapshot := model.metrics.Snapshot()
 
 	var s string
 	s = model.renderTitle(s)
-	s = model.renderACARSTransmissions(s, snapshot)
-	s = model.renderUDPMetrics(s, snapshot)
-	s = model.renderFlightMetrics(s, snapshot)
-	s = model.renderFlightControls(s)
+	s += renderTabs(model.activeTab)
+	switch model.activeTab {
+	case tabLive:
+		s += model.columns(
+			model.renderFlightMetrics(model.renderUDPMetrics("", snapshot), snapshot),
+			model.renderPhaseTimeline(model.renderScore("")),
+		)
+		s = model.renderCharts(s)
+	case tabMap:
+		s = model.renderMap(s, snapshot)
+	case tabPlan:
+		s = model.renderPlan(s, snapshot)
+	case tabLogbook:
+		s = model.renderLogbook(s)
+	case tabLogs:
+		s = model.renderLogs(s)
+	case tabSettings:
+		s = model.renderSettings(s)
+	default:
+		s += model.columns(
+			model.renderFlightControls(""),
+			model.renderFlightLog(model.renderACARSTransmissions("", snapshot)),
+		)
+	}
 
 	helpView := model.help.View(model.keys)
 	if model.showHelp {
@@ -1,15 +1,20 @@
 package tui
 
 import (
-	"fmt"
 	"math"
 	"strconv"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/prefile"
 )
 
 func (model *Model) startPIREP() tea.Cmd {
+	if problem := model.airportProblem(); problem != "" {
+		model.statusMessage = problem
+		return nil
+	}
+
 	return func() tea.Msg {
 		if model.selectedAircraftID <= 0 {
 			model.statusMessage = "Aircraft required"
@@ -34,16 +39,22 @@ func (model *Model) startPIREP() tea.Cmd {
 			return nil
 		}
 
-		blockFuelKg, err := strconv.Atoi(model.flightInputs[7].Value())
+		blockFuel, err := strconv.Atoi(model.flightInputs[7].Value())
 		if err != nil {
 			model.statusMessage = "Invalid block fuel"
 			return nil
 		}
 
+		fields, err := model.prefileFields()
+		if err != nil {
+			model.statusMessage = err.Error()
+			return nil
+		}
+
 		data := api.PrefilePIREPRequest{
 			AirlineID:          model.selectedAirlineID,
 			AircraftID:         model.selectedAircraftID,
-			FlightType:         "J", // Scheduled Pax
+			FlightType:         prefile.FlightTypes[model.prefile.flightType].Code,
 			FlightNumber:       model.flightInputs[0].Value(),
 			DepartureAirportID: model.flightInputs[1].Value(),
 			ArrivalAirportID:   model.flightInputs[2].Value(),
@@ -52,47 +63,20 @@ func (model *Model) startPIREP() tea.Cmd {
 			Level:              level,
 			PlannedDistance:    plannedDistance,
 			PlannedFlightTime:  plannedFlightTime,
-			BlockFuel:          kgToLbs(blockFuelKg),
+			BlockFuel:          weightLbs(blockFuel, model.weightUnit()),
 			Source:             1,
-			SourceName:         "vmsacars",
-			Fields: map[string]interface{}{
-				"Simulator":              "X-Plane 12",
-				"Unlimited Fuel":         "Off",
-				"Network Online":         "VATSIM",
-				"Network Callsign Check": "0",
-				"Network Callsign Used":  model.flightInputs[4].Value(),
-			},
+			SourceName:         model.flightService.SourceName,
+			Fields:             fields,
 		}
 
-		pirepID, err := model.flightService.Prefile(model.ctx, data)
-		return prefileDataMsg{pirepID, err}
+		pirepID, err := model.control.Prefile(model.ctx, data)
+		return prefileDataMsg{&pirepID, err}
 	}
 }
 
-func (model *Model) filePIREP() tea.Cmd {
+func (model *Model) filePIREP(data api.FilePIREPRequest) tea.Cmd {
 	return func() tea.Msg {
-		snapshot := model.metrics.Snapshot()
-		lastFuel := *snapshot.LastFuel
-		if lastFuel == 0 {
-			return pirepFiledMsg{
-				error: fmt.Errorf("no last fuel level"),
-			}
-		}
-		startingFuel, err := strconv.Atoi(model.flightInputs[7].Value())
-		if err != nil {
-			return pirepFiledMsg{error: fmt.Errorf("invalid starting fuel")}
-		}
-		fuelUsedKg := int(math.Max(0, float64(startingFuel-lastFuel)))
-		if fuelUsedKg == 0 {
-			return pirepFiledMsg{error: fmt.Errorf("no fuel used")}
-		}
-		data := api.FilePIREPRequest{
-			FlightTime:  *snapshot.LastFlightTime,
-			FuelUsedLbs: kgToLbs(fuelUsedKg),
-			Distance:    *snapshot.LastDistance,
-		}
-		err = model.flightService.FileFlight(model.ctx, data)
-		return pirepFiledMsg{error: err}
+		return pirepFiledMsg{error: model.control.Submit(model.ctx, data)}
 	}
 }
 
@@ -102,6 +86,7 @@ func kgToLbs(fuelUsed int) int {
 
 func (model *Model) cancelPIREP() tea.Cmd {
 	return func() tea.Msg {
-		return pirepCancelledMsg{error: model.flightService.CancelFlight(model.ctx)}
+		_, err := model.control.Cancel(model.ctx, "")
+		return pirepCancelledMsg{error: err}
 	}
 }
@@ -0,0 +1,323 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julietrb1/phpvms-xplane/models"
+)
+
+// pirepFilters are the states the PIREP picker can be narrowed to. No states
+// shows every PIREP.
+var pirepFilters = []struct {
+	name   string
+	states []models.PirepState
+}{
+	{"all", nil},
+	{"in progress or paused", []models.PirepState{models.PIREPStateInProgress, models.PIREPStatePaused}},
+	{"pending", []models.PirepState{models.PIREPStatePending}},
+	{"accepted", []models.PirepState{models.PIREPStateAccepted}},
+	{"rejected", []models.PirepState{models.PIREPStateRejected}},
+	{"cancelled", []models.PirepState{models.PIREPStateCancelled}},
+}
+
+type pirepsKeyMap struct {
+	Filter  key.Binding
+	View    key.Binding
+	Cancel  key.Binding
+	Refresh key.Binding
+}
+
+var pirepsKeys = pirepsKeyMap{
+	Filter: key.NewBinding(
+		key.WithKeys("s"),
+		key.WithHelp("s", "filter by state"),
+	),
+	View: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "view details"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "cancel PIREP"),
+	),
+	Refresh: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "refresh"),
+	),
+}
+
+type pirepPicker struct {
+	show    bool
+	loading bool
+	err     error
+	pireps  []models.ListedPIREP
+	visible []models.ListedPIREP
+	table   table.Model
+	filter  int
+	detail  bool
+	// confirmCancel is the PIREP x was pressed on once; pressing it again
+	// cancels it
+	confirmCancel string
+}
+
+func newPIREPPicker() pirepPicker {
+	columns := []table.Column{
+		{Title: "Flight", Width: 8},
+		{Title: "Route", Width: 9},
+		{Title: "Aircraft", Width: 8},
+		{Title: "State", Width: 11},
+		{Title: "Created", Width: 16},
+		{Title: "Filed", Width: 10},
+	}
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithFocused(true),
+		table.WithHeight(12),
+	)
+	tableStyles := table.DefaultStyles()
+	tableStyles.Header = tableStyles.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderForeground(colourBorder).
+		BorderBottom(true).
+		Bold(false)
+	tableStyles.Selected = tableStyles.Selected.
+		Foreground(colourText).
+		Background(colourBackground).
+		Bold(false)
+	t.SetStyles(tableStyles)
+
+	return pirepPicker{table: t}
+}
+
+func (picker *pirepPicker) setPIREPs(pireps []models.ListedPIREP) {
+	picker.pireps = pireps
+	picker.refresh()
+}
+
+func (picker *pirepPicker) refresh() {
+	states := pirepFilters[picker.filter].states
+	picker.visible = picker.visible[:0]
+	for _, pirep := range picker.pireps {
+		if states == nil || containsState(states, models.PirepState(pirep.State)) {
+			picker.visible = append(picker.visible, pirep)
+		}
+	}
+
+	rows := make([]table.Row, 0, len(picker.visible))
+	for _, pirep := range picker.visible {
+		filed := "-"
+		if !pirep.SubmittedAt.IsZero() {
+			filed = pirep.SubmittedAt.Local().Format(time.DateOnly)
+		}
+		rows = append(rows, table.Row{
+			pirep.FlightNumber,
+			pirep.DptAirportID + "-" + pirep.ArrAirportID,
+			pirep.Aircraft.Registration,
+			models.PirepState(pirep.State).String(),
+			pirep.CreatedAt.Local().Format("2006-01-02 15:04"),
+			filed,
+		})
+	}
+	picker.table.SetRows(rows)
+	if picker.table.Cursor() >= len(rows) {
+		picker.table.SetCursor(max(0, len(rows)-1))
+	}
+}
+
+func (picker *pirepPicker) selected() (models.ListedPIREP, bool) {
+	cursor := picker.table.Cursor()
+	if cursor < 0 || cursor >= len(picker.visible) {
+		return models.ListedPIREP{}, false
+	}
+	return picker.visible[cursor], true
+}
+
+func containsState(states []models.PirepState, state models.PirepState) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+func (model *Model) openPIREPPicker() tea.Cmd {
+	picker := &model.pirepPicker
+	picker.show = true
+	picker.detail = false
+	picker.confirmCancel = ""
+	return model.loadPIREPs()
+}
+
+func (model *Model) loadPIREPs() tea.Cmd {
+	model.pirepPicker.loading = true
+	return func() tea.Msg {
+		pireps, err := model.flightService.GetPIREPs(model.ctx)
+		return pirepsLoadedMsg{pireps: pireps, error: err}
+	}
+}
+
+func (model *Model) resumePIREP(pirepID string) tea.Cmd {
+	return func() tea.Msg {
+		pirep, err := model.control.Resume(model.ctx, pirepID)
+		return pirepResumedMsg{pirep: pirep, error: err}
+	}
+}
+
+func (model *Model) cancelListedPIREP(pirep models.ListedPIREP) tea.Cmd {
+	return func() tea.Msg {
+		return pirepCancelledMsg{error: model.flightService.CancelPIREP(model.ctx, pirep)}
+	}
+}
+
+func (model *Model) handleKeyPIREPPicker(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	picker := &model.pirepPicker
+
+	// Anything other than a second x calls off a cancel
+	confirming := picker.confirmCancel
+	picker.confirmCancel = ""
+
+	switch {
+	case msg.Type == tea.KeyCtrlC:
+		model.cancel()
+		return model, tea.Quit
+	case key.Matches(msg, model.keys.Back):
+		if picker.detail {
+			picker.detail = false
+		} else {
+			picker.show = false
+		}
+	case key.Matches(msg, pirepsKeys.View):
+		picker.detail = !picker.detail
+	case key.Matches(msg, pirepsKeys.Filter):
+		picker.filter = (picker.filter + 1) % len(pirepFilters)
+		picker.detail = false
+		picker.refresh()
+	case key.Matches(msg, pirepsKeys.Refresh):
+		return model, model.loadPIREPs()
+	case key.Matches(msg, model.keys.Enter):
+		pirep, ok := picker.selected()
+		if !ok {
+			break
+		}
+		if !models.PirepState(pirep.State).CanResume() {
+			picker.detail = true
+			break
+		}
+		model.statusMessage = fmt.Sprintf("Resuming PIREP %s...", pirep.ID)
+		picker.show = false
+		return model, model.resumePIREP(pirep.ID)
+	case key.Matches(msg, pirepsKeys.Cancel):
+		pirep, ok := picker.selected()
+		if !ok {
+			break
+		}
+		if !models.PirepState(pirep.State).CanCancel() {
+			model.statusMessage = fmt.Sprintf("PIREP %s is %s and can't be cancelled", pirep.ID, models.PirepState(pirep.State))
+			break
+		}
+		if confirming != pirep.ID {
+			picker.confirmCancel = pirep.ID
+			model.statusMessage = fmt.Sprintf("Press x again to cancel PIREP %s", pirep.ID)
+			break
+		}
+		model.statusMessage = fmt.Sprintf("Cancelling PIREP %s...", pirep.ID)
+		return model, model.cancelListedPIREP(pirep)
+	case key.Matches(msg, model.keys.Up), key.Matches(msg, model.keys.Down):
+		var cmd tea.Cmd
+		picker.table, cmd = picker.table.Update(msg)
+		return model, cmd
+	}
+	return model, nil
+}
+
+func (model *Model) renderPIREPPicker() string {
+	picker := &model.pirepPicker
+
+	s := styleTitle.Copy().Width(model.contentWidth()).Render("PIREPs") + "\n"
+	if model.statusMessage != "" {
+		s += styleSecondary.Render(model.statusMessage) + "\n"
+	}
+	s += "\n"
+
+	switch {
+	case picker.err != nil:
+		s += styleAttention.Render(fmt.Sprintf("Failed to load PIREPs: %v", picker.err)) + "\n"
+	case picker.loading && len(picker.pireps) == 0:
+		s += styleSecondary.Render("Loading PIREPs...") + "\n"
+	case picker.detail && len(picker.visible) > 0:
+		pirep, _ := picker.selected()
+		s += renderPIREPDetail(pirep, model.contentWidth(), model.weightUnit())
+	default:
+		s += fmt.Sprintf("%d of %d PIREPs, showing %s\n", len(picker.visible), len(picker.pireps), pirepFilters[picker.filter].name)
+		s += picker.table.View() + "\n"
+	}
+
+	action := "view"
+	if pirep, ok := picker.selected(); ok && models.PirepState(pirep.State).CanResume() {
+		action = "resume"
+	}
+	s += "\n" + styleSecondary.Render(fmt.Sprintf("enter: %s • v: details • s: filter by state • x: cancel • u: refresh • esc: back", action)) + "\n"
+	return s
+}
+
+func renderPIREPDetail(pirep models.ListedPIREP, width int, weightUnit string) string {
+	s := styleHeading.Render("PIREP "+pirep.ID) + "\n"
+
+	pair := func(name, value string) {
+		s += stylePairKey.Render(name+":") + value + "\n"
+	}
+
+	pair("Flight", strings.TrimSpace(pirep.Airline.ICAO+" "+pirep.FlightNumber))
+	route := pirep.DptAirportID + " to " + pirep.ArrAirportID
+	if pirep.AltAirportID != nil && *pirep.AltAirportID != "" {
+		route += " (alternate " + *pirep.AltAirportID + ")"
+	}
+	pair("From/to", route)
+	pair("Aircraft", strings.TrimSpace(pirep.Aircraft.Registration+" "+pirep.Aircraft.Icao))
+	state := models.PirepState(pirep.State).String()
+	if pirep.StatusText != "" {
+		state += ", " + pirep.StatusText
+	}
+	pair("State", state)
+	if pirep.Level != nil {
+		pair("Level", fmt.Sprintf("%d ft", *pirep.Level))
+	}
+	pair("Flight time", fmt.Sprintf("%d:%02d", pirep.FlightTime/60, pirep.FlightTime%60))
+	pair("Distance", fmt.Sprintf("%.0f nm", pirep.Distance.Nmi))
+	pair("Fuel used", formatWeight(pirep.FuelUsed.Kg, weightUnit))
+	if pirep.LandingRate != nil {
+		pair("Landing rate", fmt.Sprintf("%.0f fpm", *pirep.LandingRate))
+	}
+	if pirep.Score != nil {
+		pair("Score", fmt.Sprintf("%d", *pirep.Score))
+	}
+	pair("Created", pirep.CreatedAt.Local().Format(time.DateTime))
+	if !pirep.SubmittedAt.IsZero() {
+		pair("Filed", pirep.SubmittedAt.Local().Format(time.DateTime))
+	}
+	if pirep.Route != "" {
+		pair("Route", lipgloss.NewStyle().Width(max(20, width-24)).Render(pirep.Route))
+	}
+	if pirep.Notes != nil && *pirep.Notes != "" {
+		pair("Notes", *pirep.Notes)
+	}
+	fields := pirep.FieldValues()
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		pair(name, fields[name])
+	}
+	return s
+}
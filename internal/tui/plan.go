@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julietrb1/phpvms-xplane/internal/flightplan"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+func (model *Model) renderPlan(s string, snapshot udp.MetricsSnapshot) string {
+	if model.ofp == nil {
+		s += styleHeading.Render("Flight plan") + "\n"
+		return s + styleSecondary.Render("Press 'o' to fetch your SimBrief OFP") + "\n"
+	}
+
+	progress := flightplan.Progress{Next: -1}
+	if position := snapshot.LastPosition; position != nil {
+		progress = flightplan.RouteProgress(model.route, position.Lat, position.Lon)
+	}
+
+	return s + model.columns(model.renderPlanSummary(snapshot, progress), model.renderNavlog(progress))
+}
+
+func (model *Model) renderPlanSummary(snapshot udp.MetricsSnapshot, progress flightplan.Progress) string {
+	s := styleHeading.Render("Flight plan") + "\n"
+
+	plan, err := flightplan.FromOFP(model.ofp)
+	if err != nil {
+		return s + styleAttention.Render(fmt.Sprintf("Failed to read OFP: %v", err)) + "\n"
+	}
+
+	s += stylePairKey.Render("Flight:")
+	s += plan.FlightNumber + "\n"
+
+	s += stylePairKey.Render("From/to:")
+	s += plan.Origin + " to " + plan.Destination
+	if plan.Alternate != "" {
+		s += " (alternate " + plan.Alternate + ")"
+	}
+	s += "\n"
+
+	s += stylePairKey.Render("Cruise:")
+	s += fmt.Sprintf("%d ft\n", plan.Altitude)
+
+	s += stylePairKey.Render("Distance:")
+	s += fmt.Sprintf("%d nm\n", plan.Distance)
+
+	s += stylePairKey.Render("Block fuel:")
+	s += formatWeight(float64(plan.BlockFuel), model.weightUnit()) + "\n"
+
+	s += stylePairKey.Render("Time en route:")
+	s += fmt.Sprintf("%d:%02d\n", plan.FlightTime/60, plan.FlightTime%60)
+
+	s += stylePairKey.Render("Route:")
+	s += lipgloss.NewStyle().Width(max(20, model.columnWidth()-24)).Render(plan.Route) + "\n"
+
+	s += styleHeading.Render("Progress") + "\n"
+	if progress.Next < 0 {
+		return s + styleSecondary.Render("Waiting for a position from X-Plane") + "\n"
+	}
+
+	s += stylePairKey.Render("Next fix:")
+	s += fmt.Sprintf("%s, %.0f nm\n", model.route[progress.Next].Ident, progress.ToNext)
+
+	s += stylePairKey.Render("Remaining:")
+	s += fmt.Sprintf("%.0f nm\n", progress.Remaining)
+
+	if snapshot.LastDistance != nil {
+		flown := float64(*snapshot.LastDistance)
+		if total := flown + progress.Remaining; total > 0 {
+			s += stylePairKey.Render("Flown:")
+			s += renderProgressBar(flown/total, max(10, model.columnWidth()-32))
+			s += fmt.Sprintf(" %.0f%%\n", 100*flown/total)
+		}
+	}
+	return s
+}
+
+// renderNavlog lists the fixes around the next one.
+func (model *Model) renderNavlog(progress flightplan.Progress) string {
+	s := styleHeading.Render("Navlog") + "\n"
+	if len(model.route) == 0 {
+		return s + styleSecondary.Render("(none)") + "\n"
+	}
+
+	shown := max(6, model.height-14)
+	start := 0
+	if progress.Next >= 0 {
+		start = max(0, min(progress.Next-2, len(model.route)-shown))
+	}
+
+	for i := start; i < min(len(model.route), start+shown); i++ {
+		fix := model.route[i]
+		line := fmt.Sprintf("%-7s %-4s %6.0f ft", fix.Ident, fix.Type, fix.Altitude)
+		if i > 0 {
+			previous := model.route[i-1]
+			line += fmt.Sprintf(" %5.0f nm", flightplan.DistanceNM(previous.Lat, previous.Lon, fix.Lat, fix.Lon))
+		}
+
+		switch {
+		case i == progress.Next:
+			s += styleSecondary.Copy().Bold(true).Render("> "+line) + "\n"
+		case i < progress.Next:
+			s += lipgloss.NewStyle().Foreground(colourSubtle).Render("  "+line) + "\n"
+		default:
+			s += "  " + line + "\n"
+		}
+	}
+	if remaining := len(model.route) - start - shown; remaining > 0 {
+		s += styleSecondary.Render(fmt.Sprintf("...and %d more", remaining)) + "\n"
+	}
+	return s
+}
+
+func renderProgressBar(fraction float64, width int) string {
+	filled := int(max(0, min(1, fraction)) * float64(width))
+	return lipgloss.NewStyle().Foreground(colourPrimary).Render(strings.Repeat("█", filled)) +
+		lipgloss.NewStyle().Foreground(colourBorder).Render(strings.Repeat("░", width-filled))
+}
@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/prefile"
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+)
+
+// customFieldsInput is the flight input for overriding the VA's custom
+// prefile fields.
+const customFieldsInput = 10
+
+type prefileKeyMap struct {
+	FlightType key.Binding
+	Network    key.Binding
+}
+
+var prefileKeys = prefileKeyMap{
+	FlightType: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "change flight type"),
+	),
+	Network: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "change network"),
+	),
+}
+
+type prefileOptions struct {
+	flightType int
+	network    int
+	template   *prefile.Template
+}
+
+func newPrefileOptions(cfg *config.Config, logger *slog.Logger) prefileOptions {
+	options := prefileOptions{template: prefile.Default()}
+	for i, flightType := range prefile.FlightTypes {
+		if flightType.Code == cfg.FlightType {
+			options.flightType = i
+		}
+	}
+	for i, network := range prefile.Networks {
+		if strings.EqualFold(network, cfg.Network) {
+			options.network = i
+		}
+	}
+
+	// The template was checked at startup, so this only fails if the file
+	// changed since
+	if template, err := cfg.PrefileTemplate(); err != nil {
+		logger.Error("Failed to load prefile fields, using the defaults", "error", err)
+	} else {
+		options.template = template
+	}
+	return options
+}
+
+func (model *Model) handleKeyPrefile(msg tea.KeyMsg) (tea.Cmd, bool) {
+	options := &model.prefile
+
+	switch {
+	case key.Matches(msg, prefileKeys.FlightType):
+		options.flightType = (options.flightType + 1) % len(prefile.FlightTypes)
+		flightType := prefile.FlightTypes[options.flightType]
+		model.statusMessage = fmt.Sprintf("Flight type %s (%s)", flightType.Code, flightType.Name)
+	case key.Matches(msg, prefileKeys.Network):
+		options.network = (options.network + 1) % len(prefile.Networks)
+		model.statusMessage = "Network " + prefile.Networks[options.network]
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// prefileFields fills in the VA's custom fields from the form, with the
+// pilot's overrides.
+func (model *Model) prefileFields() (map[string]interface{}, error) {
+	overrides, err := parseFields(model.flightInputs[customFieldsInput].Value())
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(overrides))
+	for name, value := range overrides {
+		values[name] = fmt.Sprint(value)
+	}
+
+	return model.prefile.template.Fill(prefile.Values{
+		Simulator:  service.SimulatorName(model.config.Get().Simulator, model.metrics.LastSimulator.Load()),
+		Network:    prefile.Networks[model.prefile.network],
+		Callsign:   model.flightInputs[4].Value(),
+		FlightType: prefile.FlightTypes[model.prefile.flightType].Code,
+	}, values)
+}
+
+// renderPrefileOptions shows the choices that aren't text inputs and the
+// custom fields that will be sent.
+func (model *Model) renderPrefileOptions() string {
+	flightType := prefile.FlightTypes[model.prefile.flightType]
+	s := stylePairKey.Render("Flight type") + " " + fmt.Sprintf("%s %s ", flightType.Code, flightType.Name) +
+		styleSecondary.Render("(y)") + "\n"
+	s += stylePairKey.Render("Network") + " " + prefile.Networks[model.prefile.network] + " " +
+		styleSecondary.Render("(n)") + "\n"
+
+	fields, err := model.prefileFields()
+	if err != nil {
+		return s + stylePairKey.Render("") + " " + styleAttention.Render(err.Error()) + "\n"
+	}
+	// The template's fields in order, then any others the pilot added
+	var pairs []string
+	for _, field := range model.prefile.template.Fields {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", field.Name, fields[field.Name]))
+		delete(fields, field.Name)
+	}
+	extra := make([]string, 0, len(fields))
+	for name, value := range fields {
+		extra = append(extra, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(extra)
+	pairs = append(pairs, extra...)
+	width := max(20, model.columnWidth()-24)
+	return s + lipgloss.JoinHorizontal(lipgloss.Top, stylePairKey.Render("Sends")+" ",
+		styleSecondary.Copy().Width(width).Render(strings.Join(pairs, "; "))) + "\n"
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"errors"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+)
+
+type ProfileItem struct {
+	Name    string
+	Profile config.Profile
+}
+
+func (i ProfileItem) Title() string {
+	return i.Name
+}
+
+func (i ProfileItem) Description() string {
+	if i.Profile.BaseURL == "" {
+		return "(no base URL)"
+	}
+	return i.Profile.BaseURL
+}
+
+func (i ProfileItem) FilterValue() string {
+	return i.Name + " " + i.Profile.BaseURL
+}
+
+// profilePicker is a list of the config file's profiles, shown before PXP
+// starts when none was chosen.
+type profilePicker struct {
+	list   list.Model
+	chosen string
+}
+
+func (p *profilePicker) Init() tea.Cmd {
+	return nil
+}
+
+func (p *profilePicker) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		p.list.SetSize(msg.Width, msg.Height)
+	case tea.KeyMsg:
+		if p.list.FilterState() == list.Filtering {
+			break
+		}
+		// The list quits on esc, q and ctrl+c itself
+		if key.Matches(msg, keys.Enter) {
+			if item, ok := p.list.SelectedItem().(ProfileItem); ok {
+				p.chosen = item.Name
+				return p, tea.Quit
+			}
+		}
+	}
+
+	var cmd tea.Cmd
+	p.list, cmd = p.list.Update(msg)
+	return p, cmd
+}
+
+func (p *profilePicker) View() string {
+	return p.list.View()
+}
+
+// PickProfile asks which of the config file's profiles to fly with.
+func PickProfile(file *config.File) (string, error) {
+	var items []list.Item
+	for _, name := range file.Names() {
+		items = append(items, ProfileItem{Name: name, Profile: file.Profiles[name]})
+	}
+
+	picker := &profilePicker{list: list.New(items, list.NewDefaultDelegate(), 0, 0)}
+	picker.list.Title = "Select Profile"
+	if _, err := tea.NewProgram(picker, tea.WithAltScreen()).Run(); err != nil {
+		return "", err
+	}
+	if picker.chosen == "" {
+		return "", errors.New("no profile chosen")
+	}
+	return picker.chosen, nil
+}
@@ -2,7 +2,10 @@ package tui
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/julietrb1/phpvms-xplane/internal/rules"
+	"github.com/julietrb1/phpvms-xplane/internal/track"
 	"github.com/julietrb1/phpvms-xplane/internal/udp"
 )
 
@@ -10,7 +13,7 @@ func (model *Model) renderFlightControls(s string) string {
 	s += styleHeading.
 		Render("Flight controls") + "\n"
 
-	if model.activeTab == 0 {
+	if model.activeTab == tabFlight {
 		s += stylePairKey.Render("Airline")
 		if model.selectedAirlineID > 0 {
 			airlineInfo := model.findSelectedAirline()
@@ -42,7 +45,7 @@ func (model *Model) renderFlightControls(s string) string {
 				}
 			case 7:
 				{
-					label = "Block fuel (kg)"
+					label = "Block fuel (" + model.weightUnit() + ")"
 				}
 			case 8:
 				{
@@ -52,54 +55,54 @@ func (model *Model) renderFlightControls(s string) string {
 				{
 					label = "Route"
 				}
+			case customFieldsInput:
+				label = "Custom fields"
 			}
 			s += fmt.Sprintf("%s %s\n",
 				stylePairKey.Render(label),
 				input.View())
+			if _, ok := airportInputs[i]; ok {
+				s += model.renderAirportCheck(i)
+			}
 		}
+		s += model.renderPrefileOptions()
 
 		s += stylePairKey.Render("Aircraft")
 		if model.selectedAircraftID > 0 {
-			var aircraftInfo string
-			for _, item := range model.aircraftList.Items() {
-				if aircraftItem, ok := item.(AircraftItem); ok {
-					if aircraftItem.Aircraft.ID == model.selectedAircraftID {
-						aircraftInfo = fmt.Sprintf("%s (%s - %s)",
-							aircraftItem.Aircraft.Registration,
-							aircraftItem.Aircraft.ICAO,
-							aircraftItem.Aircraft.Name)
-						break
-					}
-				}
-			}
-			if aircraftInfo == "" {
-				aircraftInfo = fmt.Sprintf("ID: %d", model.selectedAircraftID)
+			aircraftInfo := fmt.Sprintf("ID: %d", model.selectedAircraftID)
+			if aircraft, ok := model.selectedAircraft(); ok {
+				aircraftInfo = fmt.Sprintf("%s (%s - %s)", aircraft.Registration, aircraft.ICAO, aircraft.Name)
 			}
 			s += aircraftInfo + "\n"
 		} else {
 			s += styleSecondary.Render("Press 'a' to select aircraft") + "\n"
 		}
+		for _, mismatch := range model.fleetMismatches() {
+			s += stylePairKey.Render("") + styleAttention.Render(mismatch) + "\n"
+		}
 	}
 	return s
 }
 
 func (model *Model) renderTitle(s string) string {
-	s += styleTitle.
+	width := model.contentWidth()
+	s += styleTitle.Copy().Width(width).
 		Render("PXP: the phpVMS ACARS Client") + "\n"
 
-	s += styleSubtitle.
+	s += styleSubtitle.Copy().Width(width).
 		Render(model.statusMessage) + "\n"
 
-	s += model.renderActivePirepID()
+	s += model.renderActivePirepID() + "\n"
 	return s
 }
 
 func (model *Model) renderActivePirepID() string {
+	width := model.contentWidth()
 	pirepID := model.flightService.GetActivePirepID()
 	if pirepID == nil {
-		return styleInactivePirepBar.Render("(no active PIREP)")
+		return styleInactivePirepBar.Copy().Width(width).Render("(no active PIREP)")
 	}
-	return styleActivePirepBar.Render(fmt.Sprintf("Active PIREP: %s", *pirepID))
+	return styleActivePirepBar.Copy().Width(width).Render(fmt.Sprintf("Active PIREP: %s", *pirepID))
 }
 
 func (model *Model) renderACARSTransmissions(s string, snapshot udp.MetricsSnapshot) string {
@@ -124,7 +127,7 @@ func (model *Model) renderFlightMetrics(s string, snapshot udp.MetricsSnapshot)
 	s += conditionalAttentionString(snapshot.LastStatus) + "\n"
 
 	s += stylePairKey.Render("Fuel:")
-	s += fmt.Sprintf("%d kg\n", *snapshot.LastFuel)
+	s += formatWeight(float64(*snapshot.LastFuel), model.weightUnit()) + "\n"
 
 	s += stylePairKey.Render("Flight time:")
 	s += fmt.Sprintf("%d minutes\n", *snapshot.LastFlightTime)
@@ -132,6 +135,109 @@ func (model *Model) renderFlightMetrics(s string, snapshot udp.MetricsSnapshot)
 	s += stylePairKey.Render("Distance:")
 	s += fmt.Sprintf("%d nm\n", *snapshot.LastDistance)
 
+	if position := snapshot.LastPosition; position != nil {
+		s += stylePairKey.Render("Altitude:")
+		s += fmt.Sprintf("%s ft, %s ft AGL\n", conditionalNumber(position.AltMSL), conditionalNumber(position.AltAGL))
+
+		s += stylePairKey.Render("Speed:")
+		s += fmt.Sprintf("%s kt IAS, %s kt GS\n", conditionalNumber(position.IAS), conditionalNumber(position.GS))
+
+		s += stylePairKey.Render("Vertical speed:")
+		s += fmt.Sprintf("%s fpm\n", conditionalNumber(position.VSFPM))
+
+		s += stylePairKey.Render("Heading:")
+		s += fmt.Sprintf("%s°\n", conditionalNumber(position.Heading))
+	}
+
+	return s
+}
+
+// renderPhaseTimeline lists the flight phases so far, with how long each
+// lasted.
+func (model *Model) renderPhaseTimeline(s string) string {
+	s += styleHeading.Render("Phase timeline") + "\n"
+
+	var events []track.Event
+	for _, event := range model.flightService.Recorder.Events() {
+		switch event.Kind {
+		case track.EventPhase, track.EventTakeoff, track.EventTouchdown:
+			events = append(events, event)
+		}
+	}
+	if len(events) == 0 {
+		return s + styleSecondary.Render("(none)") + "\n"
+	}
+
+	shown := max(4, model.height-24)
+	for i := max(0, len(events)-shown); i < len(events); i++ {
+		event := events[i]
+		s += stylePairKey.Render(event.Time.Local().Format(time.TimeOnly))
+		if event.Kind != track.EventPhase {
+			s += styleSecondary.Render(event.Text) + "\n"
+			continue
+		}
+
+		// A phase lasts until the next phase change
+		until := model.lastUpdate
+		for _, later := range events[i+1:] {
+			if later.Kind == track.EventPhase {
+				until = later.Time
+				break
+			}
+		}
+		lasted := until.Sub(event.Time)
+		s += fmt.Sprintf("%s (%d:%02d)\n", event.Text, int(lasted.Hours()), int(lasted.Minutes())%60)
+	}
+	return s
+}
+
+func (model *Model) renderScore(s string) string {
+	score, violations := model.flightService.GetScore()
+
+	s += styleHeading.Render("Flight score") + "\n"
+	s += stylePairKey.Render("Score:")
+	if len(violations) == 0 {
+		s += fmt.Sprintf("%d/%d\n", score, rules.MaxScore)
+		return s
+	}
+	s += styleAttention.Render(fmt.Sprintf("%d/%d", score, rules.MaxScore)) + "\n"
+
+	// Most recent first; older violations still count towards the score
+	const shown = 4
+	for i := len(violations) - 1; i >= max(0, len(violations)-shown); i-- {
+		violation := violations[i]
+		s += stylePairKey.Render(violation.Time.Local().Format(time.TimeOnly))
+		s += fmt.Sprintf("%s (-%d)\n", violation.Description, violation.Points)
+	}
+	if len(violations) > shown {
+		s += styleSecondary.Render(fmt.Sprintf("...and %d more", len(violations)-shown)) + "\n"
+	}
+	return s
+}
+
+func (model *Model) renderFlightLog(s string) string {
+	entries := model.flightService.GetFlightLog()
+	if len(entries) == 0 {
+		return s
+	}
+
+	s += styleHeading.Render("Flight log") + "\n"
+	const shown = 6
+	for _, entry := range entries[max(0, len(entries)-shown):] {
+		var status string
+		switch {
+		case entry.Sent():
+			status = styleSecondary.Render("sent")
+		case entry.Failed():
+			status = styleAttention.Render("failed")
+		case entry.Attempts > 0:
+			status = styleAttention.Render(fmt.Sprintf("retry %d", entry.Attempts))
+		default:
+			status = "queued"
+		}
+		s += stylePairKey.Render(entry.Time.Local().Format(time.TimeOnly))
+		s += fmt.Sprintf("%s [%s]\n", entry.Text, status)
+	}
 	return s
 }
 
@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/julietrb1/phpvms-xplane/internal/config"
+)
+
+// Reloader reloads the configuration while PXP runs. Prepare loads it without
+// applying it, so a change that would leave the active PIREP behind can be
+// confirmed first.
+type Reloader interface {
+	Prepare(ctx context.Context) (*config.Config, config.Changes, error)
+	Apply(next *config.Config, changes config.Changes) error
+	// Requests receives when the configuration should be reloaded, e.g. on
+	// SIGHUP
+	Requests() <-chan struct{}
+}
+
+type settingsKeyMap struct {
+	Reload key.Binding
+}
+
+var settingsKeys = settingsKeyMap{
+	Reload: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "reload"),
+	),
+}
+
+func (model *Model) handleKeySettings(msg tea.KeyMsg) (tea.Cmd, bool) {
+	// Anything other than a second r calls off a pending reload
+	pending := model.pendingReload
+	model.pendingReload = nil
+
+	switch {
+	case key.Matches(msg, settingsKeys.Reload) && pending != nil:
+		return model.applyConfig(*pending), true
+	case key.Matches(msg, settingsKeys.Reload) && model.reloader != nil:
+		model.statusMessage = "Reloading configuration..."
+		return model.loadConfig(), true
+	case key.Matches(msg, model.keys.Back) && pending != nil:
+		model.statusMessage = "Kept the current configuration"
+		return nil, true
+	}
+	return nil, false
+}
+
+// waitForReload waits for a reload to be requested from outside the TUI.
+func (model *Model) waitForReload() tea.Cmd {
+	if model.reloader == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		select {
+		case <-model.reloader.Requests():
+			return reloadRequestedMsg{}
+		case <-model.ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (model *Model) loadConfig() tea.Cmd {
+	return func() tea.Msg {
+		next, changes, err := model.reloader.Prepare(model.ctx)
+		return configLoadedMsg{next: next, changes: changes, error: err}
+	}
+}
+
+// configLoaded applies a reloaded configuration, or asks first if it would
+// leave the active PIREP behind.
+func (model *Model) configLoaded(msg configLoadedMsg) tea.Cmd {
+	switch {
+	case msg.error != nil:
+		model.statusMessage = fmt.Sprintf("Failed to reload configuration: %v", msg.error)
+		return nil
+	case msg.changes.None():
+		model.statusMessage = "Configuration unchanged"
+		return nil
+	}
+
+	if pirepID := model.flightService.GetActivePirepID(); pirepID != nil && msg.changes.Credentials() {
+		model.pendingReload = &msg
+		model.statusMessage = styleAttention.Render(fmt.Sprintf(
+			"The phpVMS site or API key changed, which would leave PIREP %s behind. Press r to reload anyway, or esc to keep the current configuration", *pirepID))
+		return model.setTab(tabSettings)
+	}
+	return model.applyConfig(msg)
+}
+
+func (model *Model) applyConfig(msg configLoadedMsg) tea.Cmd {
+	if err := model.reloader.Apply(msg.next, msg.changes); err != nil {
+		model.statusMessage = fmt.Sprintf("Failed to apply configuration: %v", err)
+		return nil
+	}
+
+	model.statusMessage = "Configuration reloaded"
+	if len(msg.changes.Applied) > 0 {
+		model.statusMessage = "Reloaded " + strings.Join(msg.changes.Applied, ", ")
+	}
+	if len(msg.changes.Restart) > 0 {
+		model.statusMessage += styleAttention.Render(". Restart PXP for " + strings.Join(msg.changes.Restart, ", "))
+	}
+
+	if msg.changes.Has("FLIGHT_TYPE", "NETWORK", "PREFILE_FIELDS_FILE") {
+		model.prefile = newPrefileOptions(model.config.Get(), model.logger)
+	}
+	// The fleet and airlines are the new site's or pilot's
+	if msg.changes.Credentials() {
+		return tea.Batch(model.fetchAircraftList(), model.fetchAirlineList())
+	}
+	return nil
+}
+
+func (model *Model) renderSettings(s string) string {
+	if model.config == nil {
+		return s + styleHeading.Render("Settings") + "\n" + styleSecondary.Render("(none)") + "\n"
+	}
+	cfg := model.config.Get()
+
+	apiKey := styleAttention.Render("(not set)")
+	if cfg.PhpVMSAPIKey.IsSet() {
+		apiKey = "(set, from " + cfg.APIKeySource() + ")"
+	}
+	prefs := "plain text"
+	if cfg.PrefsEncrypted {
+		prefs = "encrypted"
+	}
+	simbriefUserID := cfg.SimbriefUserID
+	if simbriefUserID == "" {
+		simbriefUserID = styleAttention.Render("(not set)")
+	}
+	logFile := cfg.LogFile
+	if cfg.TUIEnabled {
+		logFile = cfg.LogFilePath()
+	}
+
+	profiles := "-"
+	if len(cfg.Profiles) > 0 {
+		profiles = strings.Join(cfg.Profiles, ", ")
+	}
+
+	prefileFields := orDefault(cfg.PrefileFieldsFile, "built-in")
+	if len(cfg.PrefileFields) > 0 {
+		prefileFields = fmt.Sprintf("%d from the profile", len(cfg.PrefileFields))
+	}
+
+	left := renderSettingsSection("Profile", [][2]string{
+		{"Profile", orDefault(cfg.Profile, "none")},
+		{"Profiles", profiles},
+		{"Config file", config.DefaultFilePath()},
+	})
+	left += renderSettingsSection("phpVMS", [][2]string{
+		{"Base URL", cfg.PhpVMSBaseURL},
+		{"API key", apiKey},
+		{"Airline ID", optionalID(cfg.SelectedAirlineID)},
+		{"Aircraft ID", optionalID(cfg.SelectedAircraftID)},
+		{"Preferences", prefs},
+	})
+	left += renderSettingsSection("Prefile", [][2]string{
+		{"Flight type", cfg.FlightType},
+		{"Network", cfg.Network},
+		{"Simulator", orDefault(cfg.Simulator, "detected")},
+		{"Source name", cfg.SourceName},
+		{"Custom fields", prefileFields},
+		{"Weights", model.weightUnit()},
+	})
+	left += renderSettingsSection("X-Plane", [][2]string{
+		{"UDP listener", fmt.Sprintf("%s:%d", cfg.UDPBindHost, cfg.UDPBindPort)},
+	})
+	left += renderSettingsSection("SimBrief", [][2]string{
+		{"User ID", simbriefUserID},
+		{"FMS plans", orDefault(cfg.FMSOutputDir, "current directory")},
+	})
+
+	right := renderSettingsSection("Logging", [][2]string{
+		{"Level", cfg.LogLevel},
+		{"Format", cfg.LogFormat},
+		{"File", orDefault(logFile, "stdout")},
+	})
+	right += renderSettingsSection("Data", [][2]string{
+		{"Data directory", cfg.DataDir},
+		{"Exports", cfg.ExportsDir()},
+		{"Scoring rules", orDefault(cfg.RulesFile, "built-in")},
+	})
+	right += renderSettingsSection("Services", [][2]string{
+		{"Control socket", cfg.ControlSocketPath()},
+		{"Control API", orDefault(cfg.ControlAddr, "disabled")},
+		{"Web dashboard", orDefault(cfg.WebAddr, "disabled")},
+		{"Metrics", orDefault(cfg.MetricsAddr, "disabled")},
+	})
+
+	s += model.columns(left, right)
+	return s + styleSecondary.Render("Settings are read from the config file profile, then the environment and .env file. Press r, or send PXP SIGHUP, to reload them") + "\n"
+}
+
+func renderSettingsSection(heading string, pairs [][2]string) string {
+	s := styleHeading.Render(heading) + "\n"
+	for _, pair := range pairs {
+		s += stylePairKey.Render(pair[0]+":") + pair[1] + "\n"
+	}
+	return s
+}
+
+func optionalID(id int) string {
+	if id <= 0 {
+		return "-"
+	}
+	return strconv.Itoa(id)
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -6,12 +6,13 @@ import (
 	"log/slog"
 
 	"github.com/julietrb1/phpvms-xplane/internal/config"
+	"github.com/julietrb1/phpvms-xplane/internal/logging"
 	"github.com/julietrb1/phpvms-xplane/internal/service"
 	"github.com/julietrb1/phpvms-xplane/internal/udp"
 )
 
-func Run(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metrics, flightService *service.FlightService, cfg *config.Config, logger *slog.Logger) error {
-	model := NewModel(ctx, cancel, metrics, flightService, cfg, logger)
+func Run(ctx context.Context, cancel context.CancelFunc, metrics *udp.Metrics, flightService *service.FlightService, cfg *config.Live, reloader Reloader, logBuffer *logging.Buffer, logger *slog.Logger) error {
+	model := NewModel(ctx, cancel, metrics, flightService, cfg, reloader, logBuffer, logger)
 	p := tea.NewProgram(&model, tea.WithAltScreen())
 
 	if _, err := p.Run(); err != nil {
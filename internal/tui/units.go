@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const lbPerKg = 2.20462
+
+// weightUnit is how weights are shown and entered, kg unless WEIGHT_UNIT is
+// lb. phpVMS always takes pounds.
+func (model *Model) weightUnit() string {
+	if model.config != nil && model.config.Get().WeightUnit == "lb" {
+		return "lb"
+	}
+	return "kg"
+}
+
+// formatWeight shows a weight in kg in unit, e.g. "5400 kg".
+func formatWeight(kg float64, unit string) string {
+	if unit == "lb" {
+		return fmt.Sprintf("%.0f lb", kg*lbPerKg)
+	}
+	return fmt.Sprintf("%.0f kg", kg)
+}
+
+// weightValue is a weight in kg as it's entered in unit.
+func weightValue(kg int, unit string) string {
+	if unit == "lb" {
+		return strconv.Itoa(int(math.Round(float64(kg) * lbPerKg)))
+	}
+	return strconv.Itoa(kg)
+}
+
+// weightKg is a weight entered in unit, in kg.
+func weightKg(value int, unit string) int {
+	if unit == "lb" {
+		return int(math.Round(float64(value) / lbPerKg))
+	}
+	return value
+}
+
+// weightLbs is a weight entered in unit, in pounds for phpVMS.
+func weightLbs(value int, unit string) int {
+	if unit == "lb" {
+		return value
+	}
+	return kgToLbs(value)
+}
@@ -3,9 +3,11 @@ package udp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
+	"sync"
 	"time"
 )
 
@@ -16,6 +18,8 @@ type Listener struct {
 	Logger   *slog.Logger
 	Handler  PayloadHandler
 	MaxBytes int
+	// mu guards Addr and Conn, which change when the listener is rebound
+	mu sync.Mutex
 }
 
 type PayloadHandler interface {
@@ -42,12 +46,19 @@ func NewListener(bindHost string, bindPort int, handler PayloadHandler, logger *
 }
 
 func (l *Listener) Start(ctx context.Context) error {
-	var err error
-	l.Conn, err = net.ListenUDP("udp", l.Addr)
+	l.mu.Lock()
+	conn, err := net.ListenUDP("udp", l.Addr)
 	if err != nil {
+		l.mu.Unlock()
 		return fmt.Errorf("failed to listen on UDP: %w", err)
 	}
-	defer l.Conn.Close()
+	l.Conn = conn
+	l.mu.Unlock()
+	defer func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.Conn.Close()
+	}()
 
 	l.Logger.Info("UDP listener started", "addr", l.Addr.String())
 
@@ -57,15 +68,28 @@ func (l *Listener) Start(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			if err := l.Conn.SetReadDeadline(time.Now().Add(1 * time.Second)); err != nil {
+			conn := l.conn()
+			if err := conn.SetReadDeadline(time.Now().Add(1 * time.Second)); err != nil && !errors.Is(err, net.ErrClosed) {
 				l.Logger.Warn("Failed to set read deadline", "error", err)
 			}
 
-			n, addr, err := l.Conn.ReadFromUDP(buffer)
+			n, addr, err := conn.ReadFromUDP(buffer)
 			if err != nil {
 				if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
 					continue
 				}
+				// Rebind closed it, so the next read is on the new one. If
+				// the same one is still there, Rebind couldn't bind either
+				// address, and it waits for another try.
+				if errors.Is(err, net.ErrClosed) {
+					if l.conn() == conn {
+						select {
+						case <-ctx.Done():
+						case <-time.After(time.Second):
+						}
+					}
+					continue
+				}
 				l.Logger.Error("Error reading from UDP", "error", err)
 				continue
 			}
@@ -75,13 +99,58 @@ func (l *Listener) Start(ctx context.Context) error {
 	}
 }
 
+// Rebind moves the listener to another address. The new address is bound
+// before the old one is closed, so the listener carries on where it was if
+// that fails.
+func (l *Listener) Rebind(bindHost string, bindPort int) error {
+	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%d", bindHost, bindPort))
+	if err != nil {
+		return fmt.Errorf("failed to resolve UDP address: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.Conn == nil {
+		l.Addr = addr
+		return nil
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil && addr.Port == l.Addr.Port {
+		// The addresses overlap, e.g. 0.0.0.0 and 127.0.0.1 on the same
+		// port, so the old one has to be closed first
+		l.Conn.Close()
+		if conn, err = net.ListenUDP("udp", addr); err != nil {
+			if old, reopenErr := net.ListenUDP("udp", l.Addr); reopenErr == nil {
+				l.Conn = old
+			} else {
+				l.Logger.Error("Failed to listen on UDP again, nothing will be received until it's rebound", "addr", l.Addr.String(), "error", reopenErr)
+			}
+			return fmt.Errorf("failed to listen on UDP: %w", err)
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to listen on UDP: %w", err)
+	}
+
+	l.Conn.Close()
+	l.Addr, l.Conn = addr, conn
+	l.Logger.Info("UDP listener moved", "addr", addr.String())
+	return nil
+}
+
+func (l *Listener) conn() *net.UDPConn {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.Conn
+}
+
 func (l *Listener) processPacket(ctx context.Context, data []byte, addr *net.UDPAddr) {
 	l.Metrics.PacketsAny.Add(1)
 	l.Metrics.LastSender.Store(addr)
 	l.Metrics.LastPacketTime.Store(time.Now().Unix())
 
-	var payload Payload
-	if err := json.Unmarshal(data, &payload); err != nil {
+	payload, err := DecodePayload(data)
+	if err != nil {
 		l.Metrics.PacketsErr.Add(1)
 		// Store first 8 bytes of non-JSON data for debugging
 		if len(data) > 0 {
@@ -93,10 +162,19 @@ func (l *Listener) processPacket(ctx context.Context, data []byte, addr *net.UDP
 	}
 
 	l.Metrics.LastStatus.Store(&payload.Status)
-	l.Metrics.LastPosition.Store(&payload.Position)
-	l.Metrics.LastDistance.Store(int32(payload.Position.DistanceNM))
-	l.Metrics.LastFuel.Store(int32(payload.Fuel))
-	l.Metrics.LastFlightTime.Store(int32(payload.FlightTime))
+	if payload.Position != nil {
+		l.Metrics.LastPosition.Store(payload.Position)
+		l.Metrics.LastDistance.Store(int32(Int(payload.Position.DistanceNM)))
+	}
+	if payload.Fuel != nil {
+		l.Metrics.LastFuel.Store(int32(Int(payload.Fuel)))
+	}
+	if payload.FlightTime != nil {
+		l.Metrics.LastFlightTime.Store(int32(Int(payload.FlightTime)))
+	}
+	if payload.Simulator != nil {
+		l.Metrics.LastSimulator.Store(payload.Simulator)
+	}
 
 	if l.Handler == nil {
 		err := fmt.Errorf("no handler set")
@@ -105,11 +183,23 @@ func (l *Listener) processPacket(ctx context.Context, data []byte, addr *net.UDP
 		return
 	}
 
-	updateFlightErr, updatePositionErr := l.Handler.HandlePayload(ctx, &payload)
+	updateFlightErr, updatePositionErr := l.Handler.HandlePayload(ctx, payload)
 	l.Metrics.UpdateFlightErr.Store(&updateFlightErr)
 	l.Metrics.UpdatePositionErr.Store(&updatePositionErr)
 }
 
+// DecodePayload decodes a packet from the Lua bridge.
+func DecodePayload(data []byte) (*Payload, error) {
+	var payload Payload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+	if payload.Simulator != nil {
+		payload.Simulator.normalise()
+	}
+	return &payload, nil
+}
+
 func (l *Listener) GetMetrics() *Metrics {
 	return l.Metrics
 }
@@ -0,0 +1,77 @@
+package udp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+type countingHandler struct {
+	payloads chan *Payload
+}
+
+func (h countingHandler) HandlePayload(ctx context.Context, payload *Payload) (error, error) {
+	h.payloads <- payload
+	return nil, nil
+}
+
+func TestListenerRebind(t *testing.T) {
+	handler := countingHandler{payloads: make(chan *Payload, 1)}
+	listener, err := NewListener("127.0.0.1", 0, handler, nil)
+	if err != nil {
+		t.Fatalf("NewListener() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go listener.Start(ctx)
+	for deadline := time.Now().Add(time.Second); listener.conn() == nil; {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the listener to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	oldAddr := listener.conn().LocalAddr().(*net.UDPAddr)
+
+	if err := listener.Rebind("127.0.0.1", 0); err != nil {
+		t.Fatalf("Rebind() error = %v", err)
+	}
+	newAddr := listener.conn().LocalAddr().(*net.UDPAddr)
+	if newAddr.Port == oldAddr.Port {
+		t.Fatalf("Expected a new port, still on %d", oldAddr.Port)
+	}
+
+	// The old port is free again
+	old, err := net.ListenUDP("udp", oldAddr)
+	if err != nil {
+		t.Errorf("Expected the old port to be closed, got %v", err)
+	} else {
+		old.Close()
+	}
+
+	sender, err := net.DialUDP("udp", nil, newAddr)
+	if err != nil {
+		t.Fatalf("DialUDP() error = %v", err)
+	}
+	defer sender.Close()
+	if _, err := sender.Write([]byte(`{"status":"ENR"}`)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	select {
+	case payload := <-handler.payloads:
+		if payload.Status != "ENR" {
+			t.Errorf("Expected status ENR, got %s", payload.Status)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected a packet on the new address")
+	}
+
+	if err := listener.Rebind("256.0.0.1", 0); err == nil {
+		t.Error("Expected an invalid address to fail")
+	}
+	if listener.conn().LocalAddr().String() != newAddr.String() {
+		t.Errorf("Expected a failed rebind to stay on %s, got %s", newAddr, listener.conn().LocalAddr())
+	}
+}
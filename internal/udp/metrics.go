@@ -18,6 +18,7 @@ type Metrics struct {
 	LastFuel          atomic.Int32
 	LastFlightTime    atomic.Int32
 	LastDistance      atomic.Int32
+	LastSimulator     atomic.Pointer[Simulator]
 	UpdateFlightErr   atomic.Pointer[error]
 	UpdatePositionErr atomic.Pointer[error]
 }
@@ -42,6 +43,7 @@ type MetricsSnapshot struct {
 	LastFuel          *int       `json:"last_fuel,omitempty"`
 	LastFlightTime    *int       `json:"last_flight_time,omitempty"`
 	LastDistance      *int       `json:"last_distance,omitempty"`
+	LastSimulator     *Simulator `json:"last_simulator,omitempty"`
 	UpdateFlightErr   *error     `json:"update_flight_err,omitempty"`
 	UpdatePositionErr *error     `json:"update_position_err,omitempty"`
 }
@@ -95,6 +97,7 @@ func (metrics *Metrics) Snapshot() MetricsSnapshot {
 		LastFuel:          &lastFuel,
 		LastFlightTime:    &lastFlightTime,
 		LastDistance:      &lastDistance,
+		LastSimulator:     metrics.LastSimulator.Load(),
 		UpdateFlightErr:   metrics.UpdateFlightErr.Load(),
 		UpdatePositionErr: metrics.UpdatePositionErr.Load(),
 	}
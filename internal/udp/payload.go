@@ -1,21 +1,126 @@
 package udp
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type Payload struct {
-	Status     string   `json:"status"`
-	Position   Position `json:"position"`
-	Fuel       int      `json:"fuel"`        // kg remaining
-	FlightTime int      `json:"flight_time"` // minutes
+	Status     string     `json:"status"`
+	Position   *Position  `json:"position"`
+	OnGround   *bool      `json:"on_ground"`
+	Fuel       *float64   `json:"fuel"`        // kg remaining
+	FlightTime *float64   `json:"flight_time"` // minutes
+	Lights     *Lights    `json:"lights"`
+	Engines    *float64   `json:"engines_running"` // number of engines running
+	Weight     *float64   `json:"gross_weight"`    // kg
+	Events     []Event    `json:"events"`
+	Simulator  *Simulator `json:"simulator"`
+}
+
+// Simulator is what the bridge knows about the simulator and aircraft.
+type Simulator struct {
+	Name         string `json:"name"`
+	Version      int    `json:"version"` // FlyWithLua's XPLANE_VERSION, e.g. 12010
+	AircraftICAO string `json:"aircraft_icao"`
+	TailNumber   string `json:"tail_number"`
+}
+
+// String is the simulator's name and major version, e.g. "X-Plane 12".
+func (s Simulator) String() string {
+	name := s.Name
+	if name == "" {
+		name = "X-Plane"
+	}
+	if major := s.Version / 1000; major > 0 {
+		return fmt.Sprintf("%s %d", name, major)
+	}
+	return name
+}
+
+// normalise tidies values FlyWithLua sends padded or quoted.
+func (s *Simulator) normalise() {
+	s.Name = strings.TrimSpace(s.Name)
+	s.AircraftICAO = strings.ToUpper(strings.Trim(s.AircraftICAO, "\" \x00"))
+	s.TailNumber = strings.ToUpper(strings.Trim(s.TailNumber, "\" \x00"))
 }
 
 type Position struct {
-	Lat        float64 `json:"lat"`
-	Lon        float64 `json:"lon"`
-	AltMSL     int     `json:"altitude_msl"`
-	AltAGL     int     `json:"altitude_agl"`
-	GS         int     `json:"gs"`
-	SimTime    string  `json:"sim_time"`
-	DistanceNM int     `json:"distance"`
-	Heading    int     `json:"heading"`
-	IAS        int     `json:"ias"`
-	VSFPM      int     `json:"vs"`
+	Lat        float64  `json:"lat"`
+	Lon        float64  `json:"lon"`
+	AltMSL     *float64 `json:"altitude_msl"`
+	AltAGL     *float64 `json:"altitude_agl"`
+	GS         *float64 `json:"gs"`
+	SimTime    *SimTime `json:"sim_time"`
+	DistanceNM *float64 `json:"distance"`
+	Heading    *float64 `json:"heading"`
+	IAS        *float64 `json:"ias"`
+	VSFPM      *float64 `json:"vs"`
+}
+
+type Lights struct {
+	Beacon  *bool `json:"beacon"`
+	Nav     *bool `json:"nav"`
+	Strobe  *bool `json:"strobe"`
+	Landing *bool `json:"landing"`
+	Taxi    *bool `json:"taxi"`
+}
+
+type Event struct {
+	Log     string   `json:"log"`
+	SimTime *SimTime `json:"sim_time"`
+}
+
+// SimTime is a Unix timestamp in seconds. The Lua bridge has sent it both as
+// a number and as an RFC 3339 string, so both are accepted.
+type SimTime int64
+
+func (t *SimTime) UnmarshalJSON(data []byte) error {
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	switch value := raw.(type) {
+	case nil:
+		return nil
+	case float64:
+		*t = SimTime(value)
+	case string:
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			*t = SimTime(seconds)
+			return nil
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return fmt.Errorf("invalid sim_time %q: %w", value, err)
+		}
+		*t = SimTime(parsed.Unix())
+	default:
+		return fmt.Errorf("invalid sim_time: %s", string(data))
+	}
+
+	return nil
+}
+
+func (t SimTime) Time() time.Time {
+	return time.Unix(int64(t), 0).UTC()
+}
+
+// Float returns the value behind an optional payload field, or 0 if the
+// sender left it out.
+func Float(value *float64) float64 {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
+
+// Int is like Float but rounds to the nearest whole number.
+func Int(value *float64) int {
+	return int(math.Round(Float(value)))
 }
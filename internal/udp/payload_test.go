@@ -139,6 +139,26 @@ func TestUDPPayloadDecode(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "simulator",
+			jsonData: `{"status":"BST","simulator":{"name":"X-Plane","version":12010,"aircraft_icao":"\"b738\"","tail_number":" VH-XPL"}}`,
+			wantErr:  false,
+			validate: func(t *testing.T, payload *Payload) {
+				if payload.Simulator == nil {
+					t.Fatalf("Expected non-nil simulator")
+				}
+				payload.Simulator.normalise()
+				if payload.Simulator.String() != "X-Plane 12" {
+					t.Errorf("Expected X-Plane 12, got %s", payload.Simulator)
+				}
+				if payload.Simulator.AircraftICAO != "B738" {
+					t.Errorf("Expected aircraft B738, got %q", payload.Simulator.AircraftICAO)
+				}
+				if payload.Simulator.TailNumber != "VH-XPL" {
+					t.Errorf("Expected tail VH-XPL, got %q", payload.Simulator.TailNumber)
+				}
+			},
+		},
 		{
 			name:     "invalid json",
 			jsonData: `{"status":"ENR"`,
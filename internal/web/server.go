@@ -0,0 +1,205 @@
+package web
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/control"
+)
+
+//go:embed static
+var static embed.FS
+
+// maxPathPoints bounds the track sent to a new client, longer tracks are
+// thinned out evenly.
+const maxPathPoints = 2000
+
+// noError is what udp.Metrics reports before the first update was sent.
+const noError = "(none)"
+
+// Update is what the dashboard receives every interval.
+type Update struct {
+	Time    time.Time               `json:"time"`
+	Status  control.Status          `json:"status"`
+	Metrics control.MetricsSnapshot `json:"metrics"`
+	API     APIHealth               `json:"api"`
+	// Path is the track so far as [lat, lon] pairs. It is only sent to new
+	// clients and when the active PIREP changes, clients add each position
+	// themselves in between.
+	Path *[][2]float64 `json:"path,omitempty"`
+}
+
+type APIHealth struct {
+	OK            bool   `json:"ok"`
+	FlightError   string `json:"flight_error,omitempty"`
+	PositionError string `json:"position_error,omitempty"`
+	PendingACARS  int    `json:"pending_acars"`
+}
+
+// Server serves the dashboard to browsers on the local network.
+type Server struct {
+	Local    *control.Local
+	Interval time.Duration
+	Logger   *slog.Logger
+}
+
+func NewServer(local *control.Local, logger *slog.Logger) *Server {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &Server{
+		Local:    local,
+		Interval: time.Second,
+		Logger:   logger,
+	}
+}
+
+func (s *Server) Handler() http.Handler {
+	files, _ := fs.Sub(static, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(files))
+	mux.HandleFunc("GET /api/update", s.handleUpdate)
+	mux.HandleFunc("GET /ws", s.handleWebSocket)
+	return lanOnly(mux, s.Logger)
+}
+
+// Serve listens on addr until ctx is cancelled.
+func (s *Server) Serve(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on web address: %w", err)
+	}
+
+	server := &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	s.Logger.Info("Web dashboard listening", "addr", listener.Addr().String())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// lanOnly refuses clients that aren't on this machine or the local network,
+// in case the address is reachable from further afield.
+func lanOnly(next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		ip := net.ParseIP(host)
+		if err != nil || ip == nil || !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast()) {
+			logger.Debug("Refused dashboard client", "remote", r.RemoteAddr)
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	update, _ := s.update(r.Context(), true)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(update); err != nil {
+		s.Logger.Debug("Failed to write dashboard update", "error", err)
+	}
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrade(w, r)
+	if err != nil {
+		s.Logger.Debug("WebSocket upgrade failed", "error", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer conn.Close()
+
+	closed := make(chan error, 1)
+	go func() { closed <- conn.readLoop() }()
+
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	pirepID := ""
+	withPath := true
+	for {
+		update, currentID := s.update(r.Context(), withPath)
+		data, err := json.Marshal(update)
+		if err != nil {
+			s.Logger.Debug("Failed to encode dashboard update", "error", err)
+			return
+		}
+		if err := conn.WriteText(data); err != nil {
+			return
+		}
+		pirepID = currentID
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closed:
+			return
+		case <-ticker.C:
+		}
+
+		withPath = s.activePirepID() != pirepID
+	}
+}
+
+func (s *Server) activePirepID() string {
+	if id := s.Local.Service.GetActivePirepID(); id != nil {
+		return *id
+	}
+	return ""
+}
+
+// update gathers the dashboard state, and returns it with the ID of the
+// PIREP it is for.
+func (s *Server) update(ctx context.Context, withPath bool) (Update, string) {
+	update := Update{Time: time.Now().UTC()}
+
+	// Neither fails in a running PXP, which is all the dashboard runs in
+	update.Status, _ = s.Local.Status(ctx)
+	update.Metrics, _ = s.Local.Snapshot(ctx)
+
+	update.API = APIHealth{PendingACARS: update.Status.PendingACARS}
+	if update.Metrics.UpdateFlightErr != noError {
+		update.API.FlightError = update.Metrics.UpdateFlightErr
+	}
+	if update.Metrics.UpdatePositionErr != noError {
+		update.API.PositionError = update.Metrics.UpdatePositionErr
+	}
+	update.API.OK = update.API.FlightError == "" && update.API.PositionError == ""
+
+	if withPath {
+		path := [][2]float64{}
+		// The points are only copied if they're for the PIREP being shown
+		if meta, ok := s.Local.Service.Recorder.Meta(); ok && meta.PirepID == update.Status.PirepID {
+			if current := s.Local.Service.GetTrack(); current != nil {
+				step := max(1, (len(current.Points)+maxPathPoints-1)/maxPathPoints)
+				for i := 0; i < len(current.Points); i += step {
+					path = append(path, [2]float64{current.Points[i].Lat, current.Points[i].Lon})
+				}
+			}
+		}
+		update.Path = &path
+	}
+	return update, update.Status.PirepID
+}
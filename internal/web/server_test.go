@@ -0,0 +1,161 @@
+package web
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julietrb1/phpvms-xplane/internal/api"
+	"github.com/julietrb1/phpvms-xplane/internal/control"
+	"github.com/julietrb1/phpvms-xplane/internal/secret"
+	"github.com/julietrb1/phpvms-xplane/internal/service"
+	"github.com/julietrb1/phpvms-xplane/internal/udp"
+)
+
+func newTestServer() *Server {
+	flightService := service.NewFlightService(api.NewClient("http://127.0.0.1:9", secret.New("key"), nil), nil)
+	metrics := udp.NewMetrics()
+	metrics.PacketsAny.Add(3)
+	metrics.LastPosition.Store(&udp.Position{Lat: -33.9, Lon: 151.2})
+
+	server := NewServer(control.NewLocal(flightService, metrics), nil)
+	server.Interval = 10 * time.Millisecond
+	return server
+}
+
+func TestWebSocketUpdates(t *testing.T) {
+	httpServer := httptest.NewServer(newTestServer().Handler())
+	defer httpServer.Close()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(httpServer.URL, "http://"))
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	fmt.Fprintf(conn, "GET /ws HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\nOrigin: %s\r\n\r\n",
+		strings.TrimPrefix(httpServer.URL, "http://"), key, httpServer.URL)
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status 101, got %d", resp.StatusCode)
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Expected RFC 6455 accept key, got %q", accept)
+	}
+
+	var first Update
+	if err := json.Unmarshal(readServerFrame(t, reader), &first); err != nil {
+		t.Fatalf("Failed to decode update: %v", err)
+	}
+	if !first.Status.Running || first.Metrics.PacketsAny != 3 || first.Path == nil {
+		t.Errorf("Unexpected first update %+v", first)
+	}
+	if !first.API.OK {
+		t.Errorf("Expected API to be healthy before any update, got %+v", first.API)
+	}
+
+	var second Update
+	if err := json.Unmarshal(readServerFrame(t, reader), &second); err != nil {
+		t.Fatalf("Failed to decode update: %v", err)
+	}
+	if second.Path != nil {
+		t.Errorf("Expected path only in the first update")
+	}
+
+	// A masked close frame from the client is answered and ends the stream
+	conn.Write([]byte{0x80 | opClose, 0x80, 1, 2, 3, 4})
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	for {
+		header := make([]byte, 2)
+		if _, err := io.ReadFull(reader, header); err != nil {
+			t.Fatalf("Expected close frame, got %v", err)
+		}
+		io.CopyN(io.Discard, reader, payloadLength(t, reader, header[1]))
+		if header[0]&0x0F == opClose {
+			break
+		}
+	}
+}
+
+func TestWebSocketRefusesOtherOrigins(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.RemoteAddr = "127.0.0.1:5000"
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", "http://evil.example")
+
+	recorder := httptest.NewRecorder()
+	newTestServer().Handler().ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected cross-origin upgrade to be refused, got %d", recorder.Code)
+	}
+}
+
+func TestLANOnly(t *testing.T) {
+	handler := newTestServer().Handler()
+
+	for remote, expected := range map[string]int{
+		"127.0.0.1:5000":    http.StatusOK,
+		"192.168.1.20:5000": http.StatusOK,
+		"[fe80::1]:5000":    http.StatusOK,
+		"8.8.8.8:5000":      http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remote
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != expected {
+			t.Errorf("Expected %d for %s, got %d", expected, remote, recorder.Code)
+		}
+		if expected == http.StatusOK && !strings.Contains(recorder.Body.String(), "<canvas") {
+			t.Errorf("Expected dashboard page for %s", remote)
+		}
+	}
+}
+
+func readServerFrame(t *testing.T, reader *bufio.Reader) []byte {
+	t.Helper()
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		t.Fatalf("Failed to read frame: %v", err)
+	}
+	if header[0] != 0x80|opText {
+		t.Fatalf("Expected final text frame, got %#x", header[0])
+	}
+	payload := make([]byte, payloadLength(t, reader, header[1]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		t.Fatalf("Failed to read payload: %v", err)
+	}
+	return payload
+}
+
+func payloadLength(t *testing.T, reader *bufio.Reader, length byte) int64 {
+	t.Helper()
+
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		io.ReadFull(reader, extended)
+		return int64(extended[0])<<8 | int64(extended[1])
+	case 127:
+		t.Fatalf("Unexpectedly large frame")
+	}
+	return int64(length)
+}
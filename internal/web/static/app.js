@@ -0,0 +1,220 @@
+"use strict";
+
+const EARTH_RADIUS_NM = 3440.065;
+const ZOOM_LEVELS = [2, 5, 10, 20, 50, 100, 200, 500, 1000]; // nm across the shorter side
+
+const canvas = document.getElementById("map");
+const context = canvas.getContext("2d");
+
+let path = [];
+let position = null;
+let zoom = 4;
+
+function text(id, value, className) {
+  const element = document.getElementById(id);
+  element.textContent = value;
+  element.className = className || "";
+}
+
+function number(value, unit, digits) {
+  if (value === undefined || value === null) {
+    return "-";
+  }
+  return value.toFixed(digits || 0) + (unit ? " " + unit : "");
+}
+
+function minutes(value) {
+  if (value === undefined || value === null) {
+    return "-";
+  }
+  return Math.floor(value / 60) + "h " + String(value % 60).padStart(2, "0") + "m";
+}
+
+function render(update) {
+  const status = update.status;
+  const metrics = update.metrics;
+  position = metrics.last_position || null;
+
+  if (update.path) {
+    path = update.path;
+  }
+  if (status.pirep_id && position && (path.length === 0 || distanceNM(path[path.length - 1], [position.lat, position.lon]) > 0.05)) {
+    path.push([position.lat, position.lon]);
+  }
+
+  if (status.pirep_id) {
+    const route = [status.departure, status.arrival].filter(Boolean).join(" - ");
+    text("flight", [status.flight_number, route].filter(Boolean).join("  ") || status.pirep_id);
+  } else {
+    text("flight", "No active PIREP");
+  }
+  text("state", status.state || "-");
+  text("phase", status.phase || "-");
+  text("altitude", number(position && position.altitude_msl, "ft"));
+  text("gs", number(position && position.gs, "kt"));
+  text("ias", number(position && position.ias, "kt"));
+  text("vs", number(position && position.vs, "fpm"));
+  text("heading", number(position && position.heading, "°"));
+  // Until X-Plane has sent something these are only zero
+  const live = Boolean(metrics.last_packet_time);
+  text("fuel", live ? number(status.fuel, "kg") : "-");
+  text("distance", live ? number(status.distance, "nm") : "-");
+  text("time", live ? minutes(status.flight_time) : "-");
+  text("score", status.score === undefined ? "-" : status.score + (status.violations ? " (" + status.violations + " violations)" : ""));
+
+  const api = update.api;
+  text("api", api.ok ? "OK" : (api.flight_error || api.position_error), api.ok ? "good" : "bad");
+  text("acars", String(api.pending_acars), api.pending_acars > 0 ? "bad" : "");
+  text("packets", metrics.packets_any + (metrics.packets_err ? " (" + metrics.packets_err + " bad)" : ""));
+  if (metrics.last_packet_time) {
+    const age = Math.round((Date.parse(update.time) - Date.parse(metrics.last_packet_time)) / 1000);
+    text("last-packet", age + " s ago", age > 10 ? "bad" : "good");
+  } else {
+    text("last-packet", "never", "bad");
+  }
+
+  draw();
+}
+
+function distanceNM(a, b) {
+  const toRad = Math.PI / 180;
+  const dLat = (b[0] - a[0]) * toRad;
+  const dLon = (b[1] - a[1]) * toRad;
+  const h = Math.sin(dLat / 2) ** 2 + Math.cos(a[0] * toRad) * Math.cos(b[0] * toRad) * Math.sin(dLon / 2) ** 2;
+  return 2 * EARTH_RADIUS_NM * Math.asin(Math.sqrt(h));
+}
+
+function draw() {
+  const ratio = window.devicePixelRatio || 1;
+  const width = canvas.clientWidth;
+  const height = canvas.clientHeight;
+  canvas.width = width * ratio;
+  canvas.height = height * ratio;
+  context.setTransform(ratio, 0, 0, ratio, 0, 0);
+  context.fillStyle = "#11151c";
+  context.fillRect(0, 0, width, height);
+
+  const centre = position ? [position.lat, position.lon] : path[path.length - 1];
+  if (!centre) {
+    context.fillStyle = "#8a93a6";
+    context.font = "16px system-ui, sans-serif";
+    context.textAlign = "center";
+    context.fillText("Waiting for X-Plane", width / 2, height / 2);
+    return;
+  }
+
+  // Equirectangular around the aircraft, fine at the ranges a flight covers
+  const pixelsPerNM = Math.min(width, height) / ZOOM_LEVELS[zoom];
+  const cosLat = Math.cos(centre[0] * Math.PI / 180);
+  const project = (lat, lon) => [
+    width / 2 + (lon - centre[1]) * 60 * cosLat * pixelsPerNM,
+    height / 2 - (lat - centre[0]) * 60 * pixelsPerNM,
+  ];
+
+  drawGrid(width, height, centre, pixelsPerNM, cosLat, project);
+
+  if (path.length > 1) {
+    context.strokeStyle = "#ff5faf";
+    context.lineWidth = 2;
+    context.beginPath();
+    path.forEach((point, i) => {
+      const [x, y] = project(point[0], point[1]);
+      if (i === 0) {
+        context.moveTo(x, y);
+      } else {
+        context.lineTo(x, y);
+      }
+    });
+    context.stroke();
+  }
+
+  if (position) {
+    drawAircraft(width / 2, height / 2, position.heading || 0);
+  }
+  drawScale(height, pixelsPerNM);
+}
+
+function drawGrid(width, height, centre, pixelsPerNM, cosLat, project) {
+  const spanDegrees = Math.max(width, height) / pixelsPerNM / 60 / Math.max(cosLat, 0.1);
+  const step = [0.1, 0.25, 0.5, 1, 2, 5, 10, 20].find((s) => spanDegrees / s <= 12) || 30;
+
+  context.strokeStyle = "#2a3345";
+  context.fillStyle = "#55607a";
+  context.lineWidth = 1;
+  context.font = "11px system-ui, sans-serif";
+  context.textAlign = "left";
+
+  const latStart = Math.floor((centre[0] - spanDegrees) / step) * step;
+  for (let lat = latStart; lat <= centre[0] + spanDegrees; lat += step) {
+    const [, y] = project(lat, centre[1]);
+    context.beginPath();
+    context.moveTo(0, y);
+    context.lineTo(width, y);
+    context.stroke();
+    context.fillText(lat.toFixed(step < 1 ? 2 : 0) + "°", 4, y - 2);
+  }
+
+  const lonStart = Math.floor((centre[1] - spanDegrees) / step) * step;
+  for (let lon = lonStart; lon <= centre[1] + spanDegrees; lon += step) {
+    const [x] = project(centre[0], lon);
+    context.beginPath();
+    context.moveTo(x, 0);
+    context.lineTo(x, height);
+    context.stroke();
+    context.fillText(lon.toFixed(step < 1 ? 2 : 0) + "°", x + 2, 12);
+  }
+}
+
+function drawAircraft(x, y, heading) {
+  context.save();
+  context.translate(x, y);
+  context.rotate(heading * Math.PI / 180);
+  context.fillStyle = "#e6e9ef";
+  context.beginPath();
+  context.moveTo(0, -12);
+  context.lineTo(8, 10);
+  context.lineTo(0, 5);
+  context.lineTo(-8, 10);
+  context.closePath();
+  context.fill();
+  context.restore();
+}
+
+function drawScale(height, pixelsPerNM) {
+  const nm = ZOOM_LEVELS[zoom] / 5;
+  const length = nm * pixelsPerNM;
+  context.strokeStyle = "#e6e9ef";
+  context.fillStyle = "#e6e9ef";
+  context.lineWidth = 2;
+  context.beginPath();
+  context.moveTo(12, height - 16);
+  context.lineTo(12 + length, height - 16);
+  context.stroke();
+  context.font = "12px system-ui, sans-serif";
+  context.textAlign = "left";
+  context.fillText(nm + " nm", 12, height - 22);
+}
+
+function connect() {
+  const scheme = location.protocol === "https:" ? "wss://" : "ws://";
+  const socket = new WebSocket(scheme + location.host + "/ws");
+
+  socket.onopen = () => text("connection", "Live", "good");
+  socket.onmessage = (event) => render(JSON.parse(event.data));
+  socket.onclose = () => {
+    text("connection", "Reconnecting", "bad");
+    setTimeout(connect, 2000);
+  };
+}
+
+document.getElementById("zoom-in").onclick = () => {
+  zoom = Math.max(0, zoom - 1);
+  draw();
+};
+document.getElementById("zoom-out").onclick = () => {
+  zoom = Math.min(ZOOM_LEVELS.length - 1, zoom + 1);
+  draw();
+};
+window.addEventListener("resize", draw);
+
+connect();
@@ -2,13 +2,70 @@ package models
 
 import "fmt"
 
+// AircraftStatus is whether an aircraft is in service, e.g. "A" for active.
+type AircraftStatus string
+
+const (
+	AircraftStatusActive      AircraftStatus = "A"
+	AircraftStatusMaintenance AircraftStatus = "M"
+	AircraftStatusStored      AircraftStatus = "S"
+	AircraftStatusRetired     AircraftStatus = "R"
+	AircraftStatusScrapped    AircraftStatus = "C"
+	AircraftStatusWrittenOff  AircraftStatus = "W"
+)
+
+// AircraftState is whether an aircraft is free to fly.
+type AircraftState int
+
+const (
+	AircraftStateParked AircraftState = 0
+	AircraftStateInUse  AircraftState = 1
+	AircraftStateInAir  AircraftState = 2
+)
+
 type Aircraft struct {
 	ID           int
+	SubfleetID   int `json:"subfleet_id"`
 	Registration string
 	ICAO         string
 	Name         string
+	// AirportID is where the aircraft is, if phpVMS tracks aircraft locations.
+	AirportID   string         `json:"airport_id"`
+	HubID       string         `json:"hub_id"`
+	Status      AircraftStatus `json:"status"`
+	State       AircraftState  `json:"state"`
+	FuelOnboard Weights        `json:"fuel_onboard"`
 }
 
 func (a Aircraft) FilterValue() string {
 	return fmt.Sprintf("%s %s %s", a.Registration, a.ICAO, a.Name)
 }
+
+// Available reports whether the aircraft can be flown: in service, and not
+// already in use on another flight. Older phpVMS versions don't send a status.
+func (a Aircraft) Available() bool {
+	return (a.Status == "" || a.Status == AircraftStatusActive) && a.State == AircraftStateParked
+}
+
+// StatusText describes the aircraft's status, e.g. "parked" or "stored".
+func (a Aircraft) StatusText() string {
+	switch a.Status {
+	case AircraftStatusMaintenance:
+		return "in maintenance"
+	case AircraftStatusStored:
+		return "stored"
+	case AircraftStatusRetired:
+		return "retired"
+	case AircraftStatusScrapped:
+		return "scrapped"
+	case AircraftStatusWrittenOff:
+		return "written off"
+	}
+	switch a.State {
+	case AircraftStateInUse:
+		return "in use"
+	case AircraftStateInAir:
+		return "in flight"
+	}
+	return "parked"
+}
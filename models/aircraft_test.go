@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestAircraftUnmarshal(t *testing.T) {
+	tests := []struct {
+		name      string
+		json      string
+		fuelKg    float64
+		status    string
+		available bool
+	}{
+		{"fuel in units", `{"id": 1, "status": "A", "state": 0, "fuel_onboard": {"kg": 2000, "lbs": 4409.2}}`, 2000, "parked", true},
+		{"fuel in pounds", `{"id": 1, "status": "A", "state": 1, "fuel_onboard": 4409.2452}`, 2000, "in use", false},
+		{"stored", `{"id": 1, "status": "S", "state": 0, "fuel_onboard": null}`, 0, "stored", false},
+		{"no status", `{"id": 1, "registration": "VH-ABC"}`, 0, "parked", true},
+	}
+
+	for _, tt := range tests {
+		var aircraft Aircraft
+		if err := json.Unmarshal([]byte(tt.json), &aircraft); err != nil {
+			t.Errorf("%s: Expected no error, got %v", tt.name, err)
+			continue
+		}
+		if math.Abs(aircraft.FuelOnboard.Kg-tt.fuelKg) > 0.5 {
+			t.Errorf("%s: Expected %.0f kg fuel, got %.1f", tt.name, tt.fuelKg, aircraft.FuelOnboard.Kg)
+		}
+		if aircraft.StatusText() != tt.status {
+			t.Errorf("%s: Expected status %q, got %q", tt.name, tt.status, aircraft.StatusText())
+		}
+		if aircraft.Available() != tt.available {
+			t.Errorf("%s: Expected available %t, got %t", tt.name, tt.available, aircraft.Available())
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package models
+
+type FareType int
+
+const (
+	FareTypePassenger FareType = 0
+	FareTypeCargo     FareType = 1
+)
+
+type Fare struct {
+	ID   int      `json:"id"`
+	Code string   `json:"code"`
+	Name string   `json:"name"`
+	Type FareType `json:"type"`
+}
@@ -2,9 +2,12 @@ package models
 
 import (
 	"encoding/json"
+	"strconv"
 	"time"
 )
 
+const kgPerLb = 0.45359237
+
 type Distances struct {
 	M   float64 `json:"m"`
 	Km  float64 `json:"km"`
@@ -17,6 +20,19 @@ type Weights struct {
 	Lbs float64 `json:"lbs"`
 }
 
+// UnmarshalJSON takes phpVMS's weight in each unit, or a bare number of
+// pounds as some versions send.
+func (w *Weights) UnmarshalJSON(data []byte) error {
+	var lbs float64
+	if err := json.Unmarshal(data, &lbs); err == nil {
+		*w = Weights{Kg: lbs * kgPerLb, Lbs: lbs}
+		return nil
+	}
+
+	type weights Weights
+	return json.Unmarshal(data, (*weights)(w))
+}
+
 type Airport struct {
 	ID                 string     `json:"id"`
 	IATA               string     `json:"iata"`
@@ -117,3 +133,54 @@ type ListedPIREP struct {
 	} `json:"aircraft"`
 	Fields json.RawMessage `json:"fields"`
 }
+
+// FieldValues decodes the PIREP's custom fields. phpVMS sends them either as an
+// object of name to value or as a list of field resources, and values aren't
+// always strings; anything unreadable is left out.
+func (p ListedPIREP) FieldValues() map[string]string {
+	values := map[string]string{}
+	if len(p.Fields) == 0 {
+		return values
+	}
+
+	var object map[string]interface{}
+	if err := json.Unmarshal(p.Fields, &object); err == nil {
+		for name, value := range object {
+			if text, ok := fieldText(value); ok {
+				values[name] = text
+			}
+		}
+		return values
+	}
+
+	var list []struct {
+		Name  string      `json:"name"`
+		Slug  string      `json:"slug"`
+		Value interface{} `json:"value"`
+	}
+	if err := json.Unmarshal(p.Fields, &list); err == nil {
+		for _, field := range list {
+			name := field.Name
+			if name == "" {
+				name = field.Slug
+			}
+			if text, ok := fieldText(field.Value); ok && name != "" {
+				values[name] = text
+			}
+		}
+	}
+	return values
+}
+
+func fieldText(value interface{}) (string, bool) {
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(value), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFieldValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields string
+		want   map[string]string
+	}{
+		{"missing", ``, map[string]string{}},
+		{"null", `null`, map[string]string{}},
+		{"empty list", `[]`, map[string]string{}},
+		{"object", `{"Network Callsign Used": "QFA1", "Pax": 180, "Online": true, "Nested": {"a": 1}}`,
+			map[string]string{"Network Callsign Used": "QFA1", "Pax": "180", "Online": "true"}},
+		{"list", `[{"name": "Network Callsign Used", "slug": "network-callsign-used", "value": "QFA1"}, {"slug": "score", "value": 95.5}, {"name": "Empty", "value": null}]`,
+			map[string]string{"Network Callsign Used": "QFA1", "score": "95.5"}},
+		{"unreadable", `"text"`, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		pirep := ListedPIREP{Fields: json.RawMessage(tt.fields)}
+		got := pirep.FieldValues()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Expected %v, got %v", tt.name, tt.want, got)
+			continue
+		}
+		for name, value := range tt.want {
+			if got[name] != value {
+				t.Errorf("%s: Expected %s to be %q, got %q", tt.name, name, value, got[name])
+			}
+		}
+	}
+}
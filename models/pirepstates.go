@@ -54,6 +54,11 @@ func (s PirepState) CanFile() bool {
 	return s == PIREPStateInProgress || s == PIREPStateDraft || s == PIREPStatePaused
 }
 
+// CanResume is true for PIREPs that can be flown again after PXP restarts.
+func (s PirepState) CanResume() bool {
+	return s == PIREPStateInProgress || s == PIREPStatePaused
+}
+
 type StateMachine struct {
 	CurrentState PirepState
 }